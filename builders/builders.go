@@ -17,13 +17,19 @@ package builders
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"fmt"
 
+	"github.com/greymatter-io/nautls/encoding"
+	"github.com/greymatter-io/nautls/internal/keys"
 	"github.com/greymatter-io/nautls/internal/urls"
+	"github.com/greymatter-io/nautls/spiffe"
 	"github.com/pkg/errors"
+	"software.sslmate.com/src/go-pkcs12"
 )
 
-// BuildCertificatePool provides a utility function for creating a certificate pool from an array of resources. Note that if
-// the array of URLs is empty the system certificates will be used.
+// BuildCertificatePool provides a utility function for creating a certificate pool from an array of resources. Each
+// resource may hold PEM encoded certificates or a SPIFFE bundle document whose X.509-SVID authorities are added. Note
+// that if the array of URLs is empty the system certificates will be used.
 func BuildCertificatePool(certificateResources []string) (*x509.CertPool, error) {
 
 	if len(certificateResources) == 0 {
@@ -38,6 +44,20 @@ func BuildCertificatePool(certificateResources []string) (*x509.CertPool, error)
 			return nil, errors.Wrapf(err, "error reading certificate [%s]", certificateResource)
 		}
 
+		if spiffe.IsBundle(bytes) {
+
+			bundle, err := spiffe.ParseBundle(bytes)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error parsing spiffe bundle [%s]", certificateResource)
+			}
+
+			for _, authority := range bundle.Authorities {
+				pool.AddCert(authority)
+			}
+
+			continue
+		}
+
 		if !pool.AppendCertsFromPEM(bytes) {
 			return nil, errors.Wrapf(err, "error appending certificate from [%s]", certificateResource)
 		}
@@ -46,8 +66,58 @@ func BuildCertificatePool(certificateResources []string) (*x509.CertPool, error)
 	return pool, nil
 }
 
+// BuildAuthorityIndex provides a utility function for mapping the certificates of an array of certificate authority
+// resources to the resource that provided them. Each resource may hold PEM encoded certificates or a SPIFFE bundle
+// document and the keys of the index are the DER encoded certificates.
+func BuildAuthorityIndex(certificateResources []string) (map[string]string, error) {
+
+	index := map[string]string{}
+	for _, certificateResource := range certificateResources {
+
+		bytes, err := readResource(certificateResource)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error reading certificate [%s]", certificateResource)
+		}
+
+		var certificates []*x509.Certificate
+
+		if spiffe.IsBundle(bytes) {
+
+			bundle, err := spiffe.ParseBundle(bytes)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error parsing spiffe bundle [%s]", certificateResource)
+			}
+
+			certificates = bundle.Authorities
+
+		} else {
+
+			certificates, err = encoding.PEMDecodeCertificates(bytes)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error decoding certificates from [%s]", certificateResource)
+			}
+		}
+
+		for _, certificate := range certificates {
+			if _, ok := index[string(certificate.Raw)]; !ok {
+				index[string(certificate.Raw)] = certificateResource
+			}
+		}
+	}
+
+	return index, nil
+}
+
 // BuildCertificates provides a utility function for loading a certificate from certificate and key resources.
 func BuildCertificates(certificateResource string, keyResource string) ([]tls.Certificate, error) {
+	return BuildCertificatesWithPassphrase(certificateResource, keyResource, "")
+}
+
+// BuildCertificatesWithPassphrase provides a utility function for loading a certificate from certificate and key
+// resources where the key may be encrypted (i.e., a legacy "Proc-Type: 4,ENCRYPTED" block or an encrypted PKCS #8
+// block) with the passphrase read from the passphrase resource. Note that the passphrase is ignored if the key is not
+// encrypted.
+func BuildCertificatesWithPassphrase(certificateResource string, keyResource string, passphraseResource string) ([]tls.Certificate, error) {
 
 	certificates := []tls.Certificate{}
 
@@ -55,7 +125,7 @@ func BuildCertificates(certificateResource string, keyResource string) ([]tls.Ce
 		return certificates, nil
 	}
 
-	certificate, err := readKeyPair(certificateResource, keyResource)
+	certificate, err := readKeyPair(certificateResource, keyResource, passphraseResource)
 	if err != nil {
 		return nil, errors.Wrapf(err, "error loading key pair from [%s] and [%s]", certificateResource, keyResource)
 	}
@@ -63,8 +133,72 @@ func BuildCertificates(certificateResource string, keyResource string) ([]tls.Ce
 	return append(certificates, certificate), nil
 }
 
-// readKeyPair reads an X.509 key pair from certificate and key resources.
-func readKeyPair(certificateResource string, keyResource string) (tls.Certificate, error) {
+// BuildPKCS12Certificates provides a utility function for loading a certificate, its chain and its key from a PKCS #12
+// archive resource with the password read from the passphrase resource. Note that an empty slice is returned when the
+// archive resource is empty.
+func BuildPKCS12Certificates(pkcs12Resource string, passphraseResource string) ([]tls.Certificate, error) {
+
+	certificates := []tls.Certificate{}
+
+	if pkcs12Resource == "" {
+		return certificates, nil
+	}
+
+	certificate, err := readPKCS12(pkcs12Resource, passphraseResource)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error loading pkcs12 from [%s]", pkcs12Resource)
+	}
+
+	return append(certificates, certificate), nil
+}
+
+// BuildIdentityCertificates provides a utility function for loading a certificate from either certificate and key
+// resources or a PKCS #12 archive resource with the passphrase read from the passphrase resource. Note that an error is
+// returned when both are provided and that an empty slice is returned when neither is provided.
+func BuildIdentityCertificates(certificateResource string, keyResource string, pkcs12Resource string, passphraseResource string) ([]tls.Certificate, error) {
+
+	if pkcs12Resource == "" {
+		return BuildCertificatesWithPassphrase(certificateResource, keyResource, passphraseResource)
+	}
+
+	if (certificateResource != "") || (keyResource != "") {
+		return nil, errors.New(fmt.Sprintf("certificate and key must not be defined with pkcs12 [%s]", pkcs12Resource))
+	}
+
+	return BuildPKCS12Certificates(pkcs12Resource, passphraseResource)
+}
+
+// BuildConnectionVerifier provides a utility function for combining tls.Config VerifyConnection functions into a single
+// function that returns the first error. Note that nil functions are ignored and nil is returned if all are nil.
+func BuildConnectionVerifier(verifiers ...func(tls.ConnectionState) error) func(tls.ConnectionState) error {
+
+	combined := []func(tls.ConnectionState) error{}
+	for _, verifier := range verifiers {
+		if verifier != nil {
+			combined = append(combined, verifier)
+		}
+	}
+
+	if len(combined) == 0 {
+		return nil
+	}
+
+	return func(state tls.ConnectionState) error {
+
+		for _, verifier := range combined {
+
+			err := verifier(state)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// readKeyPair reads an X.509 key pair from certificate, key and passphrase resources.
+func readKeyPair(certificateResource string, keyResource string, passphraseResource string) (tls.Certificate, error) {
 
 	certificateBytes, err := readResource(certificateResource)
 	if err != nil {
@@ -76,9 +210,50 @@ func readKeyPair(certificateResource string, keyResource string) (tls.Certificat
 		return tls.Certificate{}, errors.Wrapf(err, "error reading key [%s]", keyResource)
 	}
 
+	passphrase, err := keys.ReadPassphrase(passphraseResource)
+	if err != nil {
+		return tls.Certificate{}, errors.Wrapf(err, "error reading passphrase [%s]", passphraseResource)
+	}
+
+	keyBytes, err = keys.Decrypt(keyBytes, passphrase)
+	if err != nil {
+		return tls.Certificate{}, errors.Wrapf(err, "error decrypting key [%s]", keyResource)
+	}
+
 	return tls.X509KeyPair(certificateBytes, keyBytes)
 }
 
+// readPKCS12 reads an X.509 certificate, its chain and its key from PKCS #12 archive and passphrase resources.
+func readPKCS12(pkcs12Resource string, passphraseResource string) (tls.Certificate, error) {
+
+	bytes, err := readResource(pkcs12Resource)
+	if err != nil {
+		return tls.Certificate{}, errors.Wrapf(err, "error reading pkcs12 [%s]", pkcs12Resource)
+	}
+
+	passphrase, err := keys.ReadPassphrase(passphraseResource)
+	if err != nil {
+		return tls.Certificate{}, errors.Wrapf(err, "error reading passphrase [%s]", passphraseResource)
+	}
+
+	key, leaf, chain, err := pkcs12.DecodeChain(bytes, string(passphrase))
+	if err != nil {
+		return tls.Certificate{}, errors.Wrapf(err, "error decoding pkcs12 [%s]", pkcs12Resource)
+	}
+
+	certificate := tls.Certificate{
+		Certificate: [][]byte{leaf.Raw},
+		Leaf:        leaf,
+		PrivateKey:  key,
+	}
+
+	for _, authority := range chain {
+		certificate.Certificate = append(certificate.Certificate, authority.Raw)
+	}
+
+	return certificate, nil
+}
+
 // readResource reads a resource URL into a byte array.
 func readResource(resource string) ([]byte, error) {
 
@@ -0,0 +1,153 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builders
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// pinPrefix defines the optional prefix of a pin (e.g., "sha256/AAAA...").
+const pinPrefix = "sha256/"
+
+// BuildPins provides a utility function for decoding SHA-256 fingerprints from an array of pins. Each pin must be the
+// hex (optionally colon separated) or base64 encoding of the fingerprint of a certificate or of its subject public key
+// info (i.e., SPKI) and may be prefixed with "sha256/".
+func BuildPins(pins []string) ([][]byte, error) {
+
+	decoded := [][]byte{}
+
+	for _, pin := range pins {
+
+		fingerprint, err := decodePin(pin)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error decoding pin [%s]", pin)
+		}
+
+		decoded = append(decoded, fingerprint)
+	}
+
+	return decoded, nil
+}
+
+// BuildPinVerifier provides a utility function for creating a tls.Config VerifyConnection function that rejects peers
+// unless the SHA-256 fingerprint of a certificate or of the subject public key info of a certificate in the verified
+// chains matches one of the pins. Additional pins may be provided as backups (e.g., for the next key of a peer) as
+// only one must match. When the verified chains are empty (i.e., certificate authority validation is skipped for a self
+// signed peer) only the leaf certificate presented by the peer is checked. Note that nil is returned if the array of
+// pins is empty.
+func BuildPinVerifier(pins []string) (func(tls.ConnectionState) error, error) {
+
+	if len(pins) == 0 {
+		return nil, nil
+	}
+
+	fingerprints, err := BuildPins(pins)
+	if err != nil {
+		return nil, errors.Wrap(err, "error building pins")
+	}
+
+	return func(state tls.ConnectionState) error {
+
+		if len(state.PeerCertificates) == 0 {
+			return errors.New("error verifying pins as the peer did not provide a certificate")
+		}
+
+		candidates := []*x509.Certificate{state.PeerCertificates[0]}
+		seen := map[string]bool{string(state.PeerCertificates[0].Raw): true}
+
+		for _, chain := range state.VerifiedChains {
+			for _, certificate := range chain {
+				if !seen[string(certificate.Raw)] {
+					candidates = append(candidates, certificate)
+					seen[string(certificate.Raw)] = true
+				}
+			}
+		}
+
+		for _, candidate := range candidates {
+			if pinned(candidate, fingerprints) {
+				return nil
+			}
+		}
+
+		presented := []string{}
+		for _, candidate := range candidates {
+			presented = append(presented, presentedPins(candidate)...)
+		}
+
+		return errors.New(fmt.Sprintf("error verifying pins as no presented fingerprint is pinned [%s]", strings.Join(presented, ", ")))
+	}, nil
+}
+
+// decodePin returns the SHA-256 fingerprint encoded by a pin.
+func decodePin(pin string) ([]byte, error) {
+
+	value := strings.TrimPrefix(strings.TrimSpace(pin), pinPrefix)
+
+	if unseparated := strings.ReplaceAll(value, ":", ""); len(unseparated) == hex.EncodedLen(sha256.Size) {
+
+		fingerprint, err := hex.DecodeString(unseparated)
+		if err == nil {
+			return fingerprint, nil
+		}
+	}
+
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding} {
+
+		fingerprint, err := encoding.DecodeString(value)
+		if err == nil && len(fingerprint) == sha256.Size {
+			return fingerprint, nil
+		}
+	}
+
+	return nil, errors.New("pin is not a hex or base64 encoded sha-256 fingerprint")
+}
+
+// pinned returns true if the fingerprint of a certificate or of its subject public key info matches a pin.
+func pinned(certificate *x509.Certificate, fingerprints [][]byte) bool {
+
+	spki := sha256.Sum256(certificate.RawSubjectPublicKeyInfo)
+	raw := sha256.Sum256(certificate.Raw)
+
+	for _, fingerprint := range fingerprints {
+		if bytes.Equal(fingerprint, spki[:]) || bytes.Equal(fingerprint, raw[:]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// presentedPins returns the base64 encoded subject public key info and certificate fingerprints of a certificate for
+// inclusion in errors.
+func presentedPins(certificate *x509.Certificate) []string {
+
+	spki := sha256.Sum256(certificate.RawSubjectPublicKeyInfo)
+	raw := sha256.Sum256(certificate.Raw)
+
+	return []string{
+		fmt.Sprintf("%s spki %s%s", certificate.Subject.CommonName, pinPrefix, base64.StdEncoding.EncodeToString(spki[:])),
+		fmt.Sprintf("%s certificate %s%s", certificate.Subject.CommonName, pinPrefix, base64.StdEncoding.EncodeToString(raw[:])),
+	}
+}
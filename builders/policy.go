@@ -0,0 +1,144 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builders
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	"github.com/pkg/errors"
+)
+
+// policyVariable defines the name of the CEL variable holding the peer certificate.
+const policyVariable = "peer"
+
+// BuildPolicyVerifier provides a utility function for creating a tls.Config VerifyConnection function that rejects
+// peers unless a CEL expression evaluates to true for the verified peer certificate. The certificate is provided as the
+// "peer" variable with the following fields:
+//
+//   - subject and issuer: maps of "commonName", "serialNumber" and "string" (e.g., "CN=web,O=Example") strings and
+//     "country", "locality", "organization", "organizationalUnit", "postalCode", "province" and "streetAddress" lists
+//   - dnsNames, emailAddresses, ipAddresses and uris: lists of the subject alternative names
+//   - serialNumber: the decimal serial number
+//   - notBefore and notAfter: the validity timestamps
+//
+// For example, "peer.subject.organizationalUnit.exists(o, o == 'payments')" allows peers of a single organizational
+// unit. Note that peers without a verified certificate are rejected and that nil is returned if the expression is
+// empty.
+func BuildPolicyVerifier(expression string) (func(tls.ConnectionState) error, error) {
+
+	if expression == "" {
+		return nil, nil
+	}
+
+	environment, err := cel.NewEnv(cel.Variable(policyVariable, cel.MapType(cel.StringType, cel.DynType)))
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating policy environment")
+	}
+
+	ast, issues := environment.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, errors.Wrapf(issues.Err(), "error compiling policy [%s]", expression)
+	}
+
+	if ast.OutputType() != cel.BoolType && ast.OutputType() != cel.DynType {
+		return nil, errors.New(fmt.Sprintf("error compiling policy [%s] with result type [%s]", expression, ast.OutputType()))
+	}
+
+	program, err := environment.Program(ast)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error creating policy program [%s]", expression)
+	}
+
+	return func(state tls.ConnectionState) error {
+
+		if len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+			return errors.New("error authorizing peer without a verified certificate")
+		}
+
+		certificate := state.VerifiedChains[0][0]
+
+		result, _, err := program.Eval(map[string]interface{}{policyVariable: policyPeer(certificate)})
+		if err != nil {
+			return errors.Wrapf(err, "error evaluating policy [%s] for peer [%s]", expression, certificate.Subject)
+		}
+
+		allowed, ok := result.Value().(bool)
+		if !ok {
+			return errors.New(fmt.Sprintf("error evaluating policy [%s] with result [%v]", expression, result.Value()))
+		}
+
+		if !allowed {
+			return errors.New(fmt.Sprintf("error authorizing peer [%s] denied by policy [%s]", certificate.Subject, expression))
+		}
+
+		return nil
+	}, nil
+}
+
+// policyPeer returns the representation of a certificate provided to policies.
+func policyPeer(certificate *x509.Certificate) map[string]interface{} {
+
+	ipAddresses := []string{}
+	for _, address := range certificate.IPAddresses {
+		ipAddresses = append(ipAddresses, address.String())
+	}
+
+	uris := []string{}
+	for _, uri := range certificate.URIs {
+		uris = append(uris, uri.String())
+	}
+
+	return map[string]interface{}{
+		"dnsNames":       nonNil(certificate.DNSNames),
+		"emailAddresses": nonNil(certificate.EmailAddresses),
+		"ipAddresses":    ipAddresses,
+		"issuer":         policyName(certificate.Issuer),
+		"notAfter":       certificate.NotAfter,
+		"notBefore":      certificate.NotBefore,
+		"serialNumber":   certificate.SerialNumber.String(),
+		"subject":        policyName(certificate.Subject),
+		"uris":           uris,
+	}
+}
+
+// policyName returns the representation of a distinguished name provided to policies.
+func policyName(name pkix.Name) map[string]interface{} {
+	return map[string]interface{}{
+		"commonName":         name.CommonName,
+		"country":            nonNil(name.Country),
+		"locality":           nonNil(name.Locality),
+		"organization":       nonNil(name.Organization),
+		"organizationalUnit": nonNil(name.OrganizationalUnit),
+		"postalCode":         nonNil(name.PostalCode),
+		"province":           nonNil(name.Province),
+		"serialNumber":       name.SerialNumber,
+		"streetAddress":      nonNil(name.StreetAddress),
+		"string":             name.String(),
+	}
+}
+
+// nonNil returns an empty list in place of a nil list so that policies may apply list macros.
+func nonNil(values []string) []string {
+
+	if values == nil {
+		return []string{}
+	}
+
+	return values
+}
@@ -0,0 +1,347 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builders
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultReloadInterval defines the minimum duration between checks for changed resources when no interval is provided.
+const DefaultReloadInterval = 30 * time.Second
+
+// Reloader maintains a tls.Config built from resources and rebuilds it when the content of the resources changes (e.g.,
+// when a mounted Kubernetes secret is updated by swapping its "..data" symlink). Changes are checked at most once per
+// interval during handshakes or continuously using Watch. Note that the last good tls.Config continues to be used when
+// reading the resources or rebuilding the tls.Config fails.
+type Reloader struct {
+	build     func(func(error)) (*tls.Config, error)
+	changes   []func(*tls.Config)
+	checked   time.Time
+	checking  bool
+	config    *tls.Config
+	digest    []byte
+	failures  []func(error)
+	interval  time.Duration
+	mutex     sync.RWMutex
+	resources []string
+}
+
+// BuildReloader provides a utility function for creating a Reloader that rebuilds a tls.Config using a build function
+// when the content of an array of resources changes. Note that empty resources are ignored and that the tls.Config is
+// built before returning.
+func BuildReloader(resources []string, interval time.Duration, build func() (*tls.Config, error)) (*Reloader, error) {
+	return BuildReloaderWithFailures(resources, interval, func(func(error)) (*tls.Config, error) { return build() })
+}
+
+// BuildReloaderWithFailures provides a utility function for creating a Reloader like BuildReloader whose build function
+// is passed a function reporting the failures that occur after the tls.Config is built (e.g., when refreshing an OCSP
+// staple in the background) to the functions registered with OnError.
+func BuildReloaderWithFailures(resources []string, interval time.Duration, build func(func(error)) (*tls.Config, error)) (*Reloader, error) {
+
+	if interval <= 0 {
+		interval = DefaultReloadInterval
+	}
+
+	reloader := &Reloader{build: build, interval: interval}
+
+	for _, resource := range resources {
+		if resource != "" {
+			reloader.resources = append(reloader.resources, resource)
+		}
+	}
+
+	digest, err := reloader.read()
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading resources")
+	}
+
+	config, err := build(reloader.report)
+	if err != nil {
+		return nil, errors.Wrap(err, "error building tls configuration")
+	}
+
+	reloader.checked = time.Now()
+	reloader.config = config
+	reloader.digest = digest
+
+	return reloader, nil
+}
+
+// OnChange registers a function invoked with the rebuilt tls.Config after the resources change.
+func (r *Reloader) OnChange(change func(*tls.Config)) {
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.changes = append(r.changes, change)
+}
+
+// OnError registers a function invoked with the error when reading the resources or rebuilding the tls.Config fails.
+func (r *Reloader) OnError(failure func(error)) {
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.failures = append(r.failures, failure)
+}
+
+// Config returns the current tls.Config.
+func (r *Reloader) Config() *tls.Config {
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.config
+}
+
+// Reload reads the resources and rebuilds the tls.Config if their content has changed or returns an error.
+func (r *Reloader) Reload() error {
+
+	digest, err := r.read()
+	if err != nil {
+		return r.fail(errors.Wrap(err, "error reading resources"))
+	}
+
+	r.mutex.RLock()
+	changed := !bytes.Equal(digest, r.digest)
+	r.mutex.RUnlock()
+
+	if !changed {
+		return nil
+	}
+
+	config, err := r.build(r.report)
+	if err != nil {
+		return r.fail(errors.Wrap(err, "error building tls configuration"))
+	}
+
+	r.mutex.Lock()
+	r.config = config
+	r.digest = digest
+	changes := append([]func(*tls.Config){}, r.changes...)
+	r.mutex.Unlock()
+
+	for _, change := range changes {
+		change(config)
+	}
+
+	return nil
+}
+
+// Watch checks for changed resources once per interval until the context is done.
+func (r *Reloader) Watch(ctx context.Context) {
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.Reload()
+		}
+	}
+}
+
+// ServerConfig returns a tls.Config for servers that serves the certificates and enforces the client authentication of
+// the current tls.Config using the GetCertificate and GetConfigForClient functions.
+func (r *Reloader) ServerConfig() *tls.Config {
+
+	return &tls.Config{
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+
+			r.check()
+
+			config := r.Config()
+			if config.GetCertificate != nil {
+				return config.GetCertificate(hello)
+			}
+
+			for index := range config.Certificates {
+				if hello.SupportsCertificate(&config.Certificates[index]) == nil {
+					return &config.Certificates[index], nil
+				}
+			}
+
+			if len(config.Certificates) == 0 {
+				return nil, errors.New("no certificates configured")
+			}
+
+			return &config.Certificates[0], nil
+		},
+		GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			r.check()
+			return r.Config(), nil
+		},
+	}
+}
+
+// ClientConfig returns a tls.Config for clients that presents the certificates of the current tls.Config using the
+// GetClientCertificate function and verifies servers against the authorities of the current tls.Config using the
+// VerifyConnection function. The server name is used for verification when the handshake does not provide one (e.g.,
+// when connecting to an IP address). All other settings (e.g., protocol versions, cipher suites, curve preferences and
+// application protocols) are copied from the current tls.Config.
+//
+// Note that the built-in verification is disabled (i.e., InsecureSkipVerify is set) as it cannot observe changed
+// authorities and is replaced by the VerifyConnection function.
+func (r *Reloader) ClientConfig(server string) *tls.Config {
+
+	config := r.Config().Clone()
+
+	config.Certificates = nil
+	config.GetClientCertificate = func(request *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+
+		r.check()
+
+		config := r.Config()
+		for index := range config.Certificates {
+			if request.SupportsCertificate(&config.Certificates[index]) == nil {
+				return &config.Certificates[index], nil
+			}
+		}
+
+		return &tls.Certificate{}, nil
+	}
+	config.InsecureSkipVerify = true
+	config.ServerName = server
+	config.VerifyConnection = func(state tls.ConnectionState) error {
+		r.check()
+		return verifyServer(r.Config(), state, server)
+	}
+
+	return config
+}
+
+// check reloads the tls.Config in the background if the interval has passed since the last check.
+func (r *Reloader) check() {
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.checking || time.Since(r.checked) < r.interval {
+		return
+	}
+
+	r.checking = true
+
+	go func() {
+
+		r.Reload()
+
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+
+		r.checked = time.Now()
+		r.checking = false
+	}()
+}
+
+// fail invokes the registered error functions and returns the error.
+func (r *Reloader) fail(err error) error {
+
+	r.mutex.RLock()
+	failures := append([]func(error){}, r.failures...)
+	r.mutex.RUnlock()
+
+	for _, failure := range failures {
+		failure(err)
+	}
+
+	return err
+}
+
+// report invokes the registered error functions.
+func (r *Reloader) report(err error) {
+	r.fail(err)
+}
+
+// read returns a digest of the content of the resources.
+func (r *Reloader) read() ([]byte, error) {
+
+	hash := sha256.New()
+
+	for _, resource := range r.resources {
+
+		data, err := readResource(resource)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error reading resource [%s]", resource)
+		}
+
+		binary.Write(hash, binary.BigEndian, uint64(len(data)))
+		hash.Write(data)
+	}
+
+	return hash.Sum(nil), nil
+}
+
+// verifyServer verifies the certificate chain of a server against the authorities of a tls.Config and invokes its
+// VerifyConnection function with the verified chains. Note that the chain is not verified when the tls.Config skips
+// verification (e.g., for pin only configurations).
+func verifyServer(config *tls.Config, state tls.ConnectionState, server string) error {
+
+	if len(state.PeerCertificates) == 0 {
+		return errors.New("server did not provide a certificate")
+	}
+
+	if config.InsecureSkipVerify {
+
+		if config.VerifyConnection != nil {
+			return config.VerifyConnection(state)
+		}
+
+		return nil
+	}
+
+	name := state.ServerName
+	if name == "" {
+		name = server
+	}
+
+	if name == "" {
+		return errors.New("error verifying server certificate: server name is unknown")
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, certificate := range state.PeerCertificates[1:] {
+		intermediates.AddCert(certificate)
+	}
+
+	chains, err := state.PeerCertificates[0].Verify(x509.VerifyOptions{
+		DNSName:       name,
+		Intermediates: intermediates,
+		Roots:         config.RootCAs,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "error verifying server certificate for [%s]", name)
+	}
+
+	state.VerifiedChains = chains
+
+	if config.VerifyConnection != nil {
+		return config.VerifyConnection(state)
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builders
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"time"
+
+	"github.com/greymatter-io/nautls/encoding"
+	"github.com/pkg/errors"
+)
+
+// BuildRevocationLists provides a utility function for loading certificate revocation lists from an array of resources.
+// Each resource may hold PEM encoded "X509 CRL" blocks or a single DER encoded revocation list.
+func BuildRevocationLists(revocationResources []string) ([]*x509.RevocationList, error) {
+
+	lists := []*x509.RevocationList{}
+
+	for _, revocationResource := range revocationResources {
+
+		bytes, err := readResource(revocationResource)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error reading revocation list [%s]", revocationResource)
+		}
+
+		block, _ := pem.Decode(bytes)
+		if block == nil {
+
+			list, err := x509.ParseRevocationList(bytes)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error parsing der revocation list [%s]", revocationResource)
+			}
+
+			lists = append(lists, list)
+			continue
+		}
+
+		decoded, err := encoding.PEMDecodeRevocationLists(bytes)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error decoding revocation list [%s]", revocationResource)
+		}
+
+		lists = append(lists, decoded...)
+	}
+
+	return lists, nil
+}
+
+// BuildRevocationVerifier provides a utility function for creating a tls.Config VerifyConnection function that rejects
+// peers whose certificate chain includes a certificate revoked by the revocation lists loaded from an array of
+// resources. A revocation list is only applied to certificates of the issuer it names and is rejected if it is not
+// signed by that issuer or if its next update has passed. Note that nil is returned if the array of resources is empty.
+func BuildRevocationVerifier(revocationResources []string) (func(tls.ConnectionState) error, error) {
+
+	if len(revocationResources) == 0 {
+		return nil, nil
+	}
+
+	lists, err := BuildRevocationLists(revocationResources)
+	if err != nil {
+		return nil, errors.Wrap(err, "error building revocation lists")
+	}
+
+	return func(state tls.ConnectionState) error {
+
+		chains := state.VerifiedChains
+		if len(chains) == 0 && len(state.PeerCertificates) > 0 {
+			chains = [][]*x509.Certificate{state.PeerCertificates}
+		}
+
+		for _, chain := range chains {
+
+			err := verifyRevocations(chain, lists, time.Now())
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, nil
+}
+
+// verifyRevocations returns an error if a certificate of a chain (ordered from leaf to root) is revoked by a revocation
+// list issued by the next certificate of the chain or if such a revocation list is invalid or stale.
+func verifyRevocations(chain []*x509.Certificate, lists []*x509.RevocationList, now time.Time) error {
+
+	for index := 0; index < len(chain)-1; index++ {
+
+		certificate, issuer := chain[index], chain[index+1]
+
+		for _, list := range lists {
+
+			if !bytes.Equal(list.RawIssuer, issuer.RawSubject) {
+				continue
+			}
+
+			err := list.CheckSignatureFrom(issuer)
+			if err != nil {
+				return errors.Wrapf(err, "error verifying revocation list signature for [%s]", issuer.Subject)
+			}
+
+			if !list.NextUpdate.IsZero() && now.After(list.NextUpdate) {
+				return errors.Errorf("error verifying revocation list for [%s]: stale since [%s]", issuer.Subject, list.NextUpdate)
+			}
+
+			for _, entry := range list.RevokedCertificateEntries {
+				if entry.SerialNumber.Cmp(certificate.SerialNumber) == 0 {
+					return errors.Errorf("certificate [%s] with serial [%s] was revoked by [%s]", certificate.Subject, certificate.SerialNumber, issuer.Subject)
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,199 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builders
+
+import (
+	"crypto/rsa"
+	"crypto/tls"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// SNICertificate represents a certificate served for a set of server names.
+type SNICertificate struct {
+
+	// Certificate defines the certificate served.
+	Certificate tls.Certificate
+
+	// Default defines whether the certificate is served when no server name matches.
+	Default bool
+
+	// Names defines the server names and wildcard patterns (e.g., "*.example.com") for which the certificate is served.
+	// It defaults to the DNS subject alternative names of the leaf certificate.
+	Names []string
+
+	// Stapler defines an optional stapler that provides the certificate with its OCSP staple.
+	Stapler *Stapler
+}
+
+// BuildCertificateSelector provides a utility function for creating a tls.Config GetCertificate function that selects
+// among certificates by the server name of a ClientHello. Certificates with an exactly matching name are preferred over
+// those with a matching wildcard pattern which are preferred over the defaults. Within each of these the first
+// certificate supported by the client (e.g., by signature algorithm) is selected with ECDSA and Ed25519 certificates
+// preferred over RSA certificates so that a name may be served to modern and legacy clients alike. Note that when no
+// certificate is marked as a default the first certificate and those with the same names are the defaults.
+func BuildCertificateSelector(certificates []SNICertificate) (func(*tls.ClientHelloInfo) (*tls.Certificate, error), error) {
+
+	if len(certificates) == 0 {
+		return nil, errors.New("no certificates defined for selection")
+	}
+
+	candidates := make([]SNICertificate, len(certificates))
+	for index, certificate := range certificates {
+
+		if len(certificate.Names) == 0 {
+
+			leaf, err := leaf(certificate.Certificate)
+			if err != nil {
+				return nil, errors.Wrap(err, "error parsing leaf certificate for selection")
+			}
+
+			certificate.Names = leaf.DNSNames
+		}
+
+		candidates[index] = certificate
+	}
+
+	marked := false
+	for _, candidate := range candidates {
+		marked = marked || candidate.Default
+	}
+
+	if !marked {
+		for index := range candidates {
+			candidates[index].Default = sameNames(candidates[index].Names, candidates[0].Names)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return !isRSA(candidates[i]) && isRSA(candidates[j])
+	})
+
+	defaults := []SNICertificate{}
+	for _, candidate := range candidates {
+		if candidate.Default {
+			defaults = append(defaults, candidate)
+		}
+	}
+
+	return func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+
+		name := strings.ToLower(strings.TrimSuffix(hello.ServerName, "."))
+
+		exact := []SNICertificate{}
+		wildcard := []SNICertificate{}
+
+		if name != "" {
+			for _, candidate := range candidates {
+				switch matchName(candidate.Names, name) {
+				case exactMatch:
+					exact = append(exact, candidate)
+				case wildcardMatch:
+					wildcard = append(wildcard, candidate)
+				}
+			}
+		}
+
+		// names are matched above so only the algorithms and versions supported by the client are checked here
+		supported := *hello
+		supported.ServerName = ""
+
+		var fallback *SNICertificate
+
+		for _, tier := range [][]SNICertificate{exact, wildcard, defaults} {
+			for index := range tier {
+
+				if fallback == nil {
+					fallback = &tier[index]
+				}
+
+				if supported.SupportsCertificate(&tier[index].Certificate) == nil {
+					return tier[index].current(hello)
+				}
+			}
+		}
+
+		return fallback.current(hello)
+	}, nil
+}
+
+// current returns the certificate with its current OCSP staple.
+func (c *SNICertificate) current(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+
+	if c.Stapler != nil {
+		return c.Stapler.GetCertificate(hello)
+	}
+
+	return &c.Certificate, nil
+}
+
+// sameNames returns true if two sets of names are equal.
+func sameNames(first []string, second []string) bool {
+
+	if len(first) != len(second) {
+		return false
+	}
+
+	for index := range first {
+		if !strings.EqualFold(first[index], second[index]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isRSA returns true if a certificate has an RSA private key.
+func isRSA(certificate SNICertificate) bool {
+	_, ok := certificate.Certificate.PrivateKey.(*rsa.PrivateKey)
+	return ok
+}
+
+// match defines the quality of a server name match.
+type match int
+
+const (
+	noMatch match = iota
+	wildcardMatch
+	exactMatch
+)
+
+// matchName returns how a server name matches the best of a set of names and wildcard patterns. A wildcard pattern only
+// matches a single leftmost label (i.e., "*.example.com" matches "www.example.com" but not "example.com").
+func matchName(names []string, name string) match {
+
+	result := noMatch
+
+	for _, pattern := range names {
+
+		pattern = strings.ToLower(strings.TrimSuffix(pattern, "."))
+
+		if pattern == name {
+			return exactMatch
+		}
+
+		if strings.HasPrefix(pattern, "*.") {
+
+			index := strings.Index(name, ".")
+			if index > 0 && name[index:] == pattern[1:] {
+				result = wildcardMatch
+			}
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,84 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builders
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+
+	"github.com/greymatter-io/nautls/spiffe"
+	"github.com/pkg/errors"
+)
+
+// BuildSPIFFEVerifier provides a utility function for creating a tls.Config VerifyConnection function that rejects
+// peers unless the SPIFFE ID of the verified peer certificate matches one of the matchers. Note that peers whose
+// certificate was not verified are rejected and that nil is returned if the array of matchers is empty.
+func BuildSPIFFEVerifier(matchers []spiffe.Matcher) (func(tls.ConnectionState) error, error) {
+
+	if len(matchers) == 0 {
+		return nil, nil
+	}
+
+	err := spiffe.Matchers(matchers).Validate()
+	if err != nil {
+		return nil, errors.Wrap(err, "error validating spiffe matchers")
+	}
+
+	return func(state tls.ConnectionState) error {
+
+		id, err := spiffe.FromConnectionState(state)
+		if err != nil {
+			return errors.Wrap(err, "error verifying spiffe id")
+		}
+
+		return spiffe.Matchers(matchers).Match(id)
+	}, nil
+}
+
+// BuildChainVerifier provides a utility function for creating a tls.Config VerifyConnection function that verifies the
+// certificate chain presented by a server against a pool of authorities before invoking a verifier with the verified
+// chains. The server name is only verified when it is not empty. This is most applicable to servers identified by other
+// means (e.g., SPIFFE IDs) as the tls.Config must skip its own verification.
+func BuildChainVerifier(pool *x509.CertPool, server string, verifier func(tls.ConnectionState) error) func(tls.ConnectionState) error {
+
+	return func(state tls.ConnectionState) error {
+
+		if len(state.PeerCertificates) == 0 {
+			return errors.New("server did not provide a certificate")
+		}
+
+		intermediates := x509.NewCertPool()
+		for _, certificate := range state.PeerCertificates[1:] {
+			intermediates.AddCert(certificate)
+		}
+
+		chains, err := state.PeerCertificates[0].Verify(x509.VerifyOptions{
+			DNSName:       server,
+			Intermediates: intermediates,
+			Roots:         pool,
+		})
+		if err != nil {
+			return errors.Wrapf(err, "error verifying server certificate chain")
+		}
+
+		state.VerifiedChains = chains
+
+		if verifier != nil {
+			return verifier(state)
+		}
+
+		return nil
+	}
+}
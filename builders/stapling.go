@@ -0,0 +1,313 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builders
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ocsp"
+)
+
+const (
+	// DefaultStapleRefresh defines the duration after which a staple without a next update is refreshed.
+	DefaultStapleRefresh = time.Hour
+
+	// DefaultStapleRetry defines the duration after which a staple that failed to refresh is refreshed again.
+	DefaultStapleRetry = time.Minute
+
+	// stapleTimeout defines the timeout of requests to OCSP responders.
+	stapleTimeout = 10 * time.Second
+)
+
+// Stapler maintains OCSP staples for a set of certificates. Staples are fetched in the background from the OCSP
+// responders named by the leaf of each certificate or read from a resource and are refreshed halfway between their this
+// update and next update. Note that a certificate is served without a staple until it is first fetched and that a
+// staple that fails to refresh continues to be served until its next update has passed.
+type Stapler struct {
+	cancel       context.CancelFunc
+	certificates []*stapled
+	client       *http.Client
+	closed       bool
+	context      context.Context
+	failures     []func(error)
+	mutex        sync.RWMutex
+}
+
+// stapled represents a certificate with the state of its staple.
+type stapled struct {
+	certificate tls.Certificate
+	issuer      *x509.Certificate
+	nextUpdate  time.Time
+	refreshAt   time.Time
+	refreshing  bool
+	resource    string
+}
+
+// BuildStapler provides a utility function for creating a Stapler for certificates. When the staple resource is empty
+// staples are fetched from the OCSP responders named by each leaf otherwise they are read from the resource which must
+// point to the location of a DER encoded OCSP response. Note that the staples are not fetched until Refresh is invoked
+// or the certificates are requested and that each certificate must include its issuer in its chain.
+func BuildStapler(certificates []tls.Certificate, stapleResource string) (*Stapler, error) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	stapler := &Stapler{cancel: cancel, client: &http.Client{Timeout: stapleTimeout}, context: ctx}
+
+	for _, certificate := range certificates {
+
+		leaf, err := leaf(certificate)
+		if err != nil {
+			return nil, errors.Wrap(err, "error parsing leaf certificate for stapling")
+		}
+
+		if len(certificate.Certificate) < 2 {
+			return nil, fmt.Errorf("error stapling [%s]: chain does not include an issuer", leaf.Subject)
+		}
+
+		issuer, err := x509.ParseCertificate(certificate.Certificate[1])
+		if err != nil {
+			return nil, errors.Wrapf(err, "error parsing issuer of [%s] for stapling", leaf.Subject)
+		}
+
+		certificate.Leaf = leaf
+
+		entry := &stapled{certificate: certificate, issuer: issuer, resource: stapleResource}
+
+		stapler.certificates = append(stapler.certificates, entry)
+	}
+
+	return stapler, nil
+}
+
+// OnError registers a function invoked with the error when fetching or verifying a staple in the background fails.
+func (s *Stapler) OnError(failure func(error)) {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.failures = append(s.failures, failure)
+}
+
+// Refresh starts refreshing the staples that are due in the background (e.g., to fetch the staples of a new Stapler
+// before the certificates are first requested).
+func (s *Stapler) Refresh() {
+
+	now := time.Now()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, entry := range s.certificates {
+		s.refresh(entry, now)
+	}
+}
+
+// Close stops refreshing the staples in the background and cancels the fetches in progress (e.g., when the tls.Config
+// using the stapler is replaced). Note that the certificates continue to be served with their current staples.
+func (s *Stapler) Close() {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.closed = true
+	s.cancel()
+}
+
+// GetCertificate implements the tls.Config GetCertificate function returning the first certificate supported by the
+// client with its current staple.
+func (s *Stapler) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+
+	now := time.Now()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var selected *stapled
+	for _, entry := range s.certificates {
+		if hello == nil || hello.SupportsCertificate(&entry.certificate) == nil {
+			selected = entry
+			break
+		}
+	}
+
+	if selected == nil {
+		if len(s.certificates) == 0 {
+			return nil, nil
+		}
+		selected = s.certificates[0]
+	}
+
+	s.refresh(selected, now)
+
+	certificate := selected.certificate
+	if !selected.nextUpdate.IsZero() && now.After(selected.nextUpdate) {
+		certificate.OCSPStaple = nil
+	}
+
+	return &certificate, nil
+}
+
+// refresh starts refreshing the staple of a certificate in the background when it is due, not already refreshing and the
+// stapler is not closed. Note that the mutex of the stapler must be held.
+func (s *Stapler) refresh(entry *stapled, now time.Time) {
+
+	if !s.closed && !entry.refreshing && now.After(entry.refreshAt) {
+		entry.refreshing = true
+		go s.refreshAsync(entry)
+	}
+}
+
+// refreshAsync refreshes the staple of a certificate in the background. Note that a failed refresh is reported to the
+// registered error functions and retried after DefaultStapleRetry.
+func (s *Stapler) refreshAsync(entry *stapled) {
+
+	data, response, err := s.load(entry, time.Now())
+
+	s.mutex.Lock()
+
+	entry.refreshing = false
+
+	if s.closed {
+		s.mutex.Unlock()
+		return
+	}
+
+	if err == nil {
+		entry.update(data, response)
+		s.mutex.Unlock()
+		return
+	}
+
+	entry.refreshAt = time.Now().Add(DefaultStapleRetry)
+	failures := append([]func(error){}, s.failures...)
+
+	s.mutex.Unlock()
+
+	err = errors.Wrapf(err, "error stapling [%s]", entry.certificate.Leaf.Subject)
+	for _, failure := range failures {
+		failure(err)
+	}
+}
+
+// load fetches and verifies the staple of a certificate.
+func (s *Stapler) load(entry *stapled, now time.Time) ([]byte, *ocsp.Response, error) {
+
+	var data []byte
+	var err error
+
+	if entry.resource != "" {
+		data, err = readResource(entry.resource)
+	} else {
+		data, err = s.fetch(entry.certificate.Leaf, entry.issuer)
+	}
+
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error reading ocsp response")
+	}
+
+	response, err := ocsp.ParseResponseForCert(data, entry.certificate.Leaf, entry.issuer)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error verifying ocsp response")
+	}
+
+	if !response.NextUpdate.IsZero() && now.After(response.NextUpdate) {
+		return nil, nil, fmt.Errorf("error verifying ocsp response: stale since [%s]", response.NextUpdate)
+	}
+
+	return data, response, nil
+}
+
+// update sets the staple of a certificate and schedules its refresh halfway between its this update and next update.
+func (e *stapled) update(data []byte, response *ocsp.Response) {
+
+	e.certificate.OCSPStaple = data
+	e.nextUpdate = response.NextUpdate
+	e.refreshAt = time.Now().Add(DefaultStapleRefresh)
+
+	if !response.NextUpdate.IsZero() {
+		e.refreshAt = response.ThisUpdate.Add(response.NextUpdate.Sub(response.ThisUpdate) / 2)
+	}
+}
+
+// fetch requests an OCSP response for a leaf from the OCSP responders it names.
+func (s *Stapler) fetch(leaf *x509.Certificate, issuer *x509.Certificate) ([]byte, error) {
+
+	if len(leaf.OCSPServer) == 0 {
+		return nil, fmt.Errorf("no ocsp responders defined by [%s]", leaf.Subject)
+	}
+
+	request, err := ocsp.CreateRequest(leaf, issuer, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating ocsp request")
+	}
+
+	var last error
+	for _, server := range leaf.OCSPServer {
+
+		post, err := http.NewRequestWithContext(s.context, http.MethodPost, server, bytes.NewReader(request))
+		if err != nil {
+			last = err
+			continue
+		}
+
+		post.Header.Set("Content-Type", "application/ocsp-request")
+
+		response, err := s.client.Do(post)
+		if err != nil {
+			last = err
+			continue
+		}
+
+		data, err := io.ReadAll(response.Body)
+		response.Body.Close()
+
+		if err != nil {
+			last = err
+			continue
+		}
+
+		if response.StatusCode != http.StatusOK {
+			last = fmt.Errorf("unexpected status [%d] from [%s]", response.StatusCode, server)
+			continue
+		}
+
+		return data, nil
+	}
+
+	return nil, errors.Wrapf(last, "error fetching ocsp response for [%s]", leaf.Subject)
+}
+
+// leaf returns the parsed leaf of a certificate.
+func leaf(certificate tls.Certificate) (*x509.Certificate, error) {
+
+	if certificate.Leaf != nil {
+		return certificate.Leaf, nil
+	}
+
+	if len(certificate.Certificate) == 0 {
+		return nil, errors.New("certificate is empty")
+	}
+
+	return x509.ParseCertificate(certificate.Certificate[0])
+}
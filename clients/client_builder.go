@@ -35,6 +35,11 @@ func (b *ClientBuilder) Build() (*http.Client, error) {
 	return b.config.Build()
 }
 
+// BuildDialer creates a Dialer for TLS connections from the ClientBuilder.
+func (b *ClientBuilder) BuildDialer() (*Dialer, error) {
+	return b.config.Dialer()
+}
+
 // WithHost sets the hostname or address of the client.
 func (b *ClientBuilder) WithHost(host string) *ClientBuilder {
 	b.config.Host = host
@@ -47,8 +52,87 @@ func (b *ClientBuilder) WithPort(port int) *ClientBuilder {
 	return b
 }
 
+// WithRoutes sets the addresses and TLS configurations used for the requests to specific hosts.
+func (b *ClientBuilder) WithRoutes(routes []Route) *ClientBuilder {
+	b.config.Routes = routes
+	return b
+}
+
 // WithSecurity sets the TLS configuration of the client.
 func (b *ClientBuilder) WithSecurity(security SecurityConfig) *ClientBuilder {
 	b.config.Security = security
 	return b
 }
+
+// WithDialTimeout sets the maximum duration (e.g., "5s") for establishing a connection.
+func (b *ClientBuilder) WithDialTimeout(timeout string) *ClientBuilder {
+	b.config.DialTimeout = timeout
+	return b
+}
+
+// WithDisableKeepAlives sets whether each connection is used for a single request only.
+func (b *ClientBuilder) WithDisableKeepAlives(disable bool) *ClientBuilder {
+	b.config.DisableKeepAlives = disable
+	return b
+}
+
+// WithHandshakeTimeout sets the maximum duration (e.g., "10s") for completing a TLS handshake.
+func (b *ClientBuilder) WithHandshakeTimeout(timeout string) *ClientBuilder {
+	b.config.HandshakeTimeout = timeout
+	return b
+}
+
+// WithHTTP2 sets whether HTTP/2 is negotiated with TLS servers that support it.
+func (b *ClientBuilder) WithHTTP2(enabled bool) *ClientBuilder {
+	b.config.HTTP2 = enabled
+	return b
+}
+
+// WithIdleConnTimeout sets the maximum duration (e.g., "90s") an idle connection is kept in the pool.
+func (b *ClientBuilder) WithIdleConnTimeout(timeout string) *ClientBuilder {
+	b.config.IdleConnTimeout = timeout
+	return b
+}
+
+// WithKeepAlive sets the interval (e.g., "30s") between TCP keep-alive probes of connections.
+func (b *ClientBuilder) WithKeepAlive(interval string) *ClientBuilder {
+	b.config.KeepAlive = interval
+	return b
+}
+
+// WithMaxConnsPerHost sets the maximum number of connections per host including those in use.
+func (b *ClientBuilder) WithMaxConnsPerHost(max int) *ClientBuilder {
+	b.config.MaxConnsPerHost = max
+	return b
+}
+
+// WithMaxIdleConns sets the maximum number of idle connections across all hosts.
+func (b *ClientBuilder) WithMaxIdleConns(max int) *ClientBuilder {
+	b.config.MaxIdleConns = max
+	return b
+}
+
+// WithMaxIdleConnsPerHost sets the maximum number of idle connections per host.
+func (b *ClientBuilder) WithMaxIdleConnsPerHost(max int) *ClientBuilder {
+	b.config.MaxIdleConnsPerHost = max
+	return b
+}
+
+// WithProxy sets the URL of the proxy through which requests are sent.
+func (b *ClientBuilder) WithProxy(proxy string) *ClientBuilder {
+	b.config.Proxy = proxy
+	return b
+}
+
+// WithProxyFromEnvironment sets whether the proxy is read from the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment
+// variables.
+func (b *ClientBuilder) WithProxyFromEnvironment(enabled bool) *ClientBuilder {
+	b.config.ProxyFromEnvironment = enabled
+	return b
+}
+
+// WithResponseHeaderTimeout sets the maximum duration (e.g., "30s") to wait for the headers of a response.
+func (b *ClientBuilder) WithResponseHeaderTimeout(timeout string) *ClientBuilder {
+	b.config.ResponseHeaderTimeout = timeout
+	return b
+}
@@ -51,6 +51,17 @@ func TestClientBuilder(t *testing.T) {
 			})
 		})
 
+		Convey(".WithRoutes is invoked", func() {
+
+			routes := []Route{{Address: "127.0.0.1:8443", Host: tests.MustGenerateString(t)}}
+
+			builder.WithRoutes(routes)
+
+			Convey("it sets the routes", func() {
+				So(builder.config.Routes, ShouldResemble, routes)
+			})
+		})
+
 		Convey(".WithTLS is invoked", func() {
 
 			security := tests.MustGenerate(reflect.TypeOf(SecurityConfig{}), t).Interface().(SecurityConfig)
@@ -62,6 +73,138 @@ func TestClientBuilder(t *testing.T) {
 			})
 		})
 
+		Convey(".WithDialTimeout is invoked", func() {
+
+			timeout := tests.MustGenerateString(t)
+
+			builder.WithDialTimeout(timeout)
+
+			Convey("it sets the dial timeout", func() {
+				So(builder.config.DialTimeout, ShouldEqual, timeout)
+			})
+		})
+
+		Convey(".WithDisableKeepAlives is invoked", func() {
+
+			disable := true
+
+			builder.WithDisableKeepAlives(disable)
+
+			Convey("it sets the disable keep alives", func() {
+				So(builder.config.DisableKeepAlives, ShouldEqual, disable)
+			})
+		})
+
+		Convey(".WithHandshakeTimeout is invoked", func() {
+
+			timeout := tests.MustGenerateString(t)
+
+			builder.WithHandshakeTimeout(timeout)
+
+			Convey("it sets the handshake timeout", func() {
+				So(builder.config.HandshakeTimeout, ShouldEqual, timeout)
+			})
+		})
+
+		Convey(".WithHTTP2 is invoked", func() {
+
+			enabled := true
+
+			builder.WithHTTP2(enabled)
+
+			Convey("it sets the http2", func() {
+				So(builder.config.HTTP2, ShouldEqual, enabled)
+			})
+		})
+
+		Convey(".WithIdleConnTimeout is invoked", func() {
+
+			timeout := tests.MustGenerateString(t)
+
+			builder.WithIdleConnTimeout(timeout)
+
+			Convey("it sets the idle connection timeout", func() {
+				So(builder.config.IdleConnTimeout, ShouldEqual, timeout)
+			})
+		})
+
+		Convey(".WithKeepAlive is invoked", func() {
+
+			interval := tests.MustGenerateString(t)
+
+			builder.WithKeepAlive(interval)
+
+			Convey("it sets the keep alive", func() {
+				So(builder.config.KeepAlive, ShouldEqual, interval)
+			})
+		})
+
+		Convey(".WithMaxConnsPerHost is invoked", func() {
+
+			max := tests.MustGenerateInt(t)
+
+			builder.WithMaxConnsPerHost(max)
+
+			Convey("it sets the max connections per host", func() {
+				So(builder.config.MaxConnsPerHost, ShouldEqual, max)
+			})
+		})
+
+		Convey(".WithMaxIdleConns is invoked", func() {
+
+			max := tests.MustGenerateInt(t)
+
+			builder.WithMaxIdleConns(max)
+
+			Convey("it sets the max idle connections", func() {
+				So(builder.config.MaxIdleConns, ShouldEqual, max)
+			})
+		})
+
+		Convey(".WithMaxIdleConnsPerHost is invoked", func() {
+
+			max := tests.MustGenerateInt(t)
+
+			builder.WithMaxIdleConnsPerHost(max)
+
+			Convey("it sets the max idle connections per host", func() {
+				So(builder.config.MaxIdleConnsPerHost, ShouldEqual, max)
+			})
+		})
+
+		Convey(".WithProxy is invoked", func() {
+
+			proxy := tests.MustGenerateString(t)
+
+			builder.WithProxy(proxy)
+
+			Convey("it sets the proxy", func() {
+				So(builder.config.Proxy, ShouldEqual, proxy)
+			})
+		})
+
+		Convey(".WithProxyFromEnvironment is invoked", func() {
+
+			enabled := true
+
+			builder.WithProxyFromEnvironment(enabled)
+
+			Convey("it sets the proxy from environment", func() {
+				So(builder.config.ProxyFromEnvironment, ShouldEqual, enabled)
+			})
+		})
+
+		Convey(".WithResponseHeaderTimeout is invoked", func() {
+
+			timeout := tests.MustGenerateString(t)
+
+			builder.WithResponseHeaderTimeout(timeout)
+
+			Convey("it sets the response header timeout", func() {
+				So(builder.config.ResponseHeaderTimeout, ShouldEqual, timeout)
+			})
+		})
+
 		Convey(".Build is invoked", func() {
 
 			client, err := builder.Build()
@@ -74,5 +217,18 @@ func TestClientBuilder(t *testing.T) {
 				So(client, ShouldNotBeZeroValue)
 			})
 		})
+
+		Convey(".BuildDialer is invoked", func() {
+
+			dialer, err := builder.BuildDialer()
+
+			Convey("it returns a nil error", func() {
+				So(err, ShouldBeNil)
+			})
+
+			Convey("it returns the dialer", func() {
+				So(dialer, ShouldNotBeNil)
+			})
+		})
 	})
 }
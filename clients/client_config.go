@@ -16,9 +16,11 @@ package clients
 
 import (
 	"crypto/tls"
-	"fmt"
 	"net"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -28,17 +30,73 @@ import (
 // Deprecated: ClientConfig should no longer be used and implementations should move to Configuration.
 type ClientConfig struct {
 
-	// Host defines the hostname or address of the servert to which the client connects.
+	// Host defines the hostname or address of the servert to which the client connects. Note that the requests to the
+	// host are sent to the port regardless of the port of their URL when the port is defined.
 	Host string `json:"host" mapstructure:"host" yaml:"host"`
 
 	// Port defines the port on the server to which the client connects.
 	Port int `json:"port" mapstructure:"port" yaml:"port"`
 
+	// Routes defines the addresses and TLS configurations used for the requests to specific hosts (e.g., to reach a host
+	// by an address missing from DNS or to trust different authorities for different upstreams). Note that the requests
+	// to hosts without a route are sent to the address of their URL using the TLS configuration of the client and that
+	// the requests to routed hosts bypass the proxy.
+	Routes []Route `json:"routes" mapstructure:"routes" yaml:"routes"`
+
 	// Security defines the TLS configuration used by the client.
 	Security SecurityConfig `json:"security" mapstructure:"security" yaml:"security"`
+
+	// DialTimeout defines the maximum duration (e.g., "5s") for establishing a connection. Note that the operating system
+	// timeout applies when empty.
+	DialTimeout string `json:"dialTimeout" mapstructure:"dialTimeout" yaml:"dialTimeout"`
+
+	// DisableKeepAlives defines whether each connection is used for a single request only (i.e., HTTP keep-alives are
+	// disabled).
+	DisableKeepAlives bool `json:"disableKeepAlives" mapstructure:"disableKeepAlives" yaml:"disableKeepAlives"`
+
+	// HandshakeTimeout defines the maximum duration (e.g., "10s") for completing a TLS handshake. Note that handshakes
+	// are not timed out when empty.
+	HandshakeTimeout string `json:"handshakeTimeout" mapstructure:"handshakeTimeout" yaml:"handshakeTimeout"`
+
+	// HTTP2 defines whether HTTP/2 is negotiated with TLS servers that support it. Note that when disabled "h2" is not
+	// offered by the HTTP client even if it is one of the next protocols of the security.
+	HTTP2 bool `json:"http2" mapstructure:"http2" yaml:"http2"`
+
+	// IdleConnTimeout defines the maximum duration (e.g., "90s") an idle connection is kept in the pool. Note that idle
+	// connections are kept indefinitely when empty.
+	IdleConnTimeout string `json:"idleConnTimeout" mapstructure:"idleConnTimeout" yaml:"idleConnTimeout"`
+
+	// KeepAlive defines the interval (e.g., "30s") between TCP keep-alive probes of connections. Note that the default
+	// interval of the net package is used when empty and that probes are disabled when negative (e.g., "-1s").
+	KeepAlive string `json:"keepAlive" mapstructure:"keepAlive" yaml:"keepAlive"`
+
+	// MaxConnsPerHost defines the maximum number of connections per host including those in use. Note that the number
+	// is not limited when zero.
+	MaxConnsPerHost int `json:"maxConnsPerHost" mapstructure:"maxConnsPerHost" yaml:"maxConnsPerHost"`
+
+	// MaxIdleConns defines the maximum number of idle connections across all hosts. Note that the number is not limited
+	// when zero.
+	MaxIdleConns int `json:"maxIdleConns" mapstructure:"maxIdleConns" yaml:"maxIdleConns"`
+
+	// MaxIdleConnsPerHost defines the maximum number of idle connections per host. Note that the default of the net/http
+	// package (i.e., 2) is used when zero.
+	MaxIdleConnsPerHost int `json:"maxIdleConnsPerHost" mapstructure:"maxIdleConnsPerHost" yaml:"maxIdleConnsPerHost"`
+
+	// Proxy defines the URL of the proxy through which requests are sent (e.g., "http://proxy.example.com:3128"). Note
+	// that requests are sent directly when empty unless the proxy is read from the environment.
+	Proxy string `json:"proxy" mapstructure:"proxy" yaml:"proxy"`
+
+	// ProxyFromEnvironment defines whether the proxy is read from the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment
+	// variables. Note that it cannot be combined with the proxy.
+	ProxyFromEnvironment bool `json:"proxyFromEnvironment" mapstructure:"proxyFromEnvironment" yaml:"proxyFromEnvironment"`
+
+	// ResponseHeaderTimeout defines the maximum duration (e.g., "30s") to wait for the headers of a response after the
+	// request is written. Note that responses are awaited indefinitely when empty.
+	ResponseHeaderTimeout string `json:"responseHeaderTimeout" mapstructure:"responseHeaderTimeout" yaml:"responseHeaderTimeout"`
 }
 
-// Build creates an http.Client from the ClientConfig instance.
+// Build creates an http.Client from the ClientConfig instance. Note that connections are made to the address of the URL
+// of each request (e.g., following a redirect to another host) unless a route applies to it.
 func (c *ClientConfig) Build() (*http.Client, error) {
 
 	configuration, err := c.Security.Build()
@@ -46,13 +104,194 @@ func (c *ClientConfig) Build() (*http.Client, error) {
 		return nil, errors.Wrap(err, "error building tls configuration for client")
 	}
 
-	client := &http.Client{
-		Transport: &http.Transport{
-			DialTLS: func(network, address string) (net.Conn, error) {
-				return tls.Dial("tcp", fmt.Sprintf("%s:%d", c.Host, c.Port), configuration)
-			},
-		},
+	transport, err := c.transport(configuration)
+	if err != nil {
+		return nil, errors.Wrap(err, "error building transport for client")
+	}
+
+	return &http.Client{Transport: transport}, nil
+}
+
+// Dialer returns a Dialer for TLS connections from the ClientConfig instance. Note that the host of the configuration
+// is used as the server name for unix sockets unless the server of the security is defined.
+func (c *ClientConfig) Dialer() (*Dialer, error) {
+
+	configuration, err := c.Security.Build()
+	if err != nil {
+		return nil, errors.Wrap(err, "error building tls configuration for client")
+	}
+
+	dialer, err := c.dialer(configuration)
+	if err != nil {
+		return nil, errors.Wrap(err, "error building dialer for client")
+	}
+
+	return dialer, nil
+}
+
+// dialer returns a Dialer that dials the address of each connection or of its route using a tls.Config.
+func (c *ClientConfig) dialer(configuration *tls.Config) (*Dialer, error) {
+
+	dialTimeout, err := parseDuration("dial timeout", c.DialTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	handshakeTimeout, err := parseDuration("handshake timeout", c.HandshakeTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	keepAlive, err := parseDuration("keep alive", c.KeepAlive)
+	if err != nil {
+		return nil, err
+	}
+
+	table, err := c.routes(configuration)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &Dialer{
+		configuration:    configuration,
+		dialer:           &net.Dialer{KeepAlive: keepAlive, Timeout: dialTimeout},
+		handshakeTimeout: handshakeTimeout,
+		host:             c.Host,
+		routes:           table,
+	}
+
+	return dialer, nil
+}
+
+// transport returns an http.Transport that dials the address of each request or of its route using a tls.Config.
+func (c *ClientConfig) transport(configuration *tls.Config) (*http.Transport, error) {
+
+	dialer, err := c.dialer(configuration)
+	if err != nil {
+		return nil, err
+	}
+
+	// The tls.Config of connections dialed directly must offer HTTP/2 itself as the http.Transport only does so for the
+	// TLSClientConfig used with proxies. When HTTP/2 is disabled "h2" must not be offered at all (e.g., by the next
+	// protocols of the security) as the http.Transport would speak HTTP/1.1 over a connection that negotiated HTTP/2.
+	if c.HTTP2 {
+		dialer.nextProtos = []string{"h2", "http/1.1"}
+	} else {
+		dialer.excludedProtos = []string{"h2"}
+	}
+
+	// The http.Transport performs the TLS handshake of proxied requests itself using the TLSClientConfig.
+	clientConfig := configuration
+	if configuration != nil && len(dialer.excludedProtos) > 0 {
+		clientConfig = configuration.Clone()
+		clientConfig.NextProtos = excludeProtos(clientConfig.NextProtos, dialer.excludedProtos)
+	}
+
+	idleConnTimeout, err := parseDuration("idle connection timeout", c.IdleConnTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	responseHeaderTimeout, err := parseDuration("response header timeout", c.ResponseHeaderTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	proxy, err := c.proxy()
+	if err != nil {
+		return nil, err
+	}
+
+	if proxy != nil && len(dialer.routes) > 0 {
+
+		// Routed hosts bypass the proxy as the http.Transport performs the TLS handshake of proxied requests itself
+		// using the TLSClientConfig and would otherwise ignore the address and TLS configuration of the route.
+		proxied := proxy
+		proxy = func(request *http.Request) (*url.URL, error) {
+
+			if dialer.routes.routed(request.URL) {
+				return nil, nil
+			}
+
+			return proxied(request)
+		}
+	}
+
+	transport := &http.Transport{
+		DialContext:           dialer.dialContext,
+		DialTLSContext:        dialer.DialContext,
+		DisableKeepAlives:     c.DisableKeepAlives,
+		ForceAttemptHTTP2:     c.HTTP2,
+		IdleConnTimeout:       idleConnTimeout,
+		MaxConnsPerHost:       c.MaxConnsPerHost,
+		MaxIdleConns:          c.MaxIdleConns,
+		MaxIdleConnsPerHost:   c.MaxIdleConnsPerHost,
+		Proxy:                 proxy,
+		ResponseHeaderTimeout: responseHeaderTimeout,
+		TLSClientConfig:       clientConfig,
+		TLSHandshakeTimeout:   dialer.handshakeTimeout,
+	}
+
+	return transport, nil
+}
+
+// routes returns the built routes of the configuration including the route of the host and port.
+func (c *ClientConfig) routes(configuration *tls.Config) (routes, error) {
+
+	table := routes{}
+
+	if c.Host != "" && c.Port != 0 {
+		table[c.Host] = route{address: net.JoinHostPort(c.Host, strconv.Itoa(c.Port)), config: configuration}
+	}
+
+	for _, definition := range c.Routes {
+		err := table.add(definition, configuration)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return table, nil
+}
+
+// proxy returns the proxy function of the configuration or nil if requests are sent directly.
+func (c *ClientConfig) proxy() (func(*http.Request) (*url.URL, error), error) {
+
+	if c.Proxy != "" && c.ProxyFromEnvironment {
+		return nil, errors.New("error building proxy from both a url and the environment")
+	}
+
+	if c.ProxyFromEnvironment {
+		return http.ProxyFromEnvironment, nil
+	}
+
+	if c.Proxy == "" {
+		return nil, nil
+	}
+
+	parsed, err := url.Parse(c.Proxy)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error parsing proxy [%s]", c.Proxy)
+	}
+
+	if parsed.Scheme == "" || parsed.Host == "" {
+		return nil, errors.Errorf("error parsing proxy [%s] without a scheme and host", c.Proxy)
+	}
+
+	return http.ProxyURL(parsed), nil
+}
+
+// parseDuration parses a duration of the configuration. Note that an empty value returns zero.
+func parseDuration(name string, value string) (time.Duration, error) {
+
+	if value == "" {
+		return 0, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, errors.Wrapf(err, "error parsing %s [%s]", name, value)
 	}
 
-	return client, nil
+	return duration, nil
 }
@@ -0,0 +1,317 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/greymatter-io/nautls/identities"
+	"github.com/greymatter-io/nautls/internal/tests/fixtures"
+	"github.com/greymatter-io/nautls/servers"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestClientConfigTransport(t *testing.T) {
+
+	authority := fixtures.MustAuthority(t, "NauTLS (Authority)", identities.ECDSA)
+	authorities := fixtures.Authorities(authority)
+
+	serverCertificate, serverKey := fixtures.MustIssueServer(t, authority, identities.ECDSA, "localhost", "localhost")
+
+	config, err := (&servers.Configuration{Certificate: serverCertificate, Key: serverKey}).TLS()
+	if err != nil {
+		t.Fatalf("error building server configuration [%s]", err)
+	}
+
+	Convey("When ClientConfig connects to a server supporting HTTP/2", t, func() {
+
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			fmt.Fprint(writer, request.Proto)
+		}))
+		server.EnableHTTP2 = true
+		server.TLS = config
+		server.StartTLS()
+		defer server.Close()
+
+		port := server.Listener.Addr().(*net.TCPAddr).Port
+
+		protocol := func(http2 bool, nextProtos ...string) string {
+
+			client, err := (&ClientConfig{
+				Host:             "localhost",
+				HandshakeTimeout: "5s",
+				HTTP2:            http2,
+				Port:             port,
+				Security:         SecurityConfig{Authorities: authorities, NextProtos: nextProtos},
+			}).Build()
+			So(err, ShouldBeNil)
+
+			response, err := client.Get(fmt.Sprintf("https://localhost:%d", port))
+			So(err, ShouldBeNil)
+			defer response.Body.Close()
+
+			body, err := io.ReadAll(response.Body)
+			So(err, ShouldBeNil)
+
+			return string(body)
+		}
+
+		Convey("and HTTP/2 is enabled", func() {
+
+			Convey("it negotiates HTTP/2", func() {
+				So(protocol(true), ShouldEqual, "HTTP/2.0")
+			})
+		})
+
+		Convey("and HTTP/2 is not enabled", func() {
+
+			Convey("it uses HTTP/1.1", func() {
+				So(protocol(false), ShouldEqual, "HTTP/1.1")
+			})
+
+			Convey("it uses HTTP/1.1 when the security offers HTTP/2", func() {
+				So(protocol(false, "h2", "http/1.1"), ShouldEqual, "HTTP/1.1")
+			})
+		})
+	})
+
+	Convey("When ClientConfig defines a response header timeout", t, func() {
+
+		release := make(chan struct{})
+
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			<-release
+		}))
+		server.TLS = config
+		server.StartTLS()
+		defer server.Close()
+		defer close(release)
+
+		client, err := (&ClientConfig{
+			Host:                  "localhost",
+			Port:                  server.Listener.Addr().(*net.TCPAddr).Port,
+			ResponseHeaderTimeout: "50ms",
+			Security:              SecurityConfig{Authorities: authorities},
+		}).Build()
+		So(err, ShouldBeNil)
+
+		Convey("it fails requests outlasting the timeout", func() {
+			So(request(client, "localhost"), ShouldNotBeNil)
+		})
+	})
+
+	Convey("When ClientConfig defines a proxy", t, func() {
+
+		proxied := make(chan string, 1)
+
+		proxy := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			proxied <- request.URL.String()
+		}))
+		defer proxy.Close()
+
+		client, err := (&ClientConfig{Host: "localhost", Port: 443, Proxy: proxy.URL}).Build()
+		So(err, ShouldBeNil)
+
+		response, err := client.Get("http://nautls.invalid/resource")
+		So(err, ShouldBeNil)
+		response.Body.Close()
+
+		Convey("it sends requests through the proxy", func() {
+			So(<-proxied, ShouldEqual, "http://nautls.invalid/resource")
+		})
+	})
+
+	Convey("When ClientConfig defines invalid transport settings", t, func() {
+
+		_, durationErr := (&ClientConfig{DialTimeout: "soon"}).Build()
+		_, proxyErr := (&ClientConfig{Proxy: "proxy.example.com"}).Build()
+		_, bothErr := (&ClientConfig{Proxy: "http://proxy.example.com", ProxyFromEnvironment: true}).Build()
+
+		Convey("it returns non-nil errors", func() {
+			So(durationErr, ShouldNotBeNil)
+			So(proxyErr, ShouldNotBeNil)
+			So(bothErr, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestClientConfigRoutes(t *testing.T) {
+
+	alpha := fixtures.MustAuthority(t, "NauTLS (Authority)", identities.ECDSA)
+	beta := fixtures.MustAuthority(t, "NauTLS (Authority)", identities.ECDSA)
+
+	alphaAuthorities := fixtures.Authorities(alpha)
+	betaAuthorities := fixtures.Authorities(beta)
+
+	// serve returns a TLS server for names responding with its name and redirecting "/redirect" to a location.
+	serve := func(authority *identities.Identity, name string, location string) (*httptest.Server, string) {
+
+		certificate, key := fixtures.MustIssueServer(t, authority, identities.ECDSA, name, name, "localhost")
+
+		config, err := (&servers.Configuration{Certificate: certificate, Key: key}).TLS()
+		So(err, ShouldBeNil)
+
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if request.URL.Path == "/redirect" {
+				http.Redirect(writer, request, location, http.StatusFound)
+				return
+			}
+			fmt.Fprint(writer, name)
+		}))
+		server.TLS = config
+		server.StartTLS()
+
+		return server, server.Listener.Addr().String()
+	}
+
+	get := func(client *http.Client, location string) (string, error) {
+
+		response, err := client.Get(location)
+		if err != nil {
+			return "", err
+		}
+		defer response.Body.Close()
+
+		body, err := io.ReadAll(response.Body)
+		return string(body), err
+	}
+
+	Convey("When ClientConfig defines routes with their own security", t, func() {
+
+		alphaServer, alphaAddress := serve(alpha, "alpha.nautls.test", "")
+		defer alphaServer.Close()
+
+		betaServer, betaAddress := serve(beta, "beta.nautls.test", "")
+		defer betaServer.Close()
+
+		client, err := (&ClientConfig{
+			Routes: []Route{
+				{Address: alphaAddress, Host: "alpha.nautls.test"},
+				{Address: betaAddress, Host: "beta.nautls.test:443", Security: &SecurityConfig{Authorities: betaAuthorities}},
+			},
+			Security: SecurityConfig{Authorities: alphaAuthorities},
+		}).Build()
+		So(err, ShouldBeNil)
+
+		Convey("it dials the address of the route using the security of the client", func() {
+			body, err := get(client, "https://alpha.nautls.test/")
+			So(err, ShouldBeNil)
+			So(body, ShouldEqual, "alpha.nautls.test")
+		})
+
+		Convey("it dials the address of the route using the security of the route", func() {
+			body, err := get(client, "https://beta.nautls.test/")
+			So(err, ShouldBeNil)
+			So(body, ShouldEqual, "beta.nautls.test")
+		})
+
+		Convey("it does not apply a route with a port to other ports", func() {
+			_, err := get(client, "https://beta.nautls.test:8443/")
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("When ClientConfig follows a redirect to another server", t, func() {
+
+		betaServer, betaAddress := serve(beta, "beta.nautls.test", "")
+		defer betaServer.Close()
+
+		_, betaPort, _ := net.SplitHostPort(betaAddress)
+
+		alphaServer, alphaAddress := serve(alpha, "alpha.nautls.test", fmt.Sprintf("https://localhost:%s/", betaPort))
+		defer alphaServer.Close()
+
+		_, alphaPort, _ := net.SplitHostPort(alphaAddress)
+
+		client, err := (&ClientConfig{
+			Security: SecurityConfig{Authorities: append(append([]string{}, alphaAuthorities...), betaAuthorities...)},
+		}).Build()
+		So(err, ShouldBeNil)
+
+		body, err := get(client, fmt.Sprintf("https://localhost:%s/redirect", alphaPort))
+
+		Convey("it dials the address of the redirect", func() {
+			So(err, ShouldBeNil)
+			So(body, ShouldEqual, "beta.nautls.test")
+		})
+	})
+
+	Convey("When ClientConfig defines the host and port", t, func() {
+
+		alphaServer, alphaAddress := serve(alpha, "alpha.nautls.test", "")
+		defer alphaServer.Close()
+
+		_, alphaPort, _ := net.SplitHostPort(alphaAddress)
+		port, _ := strconv.Atoi(alphaPort)
+
+		client, err := (&ClientConfig{Host: "localhost", Port: port, Security: SecurityConfig{Authorities: alphaAuthorities}}).Build()
+		So(err, ShouldBeNil)
+
+		Convey("it dials the port for requests to the host", func() {
+			body, err := get(client, "https://localhost/")
+			So(err, ShouldBeNil)
+			So(body, ShouldEqual, "alpha.nautls.test")
+		})
+	})
+
+	Convey("When ClientConfig defines a route and a proxy", t, func() {
+
+		alphaServer, alphaAddress := serve(alpha, "alpha.nautls.test", "")
+		defer alphaServer.Close()
+
+		proxied := make(chan string, 1)
+
+		proxy := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			proxied <- request.Host
+			writer.WriteHeader(http.StatusBadGateway)
+		}))
+		defer proxy.Close()
+
+		client, err := (&ClientConfig{
+			Proxy:    proxy.URL,
+			Routes:   []Route{{Address: alphaAddress, Host: "alpha.nautls.test"}},
+			Security: SecurityConfig{Authorities: alphaAuthorities},
+		}).Build()
+		So(err, ShouldBeNil)
+
+		body, err := get(client, "https://alpha.nautls.test/")
+
+		Convey("it sends the requests to the routed host directly", func() {
+			So(err, ShouldBeNil)
+			So(body, ShouldEqual, "alpha.nautls.test")
+			So(proxied, ShouldBeEmpty)
+		})
+	})
+
+	Convey("When ClientConfig defines invalid routes", t, func() {
+
+		_, hostErr := (&ClientConfig{Routes: []Route{{Address: "127.0.0.1:8443"}}}).Build()
+		_, addressErr := (&ClientConfig{Routes: []Route{{Address: "127.0.0.1", Host: "nautls.test"}}}).Build()
+		_, securityErr := (&ClientConfig{Routes: []Route{{Host: "nautls.test", Security: &SecurityConfig{Authorities: []string{"base64:///invalid"}}}}}).Build()
+
+		Convey("it returns non-nil errors", func() {
+			So(hostErr, ShouldNotBeNil)
+			So(addressErr, ShouldNotBeNil)
+			So(securityErr, ShouldNotBeNil)
+		})
+	})
+}
@@ -14,6 +14,11 @@
 
 package clients
 
+import (
+	"github.com/greymatter-io/nautls/protocols"
+	"github.com/greymatter-io/nautls/spiffe"
+)
+
 // ConfigurationBuilder provides an builder for client Configuration instances.
 type ConfigurationBuilder struct {
 	Configuration
@@ -27,10 +32,24 @@ func NewConfigurationBuilder() *ConfigurationBuilder {
 // Build returns a Configuration for the current state of the builder.
 func (b *ConfigurationBuilder) Build() *Configuration {
 	return &Configuration{
-		Authorities: b.Authorities,
-		Certificate: b.Certificate,
-		Key:         b.Key,
-		Server:      b.Server,
+		Authorities:      b.Authorities,
+		Certificate:      b.Certificate,
+		CipherSuites:     b.CipherSuites,
+		CurvePreferences: b.CurvePreferences,
+		Key:              b.Key,
+		MaxVersion:       b.MaxVersion,
+		MinVersion:       b.MinVersion,
+		NextProtos:       b.NextProtos,
+		Passphrase:       b.Passphrase,
+		Pins:             b.Pins,
+		PinOnly:          b.PinOnly,
+		PKCS12:           b.PKCS12,
+		Profile:          b.Profile,
+		Reload:           b.Reload,
+		Revocations:      b.Revocations,
+		SPIFFE:           b.SPIFFE,
+		Stapling:         b.Stapling,
+		Server:           b.Server,
 	}
 }
 
@@ -56,6 +75,18 @@ func (b *ConfigurationBuilder) WithCertificate(certificate string) *Configuratio
 	return b
 }
 
+// WithCipherSuites sets the enabled TLS 1.0 to 1.2 cipher suites in order of preference.
+func (b *ConfigurationBuilder) WithCipherSuites(suites protocols.CipherSuites) *ConfigurationBuilder {
+	b.CipherSuites = suites
+	return b
+}
+
+// WithCurvePreferences sets the enabled key exchange curves in order of preference.
+func (b *ConfigurationBuilder) WithCurvePreferences(curves protocols.Curves) *ConfigurationBuilder {
+	b.CurvePreferences = curves
+	return b
+}
+
 // WithKey sets the client key used for mTLS connections. The value must be a URL that points to the location of a PEM
 // encoded key.
 //
@@ -67,6 +98,98 @@ func (b *ConfigurationBuilder) WithKey(key string) *ConfigurationBuilder {
 	return b
 }
 
+// WithMaxVersion sets the maximum TLS version.
+func (b *ConfigurationBuilder) WithMaxVersion(version protocols.Version) *ConfigurationBuilder {
+	b.MaxVersion = version
+	return b
+}
+
+// WithMinVersion sets the minimum TLS version.
+func (b *ConfigurationBuilder) WithMinVersion(version protocols.Version) *ConfigurationBuilder {
+	b.MinVersion = version
+	return b
+}
+
+// WithNextProtos sets the supported application level protocols (i.e., ALPN) in order of preference.
+func (b *ConfigurationBuilder) WithNextProtos(protos []string) *ConfigurationBuilder {
+	b.NextProtos = protos
+	return b
+}
+
+// WithPassphrase sets the passphrase used to decrypt the key when it is encrypted. The value must be a URL that points to
+// the location of the passphrase.
+//
+// Note that in addition to those schemes supported by [getter](https://godoc.org/github.com/hashicorp/go-getter) a
+// "base64" scheme is supported for providing the passphrase in the path of the URL directly. This is most applicable
+// when the passphrase must be provided via an environement variable.
+func (b *ConfigurationBuilder) WithPassphrase(passphrase string) *ConfigurationBuilder {
+	b.Passphrase = passphrase
+	return b
+}
+
+// WithPins sets the SHA-256 certificate or subject public key info fingerprints of which at least one must be in the
+// verified chain of the server. The values must be hex or base64 encoded and may be prefixed with "sha256/".
+func (b *ConfigurationBuilder) WithPins(pins []string) *ConfigurationBuilder {
+	b.Pins = pins
+	return b
+}
+
+// WithPinOnly sets whether the server certificate is verified by the pins only (e.g., for self signed servers).
+func (b *ConfigurationBuilder) WithPinOnly(pinOnly bool) *ConfigurationBuilder {
+	b.PinOnly = pinOnly
+	return b
+}
+
+// WithPKCS12 sets a PKCS #12 archive holding the client certificate, key and certificate chain. The value must be a URL
+// that points to the location of a PKCS #12 (i.e., .p12 or .pfx) file and the password must be provided using
+// WithPassphrase.
+//
+// Note that in addition to those schemes supported by [getter](https://godoc.org/github.com/hashicorp/go-getter) a
+// "base64" scheme is supported for providing the PKCS #12 archive in the path of the URL directly. This is most
+// applicable when the archive must be provided via an environement variable.
+func (b *ConfigurationBuilder) WithPKCS12(pkcs12 string) *ConfigurationBuilder {
+	b.PKCS12 = pkcs12
+	return b
+}
+
+// WithProfile sets the vetted set of version, cipher suite and curve settings (e.g., protocols.IntermediateProfile).
+// Note that the settings provided via WithCipherSuites, WithCurvePreferences, WithMaxVersion and WithMinVersion override
+// those of the profile.
+func (b *ConfigurationBuilder) WithProfile(profile protocols.Profile) *ConfigurationBuilder {
+	b.Profile = profile
+	return b
+}
+
+// WithReload sets the minimum interval (e.g., "30s") between checks for changes to the content of the resources. When set
+// certificates, authorities and revocation lists are reloaded without a restart.
+func (b *ConfigurationBuilder) WithReload(reload string) *ConfigurationBuilder {
+	b.Reload = reload
+	return b
+}
+
+// WithRevocations sets the certificate revocation lists used to reject revoked server certificates. The values must be
+// URLs that point to the locations of PEM or DER encoded revocation lists.
+//
+// Note that in addition to those schemes supported by [getter](https://godoc.org/github.com/hashicorp/go-getter) a
+// "base64" scheme is supported for providing the revocation list in the path of the URL directly. This is most
+// applicable when the revocation list must be provided via an environement variable.
+func (b *ConfigurationBuilder) WithRevocations(revocations []string) *ConfigurationBuilder {
+	b.Revocations = revocations
+	return b
+}
+
+// WithSPIFFE sets the rules matching the SPIFFE ID of the server certificate of which at least one must match.
+func (b *ConfigurationBuilder) WithSPIFFE(matchers []spiffe.Matcher) *ConfigurationBuilder {
+	b.SPIFFE = matchers
+	return b
+}
+
+// WithStapling sets whether an OCSP response must be stapled to the server certificate.
+func (b *ConfigurationBuilder) WithStapling(stapling Stapling) *ConfigurationBuilder {
+	b.Stapling = stapling
+	return b
+}
+
 // WithServer sets the server name used for certificate verification.
 func (b *ConfigurationBuilder) WithServer(server string) *ConfigurationBuilder {
 	b.Server = server
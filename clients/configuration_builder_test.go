@@ -15,9 +15,12 @@
 package clients
 
 import (
+	"crypto/tls"
 	"testing"
 
 	"github.com/greymatter-io/nautls/internal/tests"
+	"github.com/greymatter-io/nautls/protocols"
+	"github.com/greymatter-io/nautls/spiffe"
 
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -59,6 +62,28 @@ func TestConfigurationBuilder(t *testing.T) {
 			})
 		})
 
+		Convey(".WithCipherSuites is invoked", func() {
+
+			suites := protocols.CipherSuites{protocols.CipherSuite(tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256)}
+
+			builder.WithCipherSuites(suites)
+
+			Convey("it sets the cipher suites", func() {
+				So(builder.CipherSuites, ShouldResemble, suites)
+			})
+		})
+
+		Convey(".WithCurvePreferences is invoked", func() {
+
+			curves := protocols.Curves{protocols.X25519, protocols.P256}
+
+			builder.WithCurvePreferences(curves)
+
+			Convey("it sets the curve preferences", func() {
+				So(builder.CurvePreferences, ShouldResemble, curves)
+			})
+		})
+
 		Convey(".WithKey is invoked", func() {
 
 			key := tests.MustGenerateString(t)
@@ -70,6 +95,136 @@ func TestConfigurationBuilder(t *testing.T) {
 			})
 		})
 
+		Convey(".WithMaxVersion is invoked", func() {
+
+			version := protocols.Version(tls.VersionTLS13)
+
+			builder.WithMaxVersion(version)
+
+			Convey("it sets the maximum version", func() {
+				So(builder.MaxVersion, ShouldEqual, version)
+			})
+		})
+
+		Convey(".WithMinVersion is invoked", func() {
+
+			version := protocols.Version(tls.VersionTLS12)
+
+			builder.WithMinVersion(version)
+
+			Convey("it sets the minimum version", func() {
+				So(builder.MinVersion, ShouldEqual, version)
+			})
+		})
+
+		Convey(".WithNextProtos is invoked", func() {
+
+			protos := tests.MustGenerateStrings(t)
+
+			builder.WithNextProtos(protos)
+
+			Convey("it sets the next protos", func() {
+				So(builder.NextProtos, ShouldResemble, protos)
+			})
+		})
+
+		Convey(".WithPassphrase is invoked", func() {
+
+			passphrase := tests.MustGenerateString(t)
+
+			builder.WithPassphrase(passphrase)
+
+			Convey("it sets the passphrase", func() {
+				So(builder.Passphrase, ShouldEqual, passphrase)
+			})
+		})
+
+		Convey(".WithPins is invoked", func() {
+
+			pins := tests.MustGenerateStrings(t)
+
+			builder.WithPins(pins)
+
+			Convey("it sets the pins", func() {
+				So(builder.Pins, ShouldResemble, pins)
+			})
+		})
+
+		Convey(".WithPinOnly is invoked", func() {
+
+			builder.WithPinOnly(true)
+
+			Convey("it sets the pin only", func() {
+				So(builder.PinOnly, ShouldBeTrue)
+			})
+		})
+
+		Convey(".WithPKCS12 is invoked", func() {
+
+			pkcs12 := tests.MustGenerateString(t)
+
+			builder.WithPKCS12(pkcs12)
+
+			Convey("it sets the pkcs12", func() {
+				So(builder.PKCS12, ShouldEqual, pkcs12)
+			})
+		})
+
+		Convey(".WithProfile is invoked", func() {
+
+			profile := protocols.IntermediateProfile
+
+			builder.WithProfile(profile)
+
+			Convey("it sets the profile", func() {
+				So(builder.Profile, ShouldEqual, profile)
+			})
+		})
+
+		Convey(".WithReload is invoked", func() {
+
+			reload := tests.MustGenerateString(t)
+
+			builder.WithReload(reload)
+
+			Convey("it sets the reload", func() {
+				So(builder.Reload, ShouldEqual, reload)
+			})
+		})
+
+		Convey(".WithRevocations is invoked", func() {
+
+			revocations := tests.MustGenerateStrings(t)
+
+			builder.WithRevocations(revocations)
+
+			Convey("it sets the revocations", func() {
+				So(builder.Revocations, ShouldResemble, revocations)
+			})
+		})
+
+		Convey(".WithSPIFFE is invoked", func() {
+
+			matchers := []spiffe.Matcher{{TrustDomain: "example.org"}}
+
+			builder.WithSPIFFE(matchers)
+
+			Convey("it sets the spiffe matchers", func() {
+				So(builder.SPIFFE, ShouldResemble, matchers)
+			})
+		})
+
+		Convey(".WithStapling is invoked", func() {
+
+			stapling := MustStaple
+
+			builder.WithStapling(stapling)
+
+			Convey("it sets the stapling", func() {
+				So(builder.Stapling, ShouldEqual, stapling)
+			})
+		})
+
 		Convey(".WithServer is invoked", func() {
 
 			server := tests.MustGenerateString(t)
@@ -0,0 +1,197 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/greymatter-io/nautls/encoding"
+	"github.com/greymatter-io/nautls/identities"
+	"github.com/greymatter-io/nautls/internal/tests"
+	"github.com/greymatter-io/nautls/internal/tests/fixtures"
+	"github.com/greymatter-io/nautls/servers"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// spkiPin returns the base64 encoded SHA-256 fingerprint of the subject public key info of a certificate.
+func spkiPin(certificate *x509.Certificate) string {
+	fingerprint := sha256.Sum256(certificate.RawSubjectPublicKeyInfo)
+	return "sha256/" + base64.StdEncoding.EncodeToString(fingerprint[:])
+}
+
+// certificatePin returns the colon separated hex encoded SHA-256 fingerprint of a certificate.
+func certificatePin(certificate *x509.Certificate) string {
+
+	fingerprint := sha256.Sum256(certificate.Raw)
+
+	octets := []string{}
+	for _, octet := range fingerprint {
+		octets = append(octets, fmt.Sprintf("%02X", octet))
+	}
+
+	return strings.Join(octets, ":")
+}
+
+func TestConfigurationPins(t *testing.T) {
+
+	authority, certificate, key := mustIssueServer(t)
+	authorities := fixtures.Authorities(authority)
+	leaf := mustLeaf(t, fixtures.Base64Resource(certificate))
+
+	self, err := identities.Self(identities.Template{
+		DNSNames:     []string{"self.nautls.test"},
+		KeyAlgorithm: identities.ECDSA,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		NotAfter:     time.Now().AddDate(1, 0, 0),
+		NotBefore:    time.Now().Add(-time.Hour),
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "self"},
+	})
+	if err != nil {
+		t.Fatalf("error creating self signed identity [%s]", err)
+	}
+
+	Convey("When Configuration", t, func() {
+
+		config, err := (&servers.Configuration{Certificate: fixtures.Base64Resource(certificate), Key: fixtures.Base64Resource(key)}).TLS()
+		So(err, ShouldBeNil)
+
+		address, server := tests.MustServe(t, config)
+		defer server.Close()
+
+		Convey(".HTTP is invoked with the spki pin of the server certificate", func() {
+
+			client, err := (&Configuration{Authorities: authorities, Pins: []string{spkiPin(leaf)}}).HTTP()
+			So(err, ShouldBeNil)
+
+			Convey("it accepts the server", func() {
+				So(request(client, address), ShouldBeNil)
+			})
+		})
+
+		Convey(".HTTP is invoked with the certificate pin of the authority", func() {
+
+			client, err := (&Configuration{Authorities: authorities, Pins: []string{certificatePin(authority.Certificate)}}).HTTP()
+			So(err, ShouldBeNil)
+
+			Convey("it accepts the server", func() {
+				So(request(client, address), ShouldBeNil)
+			})
+		})
+
+		Convey(".HTTP is invoked with a backup pin", func() {
+
+			client, err := (&Configuration{Authorities: authorities, Pins: []string{spkiPin(self.Certificate), spkiPin(leaf)}}).HTTP()
+			So(err, ShouldBeNil)
+
+			Convey("it accepts the server", func() {
+				So(request(client, address), ShouldBeNil)
+			})
+		})
+
+		Convey(".HTTP is invoked with an unmatched pin", func() {
+
+			client, err := (&Configuration{Authorities: authorities, Pins: []string{spkiPin(self.Certificate)}}).HTTP()
+			So(err, ShouldBeNil)
+
+			err = request(client, address)
+
+			Convey("it rejects the server naming the presented fingerprints", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, spkiPin(leaf))
+				So(err.Error(), ShouldContainSubstring, spkiPin(authority.Certificate))
+			})
+		})
+
+		Convey(".TLS is invoked with an invalid pin", func() {
+
+			_, err := (&Configuration{Pins: []string{tests.MustGenerateHex(t) + "-invalid"}}).TLS()
+
+			Convey("it returns a non-nil error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+
+		Convey(".TLS is invoked for pin only without pins", func() {
+
+			_, err := (&Configuration{PinOnly: true}).TLS()
+
+			Convey("it returns a non-nil error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+
+	Convey("When Configuration is pin only", t, func() {
+
+		config, err := (&servers.Configuration{
+			Certificate: fixtures.Base64Resource(encoding.PEMEncodeCertificate(self.Certificate)),
+			Key:         fixtures.Base64Resource(encoding.PEMEncodeKey(self.Key)),
+		}).TLS()
+		So(err, ShouldBeNil)
+
+		address, server := tests.MustServe(t, config)
+		defer server.Close()
+
+		Convey(".HTTP is invoked with the pin of a self signed server", func() {
+
+			client, err := (&Configuration{PinOnly: true, Pins: []string{spkiPin(self.Certificate)}}).HTTP()
+			So(err, ShouldBeNil)
+
+			Convey("it accepts the server", func() {
+				So(request(client, address), ShouldBeNil)
+			})
+		})
+
+		Convey(".HTTP is invoked with the pin of a self signed server and reloading", func() {
+
+			client, err := (&Configuration{PinOnly: true, Pins: []string{spkiPin(self.Certificate)}, Reload: "1m"}).HTTP()
+			So(err, ShouldBeNil)
+
+			Convey("it accepts the server", func() {
+				So(request(client, address), ShouldBeNil)
+			})
+		})
+
+		Convey(".HTTP is invoked with an unmatched pin", func() {
+
+			client, err := (&Configuration{PinOnly: true, Pins: []string{spkiPin(leaf)}}).HTTP()
+			So(err, ShouldBeNil)
+
+			Convey("it rejects the server", func() {
+				So(request(client, address), ShouldNotBeNil)
+			})
+		})
+
+		Convey(".HTTP is invoked without pin only", func() {
+
+			client, err := (&Configuration{Pins: []string{spkiPin(self.Certificate)}}).HTTP()
+			So(err, ShouldBeNil)
+
+			Convey("it rejects the untrusted server", func() {
+				So(request(client, address), ShouldNotBeNil)
+			})
+		})
+	})
+}
@@ -0,0 +1,350 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/greymatter-io/nautls/internal/tests"
+	"github.com/greymatter-io/nautls/internal/tests/fixtures"
+	"github.com/greymatter-io/nautls/protocols"
+	"github.com/greymatter-io/nautls/servers"
+	"github.com/mitchellh/mapstructure"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestConfigurationProtocols(t *testing.T) {
+
+	authority, certificate, key := mustIssueServer(t)
+	authorities := fixtures.Authorities(authority)
+
+	Convey("When Configuration", t, func() {
+
+		Convey("is unmarshalled from json with protocol settings", func() {
+
+			var configuration Configuration
+
+			err := json.Unmarshal([]byte(`{
+				"cipherSuites": ["TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"],
+				"curvePreferences": ["X25519Kyber768Draft00", "X25519"],
+				"maxVersion": "TLS1.3",
+				"minVersion": "TLS1.2",
+				"nextProtos": ["h2", "http/1.1"]
+			}`), &configuration)
+			So(err, ShouldBeNil)
+
+			config, err := configuration.TLS()
+
+			Convey("it sets the protocol settings of the tls.Config", func() {
+				So(err, ShouldBeNil)
+				So(config.CipherSuites, ShouldResemble, []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256})
+				So(config.CurvePreferences, ShouldResemble, protocols.Curves{protocols.X25519Kyber768Draft00, protocols.X25519}.Supported().IDs())
+				So(config.MaxVersion, ShouldEqual, tls.VersionTLS13)
+				So(config.MinVersion, ShouldEqual, tls.VersionTLS12)
+				So(config.NextProtos, ShouldResemble, []string{"h2", "http/1.1"})
+			})
+		})
+
+		Convey("is unmarshalled from json with an unknown cipher suite", func() {
+
+			var configuration Configuration
+
+			err := json.Unmarshal([]byte(`{"cipherSuites": ["TLS_UNKNOWN"]}`), &configuration)
+
+			Convey("it returns an error naming the value", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "TLS_UNKNOWN")
+			})
+		})
+
+		Convey(".TLS is invoked with a minimum version greater than the maximum version", func() {
+
+			_, err := (&Configuration{MaxVersion: tls.VersionTLS12, MinVersion: tls.VersionTLS13}).TLS()
+
+			Convey("it returns a non-nil error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+
+		Convey(".TLS is invoked with curves that are not implemented", func() {
+
+			config, err := (&Configuration{CurvePreferences: protocols.Curves{protocols.Curve(0xfafa)}}).TLS()
+
+			Convey("it returns a non-nil error", func() {
+				So(err, ShouldNotBeNil)
+			})
+
+			Convey("it returns a nil configuration", func() {
+				So(config, ShouldBeNil)
+			})
+		})
+
+		Convey(".HTTP is invoked for a server with curves that are not implemented", func() {
+
+			configuration := &servers.Configuration{
+				Certificate:      fixtures.Base64Resource(certificate),
+				CurvePreferences: protocols.Curves{protocols.Curve(0xfafa), protocols.X25519},
+				Key:              fixtures.Base64Resource(key),
+			}
+
+			config, err := configuration.TLS()
+			So(err, ShouldBeNil)
+
+			address, server := tests.MustServe(t, config)
+			defer server.Close()
+
+			Convey("it negotiates an implemented curve", func() {
+
+				client, err := (&Configuration{Authorities: authorities, CurvePreferences: configuration.CurvePreferences}).HTTP()
+				So(err, ShouldBeNil)
+				So(request(client, address), ShouldBeNil)
+			})
+		})
+
+		Convey(".HTTP is invoked for a server", func() {
+
+			configuration := &servers.Configuration{
+				Certificate: fixtures.Base64Resource(certificate),
+				Key:         fixtures.Base64Resource(key),
+				MaxVersion:  tls.VersionTLS12,
+				NextProtos:  []string{"http/1.1"},
+			}
+
+			config, err := configuration.TLS()
+			So(err, ShouldBeNil)
+
+			address, server := tests.MustServe(t, config)
+			defer server.Close()
+
+			Convey("with a compatible version it negotiates the application protocol", func() {
+
+				client, err := (&Configuration{Authorities: authorities, NextProtos: []string{"http/1.1"}}).HTTP()
+				So(err, ShouldBeNil)
+
+				response, err := client.Get(fmt.Sprintf("https://%s", address))
+				So(err, ShouldBeNil)
+				defer response.Body.Close()
+
+				So(response.TLS.Version, ShouldEqual, tls.VersionTLS12)
+				So(response.TLS.NegotiatedProtocol, ShouldEqual, "http/1.1")
+			})
+
+			Convey("with an incompatible minimum version it fails", func() {
+
+				client, err := (&Configuration{Authorities: authorities, MinVersion: tls.VersionTLS13}).HTTP()
+				So(err, ShouldBeNil)
+
+				So(request(client, address), ShouldNotBeNil)
+			})
+		})
+	})
+
+	Convey("When servers.Configuration is decoded with mapstructure", t, func() {
+
+		var configuration servers.Configuration
+
+		decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+			DecodeHook: mapstructure.ComposeDecodeHookFunc(
+				protocols.StringToCipherSuite(),
+				protocols.StringToCurve(),
+				protocols.StringToVersion(),
+				servers.IntToAuthentication(),
+			),
+			Result: &configuration,
+		})
+		if err != nil {
+			t.Fatalf("error initializing decoder [%s]", err.Error())
+		}
+
+		Convey("with known names", func() {
+
+			err := decoder.Decode(map[string]interface{}{
+				"authentication":   "RequireAndVerifyClientCert",
+				"cipherSuites":     []string{"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384"},
+				"curvePreferences": []string{"P256"},
+				"minVersion":       "TLS1.2",
+			})
+
+			Convey("it decodes the protocol settings", func() {
+				So(err, ShouldBeNil)
+				So(configuration.CipherSuites, ShouldResemble, protocols.CipherSuites{protocols.CipherSuite(tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384)})
+				So(configuration.CurvePreferences, ShouldResemble, protocols.Curves{protocols.P256})
+				So(configuration.MinVersion, ShouldEqual, tls.VersionTLS12)
+			})
+		})
+
+		Convey("with an unknown version", func() {
+
+			err := decoder.Decode(map[string]interface{}{"minVersion": "SSL3.0"})
+
+			Convey("it returns an error naming the value", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "SSL3.0")
+			})
+		})
+	})
+}
+
+func TestConfigurationProfiles(t *testing.T) {
+
+	authority, certificate, key := mustIssueServer(t)
+	authorities := fixtures.Authorities(authority)
+
+	Convey("When servers.Configuration", t, func() {
+
+		Convey(".TLS is invoked with the modern profile", func() {
+
+			configuration := &servers.Configuration{
+				Certificate: fixtures.Base64Resource(certificate),
+				Key:         fixtures.Base64Resource(key),
+				Profile:     protocols.ModernProfile,
+			}
+
+			config, err := configuration.TLS()
+			So(err, ShouldBeNil)
+
+			address, server := tests.MustServe(t, config)
+			defer server.Close()
+
+			Convey("it accepts TLS 1.3 clients", func() {
+				client, err := (&Configuration{Authorities: authorities}).HTTP()
+				So(err, ShouldBeNil)
+				So(request(client, address), ShouldBeNil)
+			})
+
+			Convey("it rejects TLS 1.2 clients", func() {
+				client, err := (&Configuration{Authorities: authorities, MaxVersion: tls.VersionTLS12}).HTTP()
+				So(err, ShouldBeNil)
+				So(request(client, address), ShouldNotBeNil)
+			})
+		})
+
+		Convey(".TLS is invoked with the modern profile and an explicit minimum version", func() {
+
+			configuration := &servers.Configuration{
+				Certificate: fixtures.Base64Resource(certificate),
+				Key:         fixtures.Base64Resource(key),
+				MinVersion:  tls.VersionTLS12,
+				Profile:     protocols.ModernProfile,
+			}
+
+			config, err := configuration.TLS()
+			So(err, ShouldBeNil)
+
+			address, server := tests.MustServe(t, config)
+			defer server.Close()
+
+			Convey("it accepts TLS 1.2 clients", func() {
+				client, err := (&Configuration{Authorities: authorities, MaxVersion: tls.VersionTLS12}).HTTP()
+				So(err, ShouldBeNil)
+				So(request(client, address), ShouldBeNil)
+			})
+		})
+
+		Convey(".TLS is invoked with the intermediate profile", func() {
+
+			configuration := &servers.Configuration{
+				Certificate: fixtures.Base64Resource(certificate),
+				Key:         fixtures.Base64Resource(key),
+				Profile:     protocols.IntermediateProfile,
+			}
+
+			config, err := configuration.TLS()
+			So(err, ShouldBeNil)
+
+			address, server := tests.MustServe(t, config)
+			defer server.Close()
+
+			Convey("it rejects clients restricted to cipher suites outside the profile", func() {
+
+				client, err := (&Configuration{
+					Authorities:  authorities,
+					CipherSuites: protocols.CipherSuites{protocols.CipherSuite(tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA)},
+					MaxVersion:   tls.VersionTLS12,
+				}).HTTP()
+				So(err, ShouldBeNil)
+
+				So(request(client, address), ShouldNotBeNil)
+			})
+
+			Convey("it accepts clients using cipher suites of the profile", func() {
+
+				client, err := (&Configuration{Authorities: authorities, MaxVersion: tls.VersionTLS12, Profile: protocols.IntermediateProfile}).HTTP()
+				So(err, ShouldBeNil)
+
+				So(request(client, address), ShouldBeNil)
+			})
+		})
+
+		Convey(".TLS is invoked with the modern profile and a reload interval", func() {
+
+			configuration := &servers.Configuration{
+				Certificate: fixtures.Base64Resource(certificate),
+				Key:         fixtures.Base64Resource(key),
+				Profile:     protocols.ModernProfile,
+				Reload:      "30s",
+			}
+
+			config, err := configuration.TLS()
+			So(err, ShouldBeNil)
+
+			address, server := tests.MustServe(t, config)
+			defer server.Close()
+
+			Convey("it rejects TLS 1.2 clients", func() {
+				client, err := (&Configuration{Authorities: authorities, MaxVersion: tls.VersionTLS12}).HTTP()
+				So(err, ShouldBeNil)
+				So(request(client, address), ShouldNotBeNil)
+			})
+		})
+	})
+
+	Convey("When Configuration", t, func() {
+
+		config, err := (&servers.Configuration{
+			Certificate: fixtures.Base64Resource(certificate),
+			Key:         fixtures.Base64Resource(key),
+			MaxVersion:  tls.VersionTLS12,
+		}).TLS()
+		So(err, ShouldBeNil)
+
+		address, server := tests.MustServe(t, config)
+		defer server.Close()
+
+		Convey(".HTTP is invoked with the modern profile and a reload interval", func() {
+
+			client, err := (&Configuration{Authorities: authorities, Profile: protocols.ModernProfile, Reload: "30s"}).HTTP()
+			So(err, ShouldBeNil)
+
+			Convey("it rejects TLS 1.2 servers", func() {
+				So(request(client, address), ShouldNotBeNil)
+			})
+		})
+
+		Convey(".HTTP is invoked with the intermediate profile and a reload interval", func() {
+
+			client, err := (&Configuration{Authorities: authorities, Profile: protocols.IntermediateProfile, Reload: "30s"}).HTTP()
+			So(err, ShouldBeNil)
+
+			Convey("it accepts TLS 1.2 servers", func() {
+				So(request(client, address), ShouldBeNil)
+			})
+		})
+	})
+}
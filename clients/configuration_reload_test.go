@@ -0,0 +1,293 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"crypto/tls"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/greymatter-io/nautls/encoding"
+	"github.com/greymatter-io/nautls/internal/tests"
+	"github.com/greymatter-io/nautls/internal/tests/fixtures"
+	"github.com/greymatter-io/nautls/protocols"
+	"github.com/greymatter-io/nautls/servers"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// mustWriteSecret writes the files of a Kubernetes style secret volume (i.e., files linked through a "..data" symlink to
+// a timestamped directory) or fails a test.
+func mustWriteSecret(t *testing.T, directory string, version string, files map[string][]byte) {
+
+	versioned := filepath.Join(directory, "..", version)
+	err := os.MkdirAll(versioned, 0755)
+	if err != nil {
+		t.Fatalf("error creating secret version [%s]", err)
+	}
+
+	for name, data := range files {
+
+		err := os.WriteFile(filepath.Join(versioned, name), data, 0644)
+		if err != nil {
+			t.Fatalf("error writing secret file [%s]", err)
+		}
+
+		_, err = os.Lstat(filepath.Join(directory, name))
+		if os.IsNotExist(err) {
+
+			err = os.Symlink(filepath.Join("..data", name), filepath.Join(directory, name))
+			if err != nil {
+				t.Fatalf("error linking secret file [%s]", err)
+			}
+		}
+	}
+
+	temporary := filepath.Join(directory, "..data_tmp")
+
+	err = os.Symlink(filepath.Join("..", version), temporary)
+	if err != nil {
+		t.Fatalf("error linking secret version [%s]", err)
+	}
+
+	err = os.Rename(temporary, filepath.Join(directory, "..data"))
+	if err != nil {
+		t.Fatalf("error swapping secret version [%s]", err)
+	}
+}
+
+func TestConfigurationReload(t *testing.T) {
+
+	first, firstCertificate, firstKey := mustIssueServer(t)
+	second, secondCertificate, secondKey := mustIssueServer(t)
+
+	firstAuthority := fixtures.Authorities(first)
+	secondAuthority := fixtures.Authorities(second)
+
+	Convey("When servers.Configuration", t, func() {
+
+		directory := filepath.Join(t.TempDir(), "secret", "tls")
+		os.MkdirAll(directory, 0755)
+
+		mustWriteSecret(t, directory, "..2020_01", map[string][]byte{"tls.crt": firstCertificate, "tls.key": firstKey})
+
+		configuration := &servers.Configuration{
+			Certificate: filepath.Join(directory, "tls.crt"),
+			Key:         filepath.Join(directory, "tls.key"),
+			Reload:      "1h",
+		}
+
+		reloader, err := configuration.Reloader()
+		So(err, ShouldBeNil)
+
+		changes := 0
+		reloader.OnChange(func(*tls.Config) { changes++ })
+
+		failures := 0
+		reloader.OnError(func(error) { failures++ })
+
+		address, server := tests.MustServe(t, reloader.ServerConfig())
+		defer server.Close()
+
+		firstClient, _ := (&Configuration{Authorities: firstAuthority}).HTTP()
+		secondClient, _ := (&Configuration{Authorities: secondAuthority}).HTTP()
+
+		Convey(".Reloader is invoked", func() {
+
+			Convey("it serves the current certificate", func() {
+				So(request(firstClient, address), ShouldBeNil)
+				So(request(secondClient, address), ShouldNotBeNil)
+			})
+
+			Convey("and the secret is swapped", func() {
+
+				mustWriteSecret(t, directory, "..2020_02", map[string][]byte{"tls.crt": secondCertificate, "tls.key": secondKey})
+
+				err := reloader.Reload()
+
+				Convey("it returns a nil error", func() {
+					So(err, ShouldBeNil)
+				})
+
+				Convey("it invokes the change functions", func() {
+					So(changes, ShouldEqual, 1)
+				})
+
+				Convey("it serves the new certificate", func() {
+					So(request(secondClient, address), ShouldBeNil)
+					So(request(firstClient, address), ShouldNotBeNil)
+				})
+			})
+
+			Convey("and the secret is swapped to an invalid certificate", func() {
+
+				mustWriteSecret(t, directory, "..2020_02", map[string][]byte{"tls.crt": []byte("invalid"), "tls.key": secondKey})
+
+				err := reloader.Reload()
+
+				Convey("it returns a non-nil error", func() {
+					So(err, ShouldNotBeNil)
+				})
+
+				Convey("it invokes the error functions", func() {
+					So(failures, ShouldEqual, 1)
+					So(changes, ShouldEqual, 0)
+				})
+
+				Convey("it serves the last good certificate", func() {
+					So(request(firstClient, address), ShouldBeNil)
+				})
+			})
+
+			Convey("and the secret is not changed", func() {
+
+				err := reloader.Reload()
+
+				Convey("it does not invoke the change functions", func() {
+					So(err, ShouldBeNil)
+					So(changes, ShouldEqual, 0)
+				})
+			})
+		})
+	})
+
+	Convey("When Configuration", t, func() {
+
+		directory := filepath.Join(t.TempDir(), "secret", "ca")
+		os.MkdirAll(directory, 0755)
+
+		mustWriteSecret(t, directory, "..2020_01", map[string][]byte{"ca.crt": encoding.PEMEncodeCertificate(first.Certificate)})
+
+		firstConfig, _ := (&servers.Configuration{Certificate: fixtures.Base64Resource(firstCertificate), Key: fixtures.Base64Resource(firstKey)}).TLS()
+		firstAddress, firstServer := tests.MustServe(t, firstConfig)
+		defer firstServer.Close()
+
+		secondConfig, _ := (&servers.Configuration{Certificate: fixtures.Base64Resource(secondCertificate), Key: fixtures.Base64Resource(secondKey)}).TLS()
+		secondAddress, secondServer := tests.MustServe(t, secondConfig)
+		defer secondServer.Close()
+
+		configuration := &Configuration{Authorities: []string{filepath.Join(directory, "ca.crt")}, Reload: "1h"}
+
+		reloader, err := configuration.Reloader()
+		So(err, ShouldBeNil)
+
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: reloader.ClientConfig("")}}
+
+		Convey(".Reloader is invoked", func() {
+
+			Convey("it trusts the current authorities", func() {
+				So(request(client, firstAddress), ShouldBeNil)
+				So(request(client, secondAddress), ShouldNotBeNil)
+			})
+
+			Convey("and the authorities are swapped", func() {
+
+				mustWriteSecret(t, directory, "..2020_02", map[string][]byte{"ca.crt": encoding.PEMEncodeCertificate(second.Certificate)})
+				client.CloseIdleConnections()
+
+				So(reloader.Reload(), ShouldBeNil)
+
+				Convey("it trusts the new authorities", func() {
+					So(request(client, secondAddress), ShouldBeNil)
+					So(request(client, firstAddress), ShouldNotBeNil)
+				})
+			})
+		})
+
+		Convey(".TLS is invoked with protocol settings", func() {
+
+			config, err := (&Configuration{
+				Authorities:      []string{filepath.Join(directory, "ca.crt")},
+				CipherSuites:     protocols.CipherSuites{protocols.CipherSuite(tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256)},
+				CurvePreferences: protocols.Curves{protocols.Curve(tls.CurveP256)},
+				MaxVersion:       tls.VersionTLS12,
+				MinVersion:       tls.VersionTLS12,
+				NextProtos:       []string{"http/1.1"},
+				Reload:           "1h",
+			}).TLS()
+
+			Convey("it returns a nil error", func() {
+				So(err, ShouldBeNil)
+			})
+
+			Convey("it keeps the protocol settings", func() {
+				So(config.CipherSuites, ShouldResemble, []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256})
+				So(config.CurvePreferences, ShouldResemble, []tls.CurveID{tls.CurveP256})
+				So(config.MaxVersion, ShouldEqual, tls.VersionTLS12)
+				So(config.MinVersion, ShouldEqual, tls.VersionTLS12)
+				So(config.NextProtos, ShouldResemble, []string{"http/1.1"})
+			})
+
+			Convey("it replaces the certificates and verification", func() {
+				So(config.Certificates, ShouldBeEmpty)
+				So(config.GetClientCertificate, ShouldNotBeNil)
+				So(config.VerifyConnection, ShouldNotBeNil)
+			})
+		})
+
+		Convey(".TLS is invoked with protocol settings and a server is dialed", func() {
+
+			serverConfig, _ := (&servers.Configuration{
+				Certificate: fixtures.Base64Resource(firstCertificate),
+				Key:         fixtures.Base64Resource(firstKey),
+				NextProtos:  []string{"h2", "http/1.1"},
+			}).TLS()
+			address, server := tests.MustServe(t, serverConfig)
+			defer server.Close()
+
+			config, err := (&Configuration{
+				Authorities:  []string{filepath.Join(directory, "ca.crt")},
+				CipherSuites: protocols.CipherSuites{protocols.CipherSuite(tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384)},
+				MaxVersion:   tls.VersionTLS12,
+				NextProtos:   []string{"http/1.1"},
+				Reload:       "1h",
+			}).TLS()
+			So(err, ShouldBeNil)
+
+			connection, err := tls.Dial("tcp", address, config)
+			So(err, ShouldBeNil)
+			defer connection.Close()
+
+			state := connection.ConnectionState()
+
+			Convey("it negotiates the maximum version", func() {
+				So(state.Version, ShouldEqual, tls.VersionTLS12)
+			})
+
+			Convey("it negotiates the cipher suite", func() {
+				So(state.CipherSuite, ShouldEqual, tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384)
+			})
+
+			Convey("it negotiates the application protocol", func() {
+				So(state.NegotiatedProtocol, ShouldEqual, "http/1.1")
+			})
+		})
+
+		Convey(".HTTP is invoked with an invalid reload interval", func() {
+
+			client, err := (&Configuration{Reload: "invalid"}).HTTP()
+
+			Convey("it returns a non-nil error", func() {
+				So(err, ShouldNotBeNil)
+			})
+
+			Convey("it returns a nil client", func() {
+				So(client, ShouldBeNil)
+			})
+		})
+	})
+}
@@ -0,0 +1,207 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/greymatter-io/nautls/encoding"
+	"github.com/greymatter-io/nautls/identities"
+	"github.com/greymatter-io/nautls/internal/tests/fixtures"
+	"github.com/greymatter-io/nautls/servers"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// mustRevocationList returns a PEM encoded revocation list revoking serial numbers or fails a test.
+func mustRevocationList(t *testing.T, authority *identities.Identity, thisUpdate time.Time, nextUpdate time.Time, serials ...int64) string {
+
+	revocations := &identities.Revocations{}
+	for _, serial := range serials {
+		revocations.Revoke(big.NewInt(serial), identities.KeyCompromise, thisUpdate)
+	}
+
+	list, err := authority.RevocationList(identities.RevocationListTemplate{
+		NextUpdate:  nextUpdate,
+		Number:      big.NewInt(1),
+		Revocations: revocations.List(),
+		ThisUpdate:  thisUpdate,
+	})
+	if err != nil {
+		t.Fatalf("error creating revocation list [%s]", err)
+	}
+
+	return fixtures.Base64Resource(encoding.PEMEncodeRevocationList(list))
+}
+
+func TestConfigurationRevocations(t *testing.T) {
+
+	authority := fixtures.MustAuthority(t, "NauTLS (Authority)", identities.ECDSA)
+
+	server := fixtures.MustIssue(t, authority, identities.Template{
+		DNSNames:     []string{"localhost"},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		KeyAlgorithm: identities.ECDSA,
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "localhost"},
+	})
+
+	client := fixtures.MustIssue(t, authority, identities.Template{
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		KeyAlgorithm: identities.ECDSA,
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "client"},
+	})
+
+	authorities := fixtures.Authorities(authority)
+	serverCertificate, serverKey := fixtures.Resources(server)
+	clientCertificate, clientKey := fixtures.Resources(client)
+
+	now := time.Now()
+	revokesNothing := mustRevocationList(t, authority, now.Add(-time.Minute), now.Add(time.Hour))
+	revokesServer := mustRevocationList(t, authority, now.Add(-time.Minute), now.Add(time.Hour), 2)
+	revokesClient := mustRevocationList(t, authority, now.Add(-time.Minute), now.Add(time.Hour), 3)
+	stale := mustRevocationList(t, authority, now.Add(-2*time.Hour), now.Add(-time.Hour))
+	forged := mustRevocationList(t, fixtures.MustAuthority(t, "NauTLS (Authority)", identities.ECDSA), now.Add(-time.Minute), now.Add(time.Hour))
+
+	shouldBeTLSClient := shouldBeClient(t, "https", &servers.Configuration{
+		Certificate: serverCertificate,
+		Key:         serverKey,
+	})
+
+	shouldNotBeTLSClient := shouldNotBeClient(t, "https", &servers.Configuration{
+		Certificate: serverCertificate,
+		Key:         serverKey,
+	})
+
+	shouldBeMTLSClient := func(revocations ...string) func(interface{}, ...interface{}) string {
+		return shouldBeClient(t, "https", &servers.Configuration{
+			Authorities:    authorities,
+			Certificate:    serverCertificate,
+			Key:            serverKey,
+			Revocations:    revocations,
+			Authentication: servers.Authentication(tls.RequireAndVerifyClientCert),
+		})
+	}
+
+	shouldNotBeMTLSClient := func(revocations ...string) func(interface{}, ...interface{}) string {
+		return shouldNotBeClient(t, "https", &servers.Configuration{
+			Authorities:    authorities,
+			Certificate:    serverCertificate,
+			Key:            serverKey,
+			Revocations:    revocations,
+			Authentication: servers.Authentication(tls.RequireAndVerifyClientCert),
+		})
+	}
+
+	Convey("When Configuration", t, func() {
+
+		Convey(".HTTP is invoked", func() {
+
+			Convey("and the revocations do not revoke the server", func() {
+
+				configuration := &Configuration{Authorities: authorities, Revocations: []string{revokesClient}}
+
+				client, err := configuration.HTTP()
+
+				Convey("it returns a nil error", func() {
+					So(err, ShouldBeNil)
+				})
+
+				Convey("it returns a valid TLS client", func() {
+					So(client, shouldBeTLSClient)
+				})
+			})
+
+			Convey("and the revocations revoke the server", func() {
+
+				configuration := &Configuration{Authorities: authorities, Revocations: []string{revokesServer}}
+
+				client, err := configuration.HTTP()
+
+				Convey("it returns a nil error", func() {
+					So(err, ShouldBeNil)
+				})
+
+				Convey("it returns an invalid TLS client", func() {
+					So(client, shouldNotBeTLSClient)
+				})
+			})
+
+			Convey("and the revocations are stale", func() {
+
+				configuration := &Configuration{Authorities: authorities, Revocations: []string{stale}}
+
+				client, _ := configuration.HTTP()
+
+				Convey("it returns an invalid TLS client", func() {
+					So(client, shouldNotBeTLSClient)
+				})
+			})
+
+			Convey("and the revocations are not signed by the authority", func() {
+
+				configuration := &Configuration{Authorities: authorities, Revocations: []string{forged}}
+
+				client, _ := configuration.HTTP()
+
+				Convey("it returns an invalid TLS client", func() {
+					So(client, shouldNotBeTLSClient)
+				})
+			})
+
+			Convey("and the revocations are invalid", func() {
+
+				configuration := &Configuration{Authorities: authorities, Revocations: []string{fixtures.Base64Resource([]byte("invalid"))}}
+
+				client, err := configuration.HTTP()
+
+				Convey("it returns a non-nil error", func() {
+					So(err, ShouldNotBeNil)
+				})
+
+				Convey("it returns a nil client", func() {
+					So(client, ShouldBeNil)
+				})
+			})
+
+			Convey("and the configuration is mTLS", func() {
+
+				configuration := &Configuration{
+					Authorities: authorities,
+					Certificate: clientCertificate,
+					Key:         clientKey,
+				}
+
+				client, _ := configuration.HTTP()
+
+				Convey("it returns a valid mTLS client when the server revokes nothing", func() {
+					So(client, shouldBeMTLSClient(revokesNothing))
+				})
+
+				Convey("it returns an invalid mTLS client when the server revokes the client", func() {
+					So(client, shouldNotBeMTLSClient(revokesClient))
+				})
+			})
+		})
+	})
+}
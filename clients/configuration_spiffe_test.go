@@ -0,0 +1,179 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"crypto/tls"
+	"testing"
+	"time"
+
+	"github.com/greymatter-io/nautls/identities"
+	"github.com/greymatter-io/nautls/internal/tests"
+	"github.com/greymatter-io/nautls/internal/tests/fixtures"
+	"github.com/greymatter-io/nautls/servers"
+	"github.com/greymatter-io/nautls/spiffe"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// mustIssueSVID issues an X.509-SVID for a SPIFFE ID and returns its PEM encoded certificate and key resources.
+func mustIssueSVID(t *testing.T, authority *identities.Identity, id string) (string, string) {
+
+	template, err := identities.SVIDTemplate(id, time.Hour)
+	if err != nil {
+		t.Fatalf("error creating svid template [%s]", err)
+	}
+
+	return fixtures.Resources(fixtures.MustIssue(t, authority, template))
+}
+
+func TestConfigurationSPIFFE(t *testing.T) {
+
+	template, err := identities.SVIDAuthorityTemplate("example.org", time.Hour)
+	if err != nil {
+		t.Fatalf("error creating svid authority template [%s]", err)
+	}
+
+	authority, err := identities.Self(template)
+	if err != nil {
+		t.Fatalf("error creating svid authority [%s]", err)
+	}
+
+	authorities := fixtures.Authorities(authority)
+
+	serverCertificate, serverKey := mustIssueSVID(t, authority, "spiffe://example.org/ns/production/sa/api")
+	clientCertificate, clientKey := mustIssueSVID(t, authority, "spiffe://example.org/ns/production/sa/web")
+	otherCertificate, otherKey := mustIssueSVID(t, authority, "spiffe://example.org/ns/staging/sa/web")
+
+	Convey("When servers.Configuration requires a spiffe id", t, func() {
+
+		config, err := (&servers.Configuration{
+			Authentication: servers.Authentication(tls.RequireAndVerifyClientCert),
+			Authorities:    authorities,
+			Certificate:    serverCertificate,
+			Key:            serverKey,
+			SPIFFE:         []spiffe.Matcher{{Prefix: "spiffe://example.org/ns/production"}},
+		}).TLS()
+		So(err, ShouldBeNil)
+
+		address, server := tests.MustServe(t, config)
+		defer server.Close()
+
+		Convey("and the client presents a matching svid and matches the server by trust domain", func() {
+
+			client, err := (&Configuration{
+				Authorities: authorities,
+				Certificate: clientCertificate,
+				Key:         clientKey,
+				SPIFFE:      []spiffe.Matcher{{TrustDomain: "example.org"}},
+			}).HTTP()
+			So(err, ShouldBeNil)
+
+			Convey("it accepts the connection without a dns name", func() {
+				So(request(client, address), ShouldBeNil)
+			})
+		})
+
+		Convey("and the client matches the server by id with reloading", func() {
+
+			client, err := (&Configuration{
+				Authorities: authorities,
+				Certificate: clientCertificate,
+				Key:         clientKey,
+				Reload:      "1m",
+				SPIFFE:      []spiffe.Matcher{{ID: "spiffe://example.org/ns/production/sa/api"}},
+			}).HTTP()
+			So(err, ShouldBeNil)
+
+			Convey("it accepts the connection", func() {
+				So(request(client, address), ShouldBeNil)
+			})
+		})
+
+		Convey("and the client expects another server id", func() {
+
+			client, err := (&Configuration{
+				Authorities: authorities,
+				Certificate: clientCertificate,
+				Key:         clientKey,
+				SPIFFE:      []spiffe.Matcher{{ID: "spiffe://example.org/ns/production/sa/db"}},
+			}).HTTP()
+			So(err, ShouldBeNil)
+
+			err = request(client, address)
+
+			Convey("it rejects the server naming its id", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "spiffe://example.org/ns/production/sa/api")
+			})
+		})
+
+		Convey("and the client presents an svid outside the prefix", func() {
+
+			client, err := (&Configuration{
+				Authorities: authorities,
+				Certificate: otherCertificate,
+				Key:         otherKey,
+				SPIFFE:      []spiffe.Matcher{{TrustDomain: "example.org"}},
+			}).HTTP()
+			So(err, ShouldBeNil)
+
+			Convey("it rejects the client", func() {
+				So(request(client, address), ShouldNotBeNil)
+			})
+		})
+
+		Convey("and the client verifies the server by dns name", func() {
+
+			client, err := (&Configuration{Authorities: authorities, Certificate: clientCertificate, Key: clientKey}).HTTP()
+			So(err, ShouldBeNil)
+
+			Convey("it rejects the server without a dns name", func() {
+				So(request(client, address), ShouldNotBeNil)
+			})
+		})
+	})
+
+	Convey("When Configuration trusts a spiffe bundle", t, func() {
+
+		bundle, err := authority.ToSPIFFEBundle(identities.SPIFFEBundleOptions{Sequence: 1})
+		So(err, ShouldBeNil)
+
+		config, err := (&servers.Configuration{Certificate: serverCertificate, Key: serverKey}).TLS()
+		So(err, ShouldBeNil)
+
+		address, server := tests.MustServe(t, config)
+		defer server.Close()
+
+		client, err := (&Configuration{
+			Authorities: []string{fixtures.Base64Resource(bundle)},
+			SPIFFE:      []spiffe.Matcher{{TrustDomain: "example.org"}},
+		}).HTTP()
+		So(err, ShouldBeNil)
+
+		Convey("it verifies the server against the authorities of the bundle", func() {
+			So(request(client, address), ShouldBeNil)
+		})
+	})
+
+	Convey("When Configuration is invoked with an invalid spiffe matcher", t, func() {
+
+		_, err := (&Configuration{SPIFFE: []spiffe.Matcher{{}}}).TLS()
+
+		Convey("it returns a non-nil error", func() {
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
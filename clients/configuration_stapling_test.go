@@ -0,0 +1,313 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/greymatter-io/nautls/encoding"
+	"github.com/greymatter-io/nautls/identities"
+	"github.com/greymatter-io/nautls/internal/tests"
+	"github.com/greymatter-io/nautls/internal/tests/fixtures"
+	"github.com/greymatter-io/nautls/responders"
+	"github.com/greymatter-io/nautls/servers"
+	"golang.org/x/crypto/ocsp"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// awaitStaple waits until a server staples its certificate as staples are fetched in the background.
+func awaitStaple(t *testing.T, config *tls.Config) {
+
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+
+		certificate, err := config.GetCertificate(nil)
+		if err != nil {
+			t.Fatalf("error getting server certificate [%s]", err)
+		}
+
+		if certificate.OCSPStaple != nil {
+			return
+		}
+	}
+
+	t.Fatalf("error waiting for server staple")
+}
+
+// stapledRequest sends a request with a client to a server once it staples its certificate.
+func stapledRequest(t *testing.T, client *http.Client, configuration *servers.Configuration) error {
+
+	config, err := configuration.TLS()
+	if err != nil {
+		t.Fatalf("error creating server tls configuration [%s]", err)
+	}
+
+	awaitStaple(t, config)
+
+	address, server := tests.MustServe(t, config)
+	defer server.Close()
+
+	response, err := client.Get(fmt.Sprintf("https://%s", address))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("unexpected status code [%d]", response.StatusCode)
+	}
+
+	return nil
+}
+
+// shouldBeStaplingClient returns a function that validates whether a client is compatible with a server stapling its
+// certificate.
+func shouldBeStaplingClient(t *testing.T, configuration *servers.Configuration) func(interface{}, ...interface{}) string {
+
+	return func(actual interface{}, expected ...interface{}) string {
+
+		client, ok := actual.(*http.Client)
+		if !ok {
+			return "expected http client but was not http client"
+		}
+
+		err := stapledRequest(t, client, configuration)
+		if err != nil {
+			return fmt.Sprintf("expected nil error but was [%s]", err.Error())
+		}
+
+		return ""
+	}
+}
+
+// shouldNotBeStaplingClient returns a function that validates whether a client is not compatible with a server
+// stapling its certificate.
+func shouldNotBeStaplingClient(t *testing.T, configuration *servers.Configuration) func(interface{}, ...interface{}) string {
+
+	return func(actual interface{}, expected ...interface{}) string {
+
+		client, ok := actual.(*http.Client)
+		if !ok {
+			return "expected http client but was not http client"
+		}
+
+		if stapledRequest(t, client, configuration) == nil {
+			return "expected incompatable client but was valid"
+		}
+
+		return ""
+	}
+}
+
+func TestConfigurationStapling(t *testing.T) {
+
+	authority := fixtures.MustAuthority(t, "NauTLS (Authority)", identities.ECDSA)
+	revocations := &identities.Revocations{}
+
+	responder := responders.NewResponder(authority, nil, responders.RevocationsSource(revocations))
+	responderServer := httptest.NewServer(responder)
+	defer responderServer.Close()
+
+	feature, _ := asn1.Marshal([]int{5})
+
+	issue := func(responder string, serial int64, extensions ...pkix.Extension) (string, string) {
+
+		server := fixtures.MustIssue(t, authority, identities.Template{
+			DNSNames:        []string{"localhost"},
+			ExtKeyUsage:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+			ExtraExtensions: extensions,
+			KeyAlgorithm:    identities.ECDSA,
+			OCSPServer:      []string{responder},
+			SerialNumber:    big.NewInt(serial),
+			Subject:         pkix.Name{CommonName: "localhost"},
+		})
+
+		chain := append(encoding.PEMEncodeCertificate(server.Certificate), encoding.PEMEncodeCertificate(authority.Certificate)...)
+
+		return fixtures.Base64Resource(chain), fixtures.Base64Resource(encoding.PEMEncodeKey(server.Key))
+	}
+
+	authorities := fixtures.Authorities(authority)
+	goodCertificate, goodKey := issue(responderServer.URL, 2)
+	revokedCertificate, revokedKey := issue(responderServer.URL, 3)
+	mustStapleCertificate, mustStapleKey := issue(responderServer.URL, 4, pkix.Extension{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 1, 24}, Value: feature})
+
+	revocations.Revoke(big.NewInt(3), identities.KeyCompromise, time.Now().Add(-time.Minute))
+
+	Convey("When Configuration", t, func() {
+
+		Convey(".HTTP is invoked", func() {
+
+			Convey("and a staple is required", func() {
+
+				client, err := (&Configuration{Authorities: authorities, Stapling: RequireStaple}).HTTP()
+				So(err, ShouldBeNil)
+
+				Convey("it returns a valid client for a server stapling a good response", func() {
+					So(client, shouldBeStaplingClient(t, &servers.Configuration{Certificate: goodCertificate, Key: goodKey, Stapling: true}))
+				})
+
+				Convey("it returns a valid client for a server stapling a response from a resource", func() {
+
+					request, _ := ocsp.CreateRequest(mustLeaf(t, goodCertificate), authority.Certificate, nil)
+					staple, _ := responder.Respond(request)
+
+					So(client, shouldBeStaplingClient(t, &servers.Configuration{Certificate: goodCertificate, Key: goodKey, Staple: fixtures.Base64Resource(staple)}))
+				})
+
+				Convey("it returns an invalid client for a server stapling a revoked response", func() {
+					So(client, shouldNotBeStaplingClient(t, &servers.Configuration{Certificate: revokedCertificate, Key: revokedKey, Stapling: true}))
+				})
+
+				Convey("it returns an invalid client for a server that does not staple", func() {
+					So(client, shouldNotBeClient(t, "https", &servers.Configuration{Certificate: goodCertificate, Key: goodKey}))
+				})
+			})
+
+			Convey("and a staple is required for must-staple certificates", func() {
+
+				client, err := (&Configuration{Authorities: authorities, Stapling: MustStaple}).HTTP()
+				So(err, ShouldBeNil)
+
+				Convey("it returns a valid client for a server that does not staple a certificate without must-staple", func() {
+					So(client, shouldBeClient(t, "https", &servers.Configuration{Certificate: goodCertificate, Key: goodKey}))
+				})
+
+				Convey("it returns an invalid client for a server that does not staple a must-staple certificate", func() {
+					So(client, shouldNotBeClient(t, "https", &servers.Configuration{Certificate: mustStapleCertificate, Key: mustStapleKey}))
+				})
+
+				Convey("it returns a valid client for a server that staples a must-staple certificate", func() {
+					So(client, shouldBeStaplingClient(t, &servers.Configuration{Certificate: mustStapleCertificate, Key: mustStapleKey, Stapling: true}))
+				})
+			})
+
+			Convey("and staples are ignored", func() {
+
+				client, err := (&Configuration{Authorities: authorities}).HTTP()
+				So(err, ShouldBeNil)
+
+				Convey("it returns a valid client for a server stapling a revoked response", func() {
+					So(client, shouldBeStaplingClient(t, &servers.Configuration{Certificate: revokedCertificate, Key: revokedKey, Stapling: true}))
+				})
+			})
+		})
+	})
+
+	Convey("When servers.Configuration", t, func() {
+
+		Convey(".TLS is invoked with stapling for a certificate without an issuer in its chain", func() {
+
+			certificate := fixtures.Base64Resource(encoding.PEMEncodeCertificate(mustLeaf(t, goodCertificate)))
+			config, err := (&servers.Configuration{Certificate: certificate, Key: goodKey, Stapling: true}).TLS()
+
+			Convey("it returns a non-nil error", func() {
+				So(err, ShouldNotBeNil)
+			})
+
+			Convey("it returns a nil configuration", func() {
+				So(config, ShouldBeNil)
+			})
+		})
+
+		Convey(".Reloader is invoked with stapling for a certificate whose responder fails", func() {
+
+			release := make(chan struct{})
+
+			failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				<-release
+				w.WriteHeader(http.StatusInternalServerError)
+			}))
+			defer failing.Close()
+
+			certificate, key := issue(failing.URL, 5)
+
+			reloader, err := (&servers.Configuration{Certificate: certificate, Key: key, Reload: "1m", Stapling: true}).Reloader()
+			So(err, ShouldBeNil)
+
+			failures := make(chan error, 1)
+			reloader.OnError(func(err error) { failures <- err })
+
+			served, err := reloader.Config().GetCertificate(nil)
+			So(err, ShouldBeNil)
+
+			close(release)
+
+			Convey("it serves the certificate without a staple and reports the failure", func() {
+
+				So(served.OCSPStaple, ShouldBeNil)
+				So((<-failures).Error(), ShouldContainSubstring, "error stapling")
+			})
+		})
+
+		Convey(".Reloader is invoked with stapling and its resources change", func() {
+
+			requests := make(chan *http.Request, 4)
+			release := make(chan struct{})
+
+			blocking := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				io.ReadAll(r.Body)
+				requests <- r
+				select {
+				case <-r.Context().Done():
+				case <-release:
+				}
+				w.WriteHeader(http.StatusInternalServerError)
+			}))
+			defer blocking.Close()
+			defer close(release)
+
+			certificate, key := issue(blocking.URL, 6)
+
+			path := filepath.Join(t.TempDir(), "authority.crt")
+			data := encoding.PEMEncodeCertificate(authority.Certificate)
+
+			err := os.WriteFile(path, data, 0600)
+			So(err, ShouldBeNil)
+
+			reloader, err := (&servers.Configuration{Authorities: []string{path}, Certificate: certificate, Key: key, Reload: "1m", Stapling: true}).Reloader()
+			So(err, ShouldBeNil)
+
+			replaced := <-requests
+
+			err = os.WriteFile(path, append(data, data...), 0600)
+			So(err, ShouldBeNil)
+			So(reloader.Reload(), ShouldBeNil)
+
+			Convey("it cancels the staple fetch of the replaced configuration", func() {
+
+				select {
+				case <-replaced.Context().Done():
+				case <-time.After(5 * time.Second):
+					t.Fatalf("error waiting for the staple fetch of the replaced configuration to be canceled")
+				}
+
+				So(<-requests, ShouldNotBeNil)
+			})
+		})
+	})
+}
@@ -2,11 +2,20 @@ package clients
 
 import (
 	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
 	"fmt"
+	"math/big"
 	"net/http"
+	"net/url"
 	"testing"
 
+	"github.com/greymatter-io/nautls/encoding"
+	"github.com/greymatter-io/nautls/identities"
 	"github.com/greymatter-io/nautls/internal/tests"
+	"github.com/greymatter-io/nautls/internal/tests/fixtures"
 	"github.com/greymatter-io/nautls/servers"
 
 	. "github.com/smartystreets/goconvey/convey"
@@ -16,6 +25,9 @@ const (
 	AuthorityCertificate = "./testdata/authority.crt"
 	ClientCertificate    = "./testdata/client.crt"
 	ClientKey            = "./testdata/client.key"
+	ClientEncryptedKey   = "./testdata/client.encrypted.key"
+	ClientPassphrase     = "./testdata/passphrase.txt"
+	ClientPKCS12         = "./testdata/client.p12"
 	ServerCertificate    = "./testdata/server.crt"
 	ServerKey            = "./testdata/server.key"
 )
@@ -211,6 +223,159 @@ func TestConfiguration(t *testing.T) {
 					So(client, shouldBeMTLSClient)
 				})
 			})
+
+			Convey("and the configuration is mTLS with an encrypted key", func() {
+
+				configuration := &Configuration{
+					Authorities: []string{AuthorityCertificate},
+					Certificate: ClientCertificate,
+					Key:         ClientEncryptedKey,
+					Passphrase:  ClientPassphrase,
+				}
+
+				client, err := configuration.HTTP()
+
+				Convey("it returns a nil error", func() {
+					So(err, ShouldBeNil)
+				})
+
+				Convey("it returns a valid mTLS client", func() {
+					So(client, shouldBeMTLSClient)
+				})
+			})
+
+			Convey("and the configuration is mTLS with a pkcs12 archive", func() {
+
+				configuration := &Configuration{
+					Authorities: []string{AuthorityCertificate},
+					PKCS12:      ClientPKCS12,
+					Passphrase:  ClientPassphrase,
+				}
+
+				client, err := configuration.HTTP()
+
+				Convey("it returns a nil error", func() {
+					So(err, ShouldBeNil)
+				})
+
+				Convey("it returns a valid mTLS client", func() {
+					So(client, shouldBeMTLSClient)
+				})
+			})
+
+			Convey("and the configuration is mTLS with both a key pair and a pkcs12 archive", func() {
+
+				configuration := &Configuration{
+					Authorities: []string{AuthorityCertificate},
+					Certificate: ClientCertificate,
+					Key:         ClientKey,
+					PKCS12:      ClientPKCS12,
+					Passphrase:  ClientPassphrase,
+				}
+
+				client, err := configuration.HTTP()
+
+				Convey("it returns a non-nil error", func() {
+					So(err, ShouldNotBeNil)
+					So(err.Error(), ShouldContainSubstring, "certificate and key must not be defined with pkcs12")
+				})
+
+				Convey("it returns a nil client", func() {
+					So(client, ShouldBeNil)
+				})
+			})
+
+			Convey("and the security is mTLS with both a key pair and a pkcs12 archive", func() {
+
+				config, err := (&SecurityConfig{
+					Authorities: []string{AuthorityCertificate},
+					Certificate: ClientCertificate,
+					Key:         ClientKey,
+					PKCS12:      ClientPKCS12,
+					Passphrase:  ClientPassphrase,
+				}).Build()
+
+				Convey("it returns a non-nil error", func() {
+					So(err, ShouldNotBeNil)
+					So(err.Error(), ShouldContainSubstring, "certificate and key must not be defined with pkcs12")
+				})
+
+				Convey("it returns a nil configuration", func() {
+					So(config, ShouldBeNil)
+				})
+			})
+
+			Convey("and the configuration is mTLS with an encrypted key and no passphrase", func() {
+
+				configuration := &Configuration{
+					Authorities: []string{AuthorityCertificate},
+					Certificate: ClientCertificate,
+					Key:         ClientEncryptedKey,
+				}
+
+				client, err := configuration.HTTP()
+
+				Convey("it returns a non-nil error", func() {
+					So(err, ShouldNotBeNil)
+				})
+
+				Convey("it returns a nil client", func() {
+					So(client, ShouldBeNil)
+				})
+			})
 		})
 	})
 }
+
+// mustLeaf returns the first certificate of a base64 scheme URL or fails a test.
+func mustLeaf(t *testing.T, resource string) *x509.Certificate {
+
+	escaped, err := url.PathUnescape(resource[len("base64:///"):])
+	if err != nil {
+		t.Fatalf("error unescaping resource [%s]", err)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(escaped)
+	if err != nil {
+		t.Fatalf("error decoding resource [%s]", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		t.Fatalf("error decoding certificate")
+	}
+
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("error parsing certificate [%s]", err)
+	}
+
+	return certificate
+}
+
+// mustIssueServer returns an authority and a PEM encoded server certificate and key issued by it or fails a test.
+func mustIssueServer(t *testing.T) (*identities.Identity, []byte, []byte) {
+
+	authority := fixtures.MustAuthority(t, "NauTLS (Authority)", identities.ECDSA)
+
+	server := fixtures.MustIssue(t, authority, identities.Template{
+		DNSNames:     []string{"localhost"},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		KeyAlgorithm: identities.ECDSA,
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "localhost"},
+	})
+
+	return authority, encoding.PEMEncodeCertificate(server.Certificate), encoding.PEMEncodeKey(server.Key)
+}
+
+// request returns the error of a request to an address.
+func request(client *http.Client, address string) error {
+
+	response, err := client.Get(fmt.Sprintf("https://%s", address))
+	if err != nil {
+		return err
+	}
+
+	return response.Body.Close()
+}
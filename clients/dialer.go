@@ -0,0 +1,175 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"slices"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Dialer provides context aware dialing of TLS connections for protocols other than HTTP (e.g., database drivers that
+// accept a dial function). Connections are made to the address dialed or of its route and are returned once the TLS
+// handshake completes.
+type Dialer struct {
+	configuration    *tls.Config
+	dialer           *net.Dialer
+	excludedProtos   []string
+	handshakeTimeout time.Duration
+	host             string
+	nextProtos       []string
+	routes           routes
+}
+
+// Dial connects to an address and completes the TLS handshake. See DialContext.
+func (d *Dialer) Dial(network string, address string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, address)
+}
+
+// DialContext connects to an address and completes the TLS handshake using a context. The network must be a "tcp"
+// network (i.e., "tcp", "tcp4" or "tcp6") with a host and port address or "unix" with the path of a socket and other
+// networks are rejected. Note that the returned connection is a *tls.Conn whose negotiated state is available using
+// ConnectionState.
+func (d *Dialer) DialContext(ctx context.Context, network string, address string) (net.Conn, error) {
+
+	connection, err := d.DialTLSContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	return connection, nil
+}
+
+// DialTLSContext connects to an address and completes the TLS handshake using a context. The handshake must complete
+// within the handshake timeout of the configuration and before the context is done.
+//
+// Note that the server name defaults to the host of the address rather than the address of its route and to the host of
+// the configuration for unix sockets and that a default tls.Config is used when the configuration or the route does not
+// define one.
+func (d *Dialer) DialTLSContext(ctx context.Context, network string, address string) (*tls.Conn, error) {
+
+	switch network {
+	case "tcp", "tcp4", "tcp6", "unix":
+	default:
+		return nil, errors.Errorf("error dialing [%s] with unsupported network [%s]", address, network)
+	}
+
+	name := d.host
+	config := d.configuration
+	dialed := address
+
+	if network != "unix" {
+
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error parsing address [%s]", address)
+		}
+
+		name = host
+		dialed, config = d.routes.resolve(address, d.configuration)
+	}
+
+	if config == nil {
+		config = &tls.Config{}
+	}
+
+	config = config.Clone()
+	if config.ServerName == "" {
+		config.ServerName = name
+	}
+
+	if len(config.NextProtos) == 0 {
+		config.NextProtos = d.nextProtos
+	}
+
+	config.NextProtos = excludeProtos(config.NextProtos, d.excludedProtos)
+
+	connection, err := d.netDialer().DialContext(ctx, network, dialed)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error dialing [%s]", dialed)
+	}
+
+	if d.handshakeTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.handshakeTimeout)
+		defer cancel()
+	}
+
+	client := tls.Client(connection, config)
+
+	err = client.HandshakeContext(ctx)
+	if err != nil {
+		connection.Close()
+		return nil, errors.Wrapf(err, "error completing tls handshake with [%s]", dialed)
+	}
+
+	return client, nil
+}
+
+// dialContext connects to an address or the address of its route without TLS (e.g., for plaintext requests and
+// proxies).
+func (d *Dialer) dialContext(ctx context.Context, network string, address string) (net.Conn, error) {
+
+	if network != "unix" {
+		address, _ = d.routes.resolve(address, d.configuration)
+	}
+
+	return d.netDialer().DialContext(ctx, network, address)
+}
+
+// netDialer returns the net.Dialer of the dialer or a default net.Dialer when it is not defined (e.g., for a Dialer that
+// was not built from a configuration).
+func (d *Dialer) netDialer() *net.Dialer {
+
+	if d.dialer == nil {
+		return &net.Dialer{}
+	}
+
+	return d.dialer
+}
+
+// excludeProtos returns the application level protocols without those excluded.
+func excludeProtos(protos []string, excluded []string) []string {
+
+	if len(excluded) == 0 {
+		return protos
+	}
+
+	included := []string{}
+
+	for _, proto := range protos {
+		if !slices.Contains(excluded, proto) {
+			included = append(included, proto)
+		}
+	}
+
+	return included
+}
+
+// ConnectionState returns the negotiated state of a TLS connection returned by a Dialer. Note that false is returned
+// when the connection is not a TLS connection.
+func ConnectionState(connection net.Conn) (tls.ConnectionState, bool) {
+
+	client, ok := connection.(*tls.Conn)
+	if !ok {
+		return tls.ConnectionState{}, false
+	}
+
+	return client.ConnectionState(), true
+}
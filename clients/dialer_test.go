@@ -0,0 +1,245 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/greymatter-io/nautls/identities"
+	"github.com/greymatter-io/nautls/internal/tests/fixtures"
+	"github.com/greymatter-io/nautls/servers"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// echo accepts connections from a listener and echoes a line read from each.
+func echo(listener net.Listener) {
+
+	for {
+
+		connection, err := listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go func() {
+			defer connection.Close()
+			line, err := bufio.NewReader(connection).ReadString('\n')
+			if err == nil {
+				fmt.Fprint(connection, line)
+			}
+		}()
+	}
+}
+
+// ping writes a line to a connection and returns the line read back.
+func ping(connection net.Conn) (string, error) {
+
+	_, err := fmt.Fprint(connection, "ping\n")
+	if err != nil {
+		return "", err
+	}
+
+	return bufio.NewReader(connection).ReadString('\n')
+}
+
+func TestClientConfigDialer(t *testing.T) {
+
+	authority := fixtures.MustAuthority(t, "NauTLS (Authority)", identities.ECDSA)
+	authorities := fixtures.Authorities(authority)
+
+	serverCertificate, serverKey := fixtures.MustIssueServer(t, authority, identities.ECDSA, "localhost", "localhost")
+
+	config, err := (&servers.Configuration{Certificate: serverCertificate, Key: serverKey, NextProtos: []string{"nautls"}}).TLS()
+	if err != nil {
+		t.Fatalf("error building server configuration [%s]", err)
+	}
+
+	Convey("When Dialer dials a TLS server over tcp", t, func() {
+
+		listener, err := tls.Listen("tcp", "localhost:0", config)
+		So(err, ShouldBeNil)
+		defer listener.Close()
+
+		go echo(listener)
+
+		_, port, _ := net.SplitHostPort(listener.Addr().String())
+
+		dialer, err := (&ClientConfig{
+			HandshakeTimeout: "5s",
+			Routes:           []Route{{Address: listener.Addr().String(), Host: "db.nautls.test"}},
+			Security:         SecurityConfig{Authorities: authorities, NextProtos: []string{"nautls"}, Server: "localhost"},
+		}).Dialer()
+		So(err, ShouldBeNil)
+
+		Convey("and the address is dialed directly", func() {
+
+			connection, err := dialer.DialContext(context.Background(), "tcp", net.JoinHostPort("localhost", port))
+			So(err, ShouldBeNil)
+			defer connection.Close()
+
+			line, err := ping(connection)
+
+			Convey("it exchanges data over the connection", func() {
+				So(err, ShouldBeNil)
+				So(line, ShouldEqual, "ping\n")
+			})
+
+			Convey("it reports the negotiated connection state", func() {
+
+				state, ok := ConnectionState(connection)
+
+				So(ok, ShouldBeTrue)
+				So(state.HandshakeComplete, ShouldBeTrue)
+				So(state.NegotiatedProtocol, ShouldEqual, "nautls")
+				So(state.PeerCertificates[0].Subject.CommonName, ShouldEqual, "localhost")
+			})
+		})
+
+		Convey("and the address is routed", func() {
+
+			connection, err := dialer.Dial("tcp", "db.nautls.test:5432")
+			So(err, ShouldBeNil)
+			defer connection.Close()
+
+			line, err := ping(connection)
+
+			Convey("it dials the address of the route", func() {
+				So(err, ShouldBeNil)
+				So(line, ShouldEqual, "ping\n")
+			})
+		})
+
+		Convey("and the context is done", func() {
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			_, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort("localhost", port))
+
+			Convey("it returns a non-nil error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+
+	Convey("When Dialer dials a TLS server over a unix socket", t, func() {
+
+		socket := filepath.Join(t.TempDir(), "nautls.sock")
+
+		listener, err := net.Listen("unix", socket)
+		So(err, ShouldBeNil)
+		defer listener.Close()
+
+		go echo(tls.NewListener(listener, config))
+
+		dialer, err := (&ClientConfig{Host: "localhost", Security: SecurityConfig{Authorities: authorities}}).Dialer()
+		So(err, ShouldBeNil)
+
+		connection, err := dialer.Dial("unix", socket)
+		So(err, ShouldBeNil)
+		defer connection.Close()
+
+		line, err := ping(connection)
+
+		Convey("it verifies the server by the host and exchanges data", func() {
+			So(err, ShouldBeNil)
+			So(line, ShouldEqual, "ping\n")
+		})
+	})
+
+	Convey("When Dialer dials a server that does not complete the handshake", t, func() {
+
+		listener, err := net.Listen("tcp", "localhost:0")
+		So(err, ShouldBeNil)
+		defer listener.Close()
+
+		accepted := make(chan net.Conn, 1)
+		go func() {
+			connection, err := listener.Accept()
+			if err == nil {
+				accepted <- connection
+			}
+		}()
+
+		dialer, err := (&ClientConfig{HandshakeTimeout: "50ms", Security: SecurityConfig{Authorities: authorities}}).Dialer()
+		So(err, ShouldBeNil)
+
+		started := time.Now()
+		_, err = dialer.DialContext(context.Background(), "tcp", listener.Addr().String())
+
+		Convey("it returns an error once the handshake timeout passes", func() {
+			So(err, ShouldNotBeNil)
+			So(time.Since(started), ShouldBeLessThan, 5*time.Second)
+		})
+
+		Reset(func() {
+			select {
+			case connection := <-accepted:
+				connection.Close()
+			default:
+			}
+		})
+	})
+
+	Convey("When Dialer dials an unsupported network", t, func() {
+
+		dialer, err := (&ClientConfig{Security: SecurityConfig{Authorities: authorities}}).Dialer()
+		So(err, ShouldBeNil)
+
+		_, err = dialer.Dial("udp", "localhost:5432")
+
+		Convey("it returns a non-nil error", func() {
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "unsupported network")
+		})
+	})
+
+	Convey("When a Dialer without a configuration dials a TLS server", t, func() {
+
+		listener, err := tls.Listen("tcp", "localhost:0", config)
+		So(err, ShouldBeNil)
+		defer listener.Close()
+
+		go echo(listener)
+
+		_, err = (&Dialer{}).Dial("tcp", listener.Addr().String())
+
+		Convey("it verifies the server using the defaults of the crypto/tls package", func() {
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "certificate")
+		})
+	})
+
+	Convey("When ConnectionState is invoked with a plaintext connection", t, func() {
+
+		client, server := net.Pipe()
+		defer client.Close()
+		defer server.Close()
+
+		_, ok := ConnectionState(client)
+
+		Convey("it returns false", func() {
+			So(ok, ShouldBeFalse)
+		})
+	})
+}
@@ -0,0 +1,125 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"crypto/tls"
+	"net"
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+// Route provides a serializable representation of the address and TLS configuration used for the requests to a host
+// similar to the curl "--resolve" option.
+type Route struct {
+
+	// Address defines the address (e.g., "10.0.0.5:8443") dialed in place of the address of the requests to the host.
+	// Note that the address of the request is dialed when empty.
+	Address string `json:"address" mapstructure:"address" yaml:"address"`
+
+	// Host defines the hostname (e.g., "api.example.com") or hostname and port (e.g., "api.example.com:443") of the
+	// requests to which the route applies. Note that a route with a port takes precedence over one without.
+	Host string `json:"host" mapstructure:"host" yaml:"host"`
+
+	// Security defines the TLS configuration used for the requests to the host. Note that the TLS configuration of the
+	// client is used when nil.
+	Security *SecurityConfig `json:"security" mapstructure:"security" yaml:"security"`
+}
+
+// route provides a built route.
+type route struct {
+	address string
+	config  *tls.Config
+}
+
+// routes provides built routes keyed by the hostname or hostname and port of the requests to which they apply.
+type routes map[string]route
+
+// add builds and adds a route using the TLS configuration of the client when the route does not define one.
+func (r routes) add(definition Route, configuration *tls.Config) error {
+
+	if definition.Host == "" {
+		return errors.New("error building route without a host")
+	}
+
+	if definition.Address != "" {
+		_, _, err := net.SplitHostPort(definition.Address)
+		if err != nil {
+			return errors.Wrapf(err, "error parsing address [%s] of route [%s]", definition.Address, definition.Host)
+		}
+	}
+
+	if definition.Security != nil {
+
+		built, err := definition.Security.Build()
+		if err != nil {
+			return errors.Wrapf(err, "error building tls configuration for route [%s]", definition.Host)
+		}
+
+		configuration = built
+	}
+
+	r[definition.Host] = route{address: definition.Address, config: configuration}
+
+	return nil
+}
+
+// lookup returns the route for the address (i.e., the host and port) of a request.
+func (r routes) lookup(address string) (route, bool) {
+
+	found, ok := r[address]
+	if ok {
+		return found, true
+	}
+
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return route{}, false
+	}
+
+	found, ok = r[host]
+	return found, ok
+}
+
+// resolve returns the address dialed and the tls.Config used for the address of a request.
+func (r routes) resolve(address string, configuration *tls.Config) (string, *tls.Config) {
+
+	found, ok := r.lookup(address)
+	if !ok {
+		return address, configuration
+	}
+
+	if found.address != "" {
+		address = found.address
+	}
+
+	return address, found.config
+}
+
+// routed returns true if a route applies to the URL of a request.
+func (r routes) routed(location *url.URL) bool {
+
+	port := location.Port()
+	if port == "" {
+		port = "80"
+		if location.Scheme == "https" {
+			port = "443"
+		}
+	}
+
+	_, ok := r.lookup(net.JoinHostPort(location.Hostname(), port))
+	return ok
+}
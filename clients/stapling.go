@@ -0,0 +1,231 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ocsp"
+)
+
+// Stapling defines whether a client requires the server to staple a valid OCSP response to its certificate.
+type Stapling int
+
+const (
+	// IgnoreStaple does not verify OCSP staples.
+	IgnoreStaple Stapling = iota
+
+	// MustStaple requires a valid OCSP staple only when the server certificate carries the TLS feature (i.e.,
+	// "must-staple") extension requesting the status_request feature.
+	MustStaple
+
+	// RequireStaple requires a valid OCSP staple for every server certificate.
+	RequireStaple
+)
+
+var (
+	// tlsFeatureExtension defines the object identifier of the TLS feature extension defined by RFC 7633.
+	tlsFeatureExtension = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 1, 24}
+
+	// statusRequestFeature defines the status_request TLS feature.
+	statusRequestFeature = 5
+)
+
+// MarshalJSON implements the json.Marshaler interface for Stapling instances.
+func (s Stapling) MarshalJSON() ([]byte, error) {
+
+	value, err := s.ToString()
+	if err != nil {
+		return nil, errors.Wrap(err, "error marshalling stapling to json")
+	}
+
+	return []byte(fmt.Sprintf("\"%s\"", value)), nil
+}
+
+// MarshalYAML implements the yaml.Marshaler interface for Stapling instances.
+func (s Stapling) MarshalYAML() (interface{}, error) {
+	return s.ToString()
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for Stapling instances.
+func (s *Stapling) UnmarshalJSON(bytes []byte) error {
+
+	var value string
+
+	err := json.Unmarshal(bytes, &value)
+	if err != nil {
+		return errors.Wrap(err, "error unmarshalling stapling from json")
+	}
+
+	return s.FromString(value)
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface for Stapling instances.
+func (s *Stapling) UnmarshalYAML(unmarshal func(interface{}) error) error {
+
+	var value string
+
+	err := unmarshal(&value)
+	if err != nil {
+		return errors.Wrap(err, "error unmarshalling stapling from yaml")
+	}
+
+	return s.FromString(value)
+}
+
+// FromString sets the value of a stapling to the value represented by a string or errors.
+func (s *Stapling) FromString(value string) error {
+
+	var stapling Stapling
+
+	switch strings.ToLower(value) {
+	case "", "ignorestaple":
+		stapling = IgnoreStaple
+	case "muststaple":
+		stapling = MustStaple
+	case "requirestaple":
+		stapling = RequireStaple
+	default:
+		return errors.New(fmt.Sprintf("error unmarshalling unknown stapling value [%s]", value))
+	}
+
+	*s = stapling
+
+	return nil
+}
+
+// ToString returns the string representation of the stapling or an error.
+func (s Stapling) ToString() (string, error) {
+
+	switch s {
+	case IgnoreStaple:
+		return "IgnoreStaple", nil
+	case MustStaple:
+		return "MustStaple", nil
+	case RequireStaple:
+		return "RequireStaple", nil
+	default:
+		return "", errors.New(fmt.Sprintf("error converting unknown stapling value to string [%d]", s))
+	}
+}
+
+// StringToStapling returns a mapstructure.DecodeHookFunction that converts a string to a stapling.
+func StringToStapling() mapstructure.DecodeHookFunc {
+
+	return func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+
+		if from != reflect.TypeOf("") {
+			return data, nil
+		}
+
+		if to != reflect.TypeOf(Stapling(0)) {
+			return data, nil
+		}
+
+		var stapling Stapling
+
+		err := stapling.FromString(data.(string))
+		if err != nil {
+			return nil, errors.Wrapf(err, "error decoding string as stapling")
+		}
+
+		return stapling, nil
+	}
+}
+
+// verifier returns a tls.Config VerifyConnection function that enforces the stapling or nil if staples are ignored.
+func (s Stapling) verifier() func(tls.ConnectionState) error {
+
+	if s == IgnoreStaple {
+		return nil
+	}
+
+	return func(state tls.ConnectionState) error {
+
+		chain := state.PeerCertificates
+		if len(state.VerifiedChains) > 0 {
+			chain = state.VerifiedChains[0]
+		}
+
+		if len(chain) == 0 {
+			return nil
+		}
+
+		leaf := chain[0]
+
+		if len(state.OCSPResponse) == 0 {
+
+			if s == RequireStaple || mustStaple(leaf) {
+				return fmt.Errorf("server certificate [%s] was not stapled", leaf.Subject)
+			}
+
+			return nil
+		}
+
+		if len(chain) < 2 {
+			return fmt.Errorf("error verifying staple of [%s]: issuer is unknown", leaf.Subject)
+		}
+
+		response, err := ocsp.ParseResponseForCert(state.OCSPResponse, leaf, chain[1])
+		if err != nil {
+			return errors.Wrapf(err, "error verifying staple of [%s]", leaf.Subject)
+		}
+
+		if !response.NextUpdate.IsZero() && time.Now().After(response.NextUpdate) {
+			return fmt.Errorf("error verifying staple of [%s]: stale since [%s]", leaf.Subject, response.NextUpdate)
+		}
+
+		if response.Status != ocsp.Good {
+			return fmt.Errorf("server certificate [%s] has ocsp status [%d]", leaf.Subject, response.Status)
+		}
+
+		return nil
+	}
+}
+
+// mustStaple returns true if a certificate carries the TLS feature extension requesting the status_request feature.
+func mustStaple(certificate *x509.Certificate) bool {
+
+	for _, extension := range certificate.Extensions {
+
+		if !extension.Id.Equal(tlsFeatureExtension) {
+			continue
+		}
+
+		var features []int
+
+		_, err := asn1.Unmarshal(extension.Value, &features)
+		if err != nil {
+			return true
+		}
+
+		for _, feature := range features {
+			if feature == statusRequestFeature {
+				return true
+			}
+		}
+	}
+
+	return false
+}
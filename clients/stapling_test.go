@@ -0,0 +1,125 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/greymatter-io/nautls/internal/tests"
+	"github.com/mitchellh/mapstructure"
+	"gopkg.in/yaml.v2"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// ValidStaplings returns a map of the valid stapling values and their string representation.
+func ValidStaplings() map[Stapling]string {
+	return map[Stapling]string{
+		IgnoreStaple:  "IgnoreStaple",
+		MustStaple:    "MustStaple",
+		RequireStaple: "RequireStaple",
+	}
+}
+
+func TestStapling(t *testing.T) {
+
+	Convey("When stapling", t, func() {
+
+		for stapling, value := range ValidStaplings() {
+
+			Convey(".ToString is invoked for "+value, func() {
+
+				actual, err := stapling.ToString()
+
+				Convey("it returns a nil error", func() {
+					So(err, ShouldBeNil)
+				})
+
+				Convey("it returns the string representation", func() {
+					So(actual, ShouldEqual, value)
+				})
+			})
+
+			Convey("is marshalled to and from json for "+value, func() {
+
+				bytes, err := json.Marshal(stapling)
+				So(err, ShouldBeNil)
+
+				var actual Stapling
+				err = json.Unmarshal(bytes, &actual)
+
+				Convey("it returns the original value", func() {
+					So(err, ShouldBeNil)
+					So(actual, ShouldEqual, stapling)
+				})
+			})
+
+			Convey("is marshalled to and from yaml for "+value, func() {
+
+				bytes, err := yaml.Marshal(stapling)
+				So(err, ShouldBeNil)
+
+				var actual Stapling
+				err = yaml.Unmarshal(bytes, &actual)
+
+				Convey("it returns the original value", func() {
+					So(err, ShouldBeNil)
+					So(actual, ShouldEqual, stapling)
+				})
+			})
+
+			Convey("#StringToStapling is invoked for "+value, func() {
+
+				var actual Stapling
+
+				decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{DecodeHook: StringToStapling(), Result: &actual})
+				if err != nil {
+					t.Fatalf("error initializing decoder [%s]", err.Error())
+				}
+
+				err = decoder.Decode(value)
+
+				Convey("it returns the decoded value", func() {
+					So(err, ShouldBeNil)
+					So(actual, ShouldEqual, stapling)
+				})
+			})
+		}
+
+		Convey(".FromString is invoked with an invalid value", func() {
+
+			actual := RequireStaple
+			err := actual.FromString(tests.MustGenerateHex(t) + "-invalid")
+
+			Convey("it returns a non-nil error", func() {
+				So(err, ShouldNotBeNil)
+			})
+
+			Convey("it does not modify the stapling", func() {
+				So(actual, ShouldEqual, RequireStaple)
+			})
+		})
+
+		Convey(".ToString is invoked with an invalid value", func() {
+
+			_, err := Stapling(-1).ToString()
+
+			Convey("it returns a non-nil error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
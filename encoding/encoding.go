@@ -12,13 +12,20 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// Package encoding provies helper methods for PEM encoding x509 certificates and RSA private keys.
+// Package encoding provies helper methods for PEM encoding x509 certificates, certificate signing requests, revocation
+// lists and RSA, ECDSA and Ed25519 private keys.
 package encoding
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
+
+	"github.com/pkg/errors"
+	"github.com/youmark/pkcs8"
 )
 
 func PEMEncodeCertificate(certificate *x509.Certificate) []byte {
@@ -40,21 +47,159 @@ func PEMEncodeCertificates(certificates []*x509.Certificate) [][]byte {
 	return pems
 }
 
-func PEMEncodeKey(key *rsa.PrivateKey) []byte {
-	if key == nil {
+// PEMDecodeCertificates decodes the "CERTIFICATE" blocks of PEM encoded bytes.
+func PEMDecodeCertificates(bytes []byte) ([]*x509.Certificate, error) {
+	certificates := []*x509.Certificate{}
+	for block, rest := pem.Decode(bytes); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "error parsing certificate")
+		}
+
+		certificates = append(certificates, certificate)
+	}
+
+	return certificates, nil
+}
+
+// PEMEncodeKey encodes a private key as an "RSA PRIVATE KEY" (PKCS #1), "EC PRIVATE KEY" (SEC 1) or "PRIVATE KEY"
+// (PKCS #8) block for RSA, ECDSA and Ed25519 keys respectively. Note that an empty slice is returned for nil keys,
+// Ed25519 keys of an invalid length and unsupported key types.
+func PEMEncodeKey(key crypto.Signer) []byte {
+	switch typed := key.(type) {
+	case *rsa.PrivateKey:
+		if typed == nil {
+			return []byte{}
+		}
+
+		return pem.EncodeToMemory(&pem.Block{
+			Type:  "RSA PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(typed),
+		})
+	case *ecdsa.PrivateKey:
+		if typed == nil {
+			return []byte{}
+		}
+
+		bytes, err := x509.MarshalECPrivateKey(typed)
+		if err != nil {
+			return []byte{}
+		}
+
+		return pem.EncodeToMemory(&pem.Block{
+			Type:  "EC PRIVATE KEY",
+			Bytes: bytes,
+		})
+	case ed25519.PrivateKey:
+		if len(typed) != ed25519.PrivateKeySize {
+			return []byte{}
+		}
+
+		bytes, err := x509.MarshalPKCS8PrivateKey(typed)
+		if err != nil {
+			return []byte{}
+		}
+
+		return pem.EncodeToMemory(&pem.Block{
+			Type:  "PRIVATE KEY",
+			Bytes: bytes,
+		})
+	default:
 		return []byte{}
 	}
+}
+
+// PEMEncodeEncryptedKey encodes a private key as an "ENCRYPTED PRIVATE KEY" (PKCS #8) block encrypted using AES-256-CBC
+// with a key derived from the passphrase using PBKDF2.
+func PEMEncodeEncryptedKey(key crypto.Signer, passphrase []byte) ([]byte, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("error encrypting key with an empty passphrase")
+	}
+
+	if typed, ok := key.(ed25519.PrivateKey); ok && len(typed) != ed25519.PrivateKeySize {
+		return nil, errors.New("error encrypting ed25519 key of invalid length")
+	}
+
+	bytes, err := pkcs8.MarshalPrivateKey(key, passphrase, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "error encrypting key")
+	}
 
 	return pem.EncodeToMemory(&pem.Block{
-		Type:  "RSA PRIVATE KEY",
-		Bytes: x509.MarshalPKCS1PrivateKey(key),
-	})
+		Type:  "ENCRYPTED PRIVATE KEY",
+		Bytes: bytes,
+	}), nil
 }
 
-func PEMEncodeKeys(keys []*rsa.PrivateKey) [][]byte {
+func PEMEncodeKeys(keys []crypto.Signer) [][]byte {
 	pems := make([][]byte, len(keys))
 	for i, key := range keys {
 		pems[i] = PEMEncodeKey(key)
 	}
 	return pems
 }
+
+// PEMEncodeCertificateRequest encodes a certificate signing request as a "CERTIFICATE REQUEST" (PKCS #10) block.
+func PEMEncodeCertificateRequest(request *x509.CertificateRequest) []byte {
+	if request == nil {
+		return []byte{}
+	}
+
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE REQUEST",
+		Bytes: request.Raw,
+	})
+}
+
+// PEMDecodeCertificateRequest decodes the first "CERTIFICATE REQUEST" (PKCS #10) block of PEM encoded bytes.
+func PEMDecodeCertificateRequest(bytes []byte) (*x509.CertificateRequest, error) {
+	for block, rest := pem.Decode(bytes); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE REQUEST" {
+			continue
+		}
+
+		request, err := x509.ParseCertificateRequest(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "error parsing certificate request")
+		}
+
+		return request, nil
+	}
+
+	return nil, errors.New("error decoding certificate request: no CERTIFICATE REQUEST block found")
+}
+
+// PEMEncodeRevocationList encodes a certificate revocation list as an "X509 CRL" block.
+func PEMEncodeRevocationList(list *x509.RevocationList) []byte {
+	if list == nil {
+		return []byte{}
+	}
+
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  "X509 CRL",
+		Bytes: list.Raw,
+	})
+}
+
+// PEMDecodeRevocationLists decodes the "X509 CRL" blocks of PEM encoded bytes.
+func PEMDecodeRevocationLists(bytes []byte) ([]*x509.RevocationList, error) {
+	lists := []*x509.RevocationList{}
+	for block, rest := pem.Decode(bytes); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "X509 CRL" {
+			continue
+		}
+
+		list, err := x509.ParseRevocationList(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "error parsing revocation list")
+		}
+
+		lists = append(lists, list)
+	}
+
+	return lists, nil
+}
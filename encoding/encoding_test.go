@@ -4,7 +4,7 @@
 // you may not use this file except in compliance with the License.
 // You may obtain a copy of the License at
 //
-//     http://www.apache.org/licenses/LICENSE-2.0
+//	http://www.apache.org/licenses/LICENSE-2.0
 //
 // Unless required by applicable law or agreed to in writing, software
 // distributed under the License is distributed on an "AS IS" BASIS,
@@ -14,6 +14,10 @@
 package encoding
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
@@ -25,6 +29,7 @@ import (
 
 	"github.com/greymatter-io/nautls/identities"
 	. "github.com/smartystreets/goconvey/convey"
+	"github.com/youmark/pkcs8"
 )
 
 func testCert(t *testing.T) *identities.Identity {
@@ -64,6 +69,22 @@ func testKey(t *testing.T) *rsa.PrivateKey {
 	return key
 }
 
+func testECDSAKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating private key: %s", err)
+	}
+	return key
+}
+
+func testEd25519Key(t *testing.T) ed25519.PrivateKey {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating private key: %s", err)
+	}
+	return key
+}
+
 func TestPEMEncodeCertificate(t *testing.T) {
 	Convey("When PEMEncodeCertificate is called", t, func() {
 		Convey("with a nil certificate", func() {
@@ -145,13 +166,120 @@ func TestPEMEncodeKey(t *testing.T) {
 				So(decodedKey, ShouldResemble, key)
 			})
 		})
+
+		Convey("with a valid ecdsa key", func() {
+			key := testECDSAKey(t)
+			keyPEM := PEMEncodeKey(key)
+			decoded, _ := pem.Decode(keyPEM)
+			decodedKey, err := x509.ParseECPrivateKey(decoded.Bytes)
+
+			Convey("it should return an EC PRIVATE KEY block", func() {
+				So(decoded.Type, ShouldEqual, "EC PRIVATE KEY")
+			})
+
+			Convey("ParseECPrivateKey should return a valid key", func() {
+				So(err, ShouldBeNil)
+			})
+
+			Convey("it should return a valid ecdsa private key", func() {
+				So(decodedKey.Equal(key), ShouldBeTrue)
+			})
+		})
+
+		Convey("with a nil ed25519 key", func() {
+			key := PEMEncodeKey(ed25519.PrivateKey(nil))
+
+			Convey("it should return an empty slice", func() {
+				So(key, ShouldBeEmpty)
+			})
+		})
+
+		Convey("with a short ed25519 key", func() {
+			key := PEMEncodeKey(ed25519.PrivateKey(make([]byte, ed25519.SeedSize)))
+
+			Convey("it should return an empty slice", func() {
+				So(key, ShouldBeEmpty)
+			})
+		})
+
+		Convey("with a valid ed25519 key", func() {
+			key := testEd25519Key(t)
+			keyPEM := PEMEncodeKey(key)
+			decoded, _ := pem.Decode(keyPEM)
+			decodedKey, err := x509.ParsePKCS8PrivateKey(decoded.Bytes)
+
+			Convey("it should return a PRIVATE KEY block", func() {
+				So(decoded.Type, ShouldEqual, "PRIVATE KEY")
+			})
+
+			Convey("ParsePKCS8PrivateKey should return a valid key", func() {
+				So(err, ShouldBeNil)
+			})
+
+			Convey("it should return a valid ed25519 private key", func() {
+				So(decodedKey, ShouldResemble, key)
+			})
+		})
+	})
+}
+
+func TestPEMEncodeEncryptedKey(t *testing.T) {
+	Convey("When PEMEncodeEncryptedKey is called", t, func() {
+		Convey("with an empty passphrase", func() {
+			keyPEM, err := PEMEncodeEncryptedKey(testECDSAKey(t), []byte{})
+
+			Convey("it should return a non-nil error", func() {
+				So(err, ShouldNotBeNil)
+			})
+
+			Convey("it should return a nil slice", func() {
+				So(keyPEM, ShouldBeNil)
+			})
+		})
+
+		Convey("with a short ed25519 key", func() {
+			keyPEM, err := PEMEncodeEncryptedKey(ed25519.PrivateKey(make([]byte, ed25519.SeedSize)), []byte("nautls"))
+
+			Convey("it should return a non-nil error", func() {
+				So(err, ShouldNotBeNil)
+			})
+
+			Convey("it should return a nil slice", func() {
+				So(keyPEM, ShouldBeNil)
+			})
+		})
+
+		Convey("with a valid key and passphrase", func() {
+			key := testECDSAKey(t)
+			keyPEM, err := PEMEncodeEncryptedKey(key, []byte("nautls"))
+			decoded, _ := pem.Decode(keyPEM)
+
+			Convey("it should return a nil error", func() {
+				So(err, ShouldBeNil)
+			})
+
+			Convey("it should return an ENCRYPTED PRIVATE KEY block", func() {
+				So(decoded.Type, ShouldEqual, "ENCRYPTED PRIVATE KEY")
+			})
+
+			Convey("it should not be readable without the passphrase", func() {
+				_, err := x509.ParsePKCS8PrivateKey(decoded.Bytes)
+				So(err, ShouldNotBeNil)
+			})
+
+			Convey("it should be readable with the passphrase", func() {
+				decodedKey, err := pkcs8.ParsePKCS8PrivateKeyECDSA(decoded.Bytes, []byte("nautls"))
+				So(err, ShouldBeNil)
+				So(decodedKey.Equal(key), ShouldBeTrue)
+			})
+		})
 	})
 }
 
 func TestPEMEncodeKeys(t *testing.T) {
 	Convey("When PEMEncodeKeys is called", t, func() {
 		Convey("with an empty slice", func() {
-			keys := PEMEncodeKeys([]*rsa.PrivateKey{})
+			keys := PEMEncodeKeys([]crypto.Signer{})
 
 			Convey("it should return an empty slice", func() {
 				So(keys, ShouldBeEmpty)
@@ -159,10 +287,151 @@ func TestPEMEncodeKeys(t *testing.T) {
 		})
 
 		Convey("with valid keys", func() {
-			keys := PEMEncodeKeys([]*rsa.PrivateKey{testKey(t), testKey(t)})
+			keys := PEMEncodeKeys([]crypto.Signer{testKey(t), testECDSAKey(t), testEd25519Key(t)})
 
 			Convey("it should return the right number of keys", func() {
-				So(len(keys), ShouldEqual, 2)
+				So(len(keys), ShouldEqual, 3)
+			})
+		})
+	})
+}
+
+func TestPEMEncodeCertificateRequest(t *testing.T) {
+	Convey("When PEMEncodeCertificateRequest is called", t, func() {
+		Convey("with a nil request", func() {
+			requestPEM := PEMEncodeCertificateRequest(nil)
+
+			Convey("it should return an empty slice", func() {
+				So(requestPEM, ShouldBeEmpty)
+			})
+		})
+
+		Convey("with a valid request", func() {
+			request, _ := identities.CertificateRequest(identities.Template{
+				DNSNames: []string{"nautls.com"},
+				Subject:  pkix.Name{CommonName: "nautls.com"},
+			}, testECDSAKey(t))
+			requestPEM := PEMEncodeCertificateRequest(request)
+
+			Convey("it should return a CERTIFICATE REQUEST block", func() {
+				decoded, _ := pem.Decode(requestPEM)
+				So(decoded.Type, ShouldEqual, "CERTIFICATE REQUEST")
+			})
+
+			Convey("PEMDecodeCertificateRequest should return the request", func() {
+				decoded, err := PEMDecodeCertificateRequest(requestPEM)
+				So(err, ShouldBeNil)
+				So(decoded.Raw, ShouldResemble, request.Raw)
+				So(decoded.DNSNames, ShouldResemble, []string{"nautls.com"})
+			})
+		})
+	})
+}
+
+func TestPEMDecodeCertificates(t *testing.T) {
+	Convey("When PEMDecodeCertificates is called", t, func() {
+		Convey("with certificate and key blocks", func() {
+			first := testCert(t)
+			second := testCert(t)
+
+			data := append(PEMEncodeCertificate(first.Certificate), PEMEncodeKey(first.Key)...)
+			certificates, err := PEMDecodeCertificates(append(data, PEMEncodeCertificate(second.Certificate)...))
+
+			Convey("it should return a nil error", func() {
+				So(err, ShouldBeNil)
+			})
+
+			Convey("it should return the certificates in order", func() {
+				So(certificates, ShouldHaveLength, 2)
+				So(certificates[0].Raw, ShouldResemble, first.Certificate.Raw)
+				So(certificates[1].Raw, ShouldResemble, second.Certificate.Raw)
+			})
+		})
+
+		Convey("with an invalid CERTIFICATE block", func() {
+			certificates, err := PEMDecodeCertificates(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("invalid")}))
+
+			Convey("it should return a non-nil error", func() {
+				So(err, ShouldNotBeNil)
+			})
+
+			Convey("it should return nil certificates", func() {
+				So(certificates, ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestPEMDecodeCertificateRequest(t *testing.T) {
+	Convey("When PEMDecodeCertificateRequest is called", t, func() {
+		Convey("without a CERTIFICATE REQUEST block", func() {
+			request, err := PEMDecodeCertificateRequest(PEMEncodeCertificate(testCert(t).Certificate))
+
+			Convey("it should return a non-nil error", func() {
+				So(err, ShouldNotBeNil)
+			})
+
+			Convey("it should return a nil request", func() {
+				So(request, ShouldBeNil)
+			})
+		})
+
+		Convey("with an invalid CERTIFICATE REQUEST block", func() {
+			request, err := PEMDecodeCertificateRequest(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: []byte("invalid")}))
+
+			Convey("it should return a non-nil error", func() {
+				So(err, ShouldNotBeNil)
+			})
+
+			Convey("it should return a nil request", func() {
+				So(request, ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestPEMEncodeRevocationList(t *testing.T) {
+	Convey("When PEMEncodeRevocationList is called", t, func() {
+		Convey("with a nil list", func() {
+			listPEM := PEMEncodeRevocationList(nil)
+
+			Convey("it should return an empty slice", func() {
+				So(listPEM, ShouldBeEmpty)
+			})
+		})
+
+		Convey("with a valid list", func() {
+			authority, _ := identities.Self(identities.Template{
+				BasicConstraintsValid: true,
+				IsCA:                  true,
+				KeyAlgorithm:          identities.ECDSA,
+				KeyUsage:              x509.KeyUsageCRLSign | x509.KeyUsageCertSign,
+				NotAfter:              time.Now().AddDate(10, 0, 0),
+				NotBefore:             time.Now(),
+				SerialNumber:          big.NewInt(time.Now().Unix()),
+				Subject:               pkix.Name{CommonName: "NauTLS (Authority)"},
+			})
+
+			revocations := &identities.Revocations{}
+			revocations.Revoke(big.NewInt(1), identities.KeyCompromise, time.Now())
+
+			list, _ := authority.RevocationList(identities.RevocationListTemplate{
+				NextUpdate:  time.Now().Add(time.Hour),
+				Number:      big.NewInt(1),
+				Revocations: revocations.List(),
+			})
+			listPEM := PEMEncodeRevocationList(list)
+
+			Convey("it should return an X509 CRL block", func() {
+				decoded, _ := pem.Decode(listPEM)
+				So(decoded.Type, ShouldEqual, "X509 CRL")
+			})
+
+			Convey("PEMDecodeRevocationLists should return the list", func() {
+				decoded, err := PEMDecodeRevocationLists(append(PEMEncodeCertificate(authority.Certificate), listPEM...))
+				So(err, ShouldBeNil)
+				So(decoded, ShouldHaveLength, 1)
+				So(decoded[0].Raw, ShouldResemble, list.Raw)
 			})
 		})
 	})
@@ -0,0 +1,89 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package grpcs provides gRPC transport credentials and interceptors built from client and server configurations.
+package grpcs
+
+import (
+	"github.com/greymatter-io/nautls/clients"
+	"github.com/greymatter-io/nautls/servers"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/credentials"
+)
+
+// ClientCredentials returns gRPC transport credentials for clients from a client configuration. Note that when the
+// configuration reloads its resources the credentials present and verify against the current certificates and
+// authorities without recreating the connection.
+func ClientCredentials(config *clients.SecurityConfig) (credentials.TransportCredentials, error) {
+
+	if config == nil {
+		return nil, errors.New("error building client credentials from nil configuration")
+	}
+
+	tlsConfig, err := config.Build()
+	if err != nil {
+		return nil, errors.Wrap(err, "error building tls configuration for client")
+	}
+
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+// ServerCredentials returns gRPC transport credentials for servers from a server configuration. Note that when the
+// configuration reloads its resources the credentials serve the current certificates and verify clients against the
+// current authorities without restarting the server.
+func ServerCredentials(config *servers.SecurityConfig) (credentials.TransportCredentials, error) {
+
+	if config == nil {
+		return nil, errors.New("error building server credentials from nil configuration")
+	}
+
+	tlsConfig, err := config.Build()
+	if err != nil {
+		return nil, errors.Wrap(err, "error building tls configuration for server")
+	}
+
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+// ClientConfigurationCredentials returns gRPC transport credentials for clients from a client configuration. See
+// ClientCredentials.
+func ClientConfigurationCredentials(configuration *clients.Configuration) (credentials.TransportCredentials, error) {
+
+	if configuration == nil {
+		return nil, errors.New("error building client credentials from nil configuration")
+	}
+
+	tlsConfig, err := configuration.TLS()
+	if err != nil {
+		return nil, errors.Wrap(err, "error building tls configuration for client")
+	}
+
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+// ServerConfigurationCredentials returns gRPC transport credentials for servers from a server configuration. See
+// ServerCredentials.
+func ServerConfigurationCredentials(configuration *servers.Configuration) (credentials.TransportCredentials, error) {
+
+	if configuration == nil {
+		return nil, errors.New("error building server credentials from nil configuration")
+	}
+
+	tlsConfig, err := configuration.TLS()
+	if err != nil {
+		return nil, errors.Wrap(err, "error building tls configuration for server")
+	}
+
+	return credentials.NewTLS(tlsConfig), nil
+}
@@ -0,0 +1,252 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcs
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/greymatter-io/nautls/clients"
+	"github.com/greymatter-io/nautls/encoding"
+	"github.com/greymatter-io/nautls/identities"
+	"github.com/greymatter-io/nautls/internal/tests/fixtures"
+	"github.com/greymatter-io/nautls/servers"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// mustServe serves the health service with credentials on a loopback listener and returns its address, the peers
+// observed by the handlers of calls and the server.
+func mustServe(t *testing.T, creds credentials.TransportCredentials, authorities []string) (string, chan *servers.Peer, *grpc.Server) {
+
+	unary, err := UnaryServerInterceptor(authorities)
+	if err != nil {
+		t.Fatalf("error building unary interceptor [%s]", err)
+	}
+
+	stream, err := StreamServerInterceptor(authorities)
+	if err != nil {
+		t.Fatalf("error building stream interceptor [%s]", err)
+	}
+
+	observed := make(chan *servers.Peer, 16)
+
+	observeUnary := func(ctx context.Context, request interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		found, _ := servers.PeerFromContext(ctx)
+		observed <- found
+		return handler(ctx, request)
+	}
+
+	observeStream := func(service interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		found, _ := servers.PeerFromContext(stream.Context())
+		observed <- found
+		return handler(service, stream)
+	}
+
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("error listening [%s]", err)
+	}
+
+	server := grpc.NewServer(
+		grpc.Creds(creds),
+		grpc.ChainUnaryInterceptor(unary, observeUnary),
+		grpc.ChainStreamInterceptor(stream, observeStream),
+	)
+	grpc_health_v1.RegisterHealthServer(server, health.NewServer())
+
+	go server.Serve(listener)
+
+	return fmt.Sprintf("localhost:%d", listener.Addr().(*net.TCPAddr).Port), observed, server
+}
+
+// check invokes the unary health check of a server.
+func check(creds credentials.TransportCredentials, address string) error {
+
+	connection, err := grpc.NewClient(address, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return err
+	}
+	defer connection.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err = grpc_health_v1.NewHealthClient(connection).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	return err
+}
+
+// watch receives the first message of the streaming health watch of a server.
+func watch(creds credentials.TransportCredentials, address string) error {
+
+	connection, err := grpc.NewClient(address, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return err
+	}
+	defer connection.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := grpc_health_v1.NewHealthClient(connection).Watch(ctx, &grpc_health_v1.HealthCheckRequest{})
+	if err != nil {
+		return err
+	}
+
+	_, err = stream.Recv()
+	return err
+}
+
+func TestCredentials(t *testing.T) {
+
+	authority := fixtures.MustAuthority(t, "NauTLS (Authority)", identities.ECDSA)
+	untrusted := fixtures.MustAuthority(t, "NauTLS (Untrusted)", identities.ECDSA)
+
+	authorities := []string{fixtures.Base64Resource(encoding.PEMEncodeCertificate(authority.Certificate))}
+
+	serverCertificate, serverKey := fixtures.MustIssueServer(t, authority, identities.ECDSA, "localhost", "localhost")
+	clientCertificate, clientKey := fixtures.MustIssueClient(t, authority, identities.ECDSA, "client.nautls.com", "client.nautls.com")
+	untrustedCertificate, untrustedKey := fixtures.MustIssueClient(t, untrusted, identities.ECDSA, "client.nautls.com", "client.nautls.com")
+
+	for _, reload := range []string{"", "1m"} {
+
+		Convey(fmt.Sprintf("When ServerCredentials is invoked with reload [%s]", reload), t, func() {
+
+			creds, err := ServerCredentials(&servers.SecurityConfig{
+				Authentication: servers.Authentication(tls.RequireAndVerifyClientCert),
+				Authorities:    authorities,
+				Certificate:    serverCertificate,
+				Key:            serverKey,
+				Reload:         reload,
+			})
+			So(err, ShouldBeNil)
+
+			address, observed, server := mustServe(t, creds, authorities)
+			defer server.Stop()
+
+			Convey("and ClientCredentials is invoked with a trusted certificate", func() {
+
+				client, err := ClientCredentials(&clients.SecurityConfig{
+					Authorities: authorities,
+					Certificate: clientCertificate,
+					Key:         clientKey,
+					Reload:      reload,
+				})
+				So(err, ShouldBeNil)
+
+				Convey("it completes unary calls exposing the verified peer", func() {
+
+					So(check(client, address), ShouldBeNil)
+
+					found := <-observed
+					So(found, ShouldNotBeNil)
+					So(found.Verified, ShouldBeTrue)
+					So(found.Subject, ShouldEqual, "CN=client.nautls.com")
+					So(found.Authority, ShouldEqual, authorities[0])
+				})
+
+				Convey("it completes streaming calls exposing the verified peer", func() {
+
+					So(watch(client, address), ShouldBeNil)
+
+					found := <-observed
+					So(found, ShouldNotBeNil)
+					So(found.Verified, ShouldBeTrue)
+					So(found.Subject, ShouldEqual, "CN=client.nautls.com")
+				})
+			})
+
+			Convey("and ClientCredentials is invoked with an untrusted certificate", func() {
+
+				client, err := ClientCredentials(&clients.SecurityConfig{
+					Authorities: authorities,
+					Certificate: untrustedCertificate,
+					Key:         untrustedKey,
+				})
+				So(err, ShouldBeNil)
+
+				Convey("it fails unary calls", func() {
+					So(check(client, address), ShouldNotBeNil)
+				})
+			})
+		})
+	}
+
+	Convey("When ServerConfigurationCredentials is invoked without client authentication", t, func() {
+
+		creds, err := ServerConfigurationCredentials(&servers.Configuration{Certificate: serverCertificate, Key: serverKey})
+		So(err, ShouldBeNil)
+
+		address, observed, server := mustServe(t, creds, authorities)
+		defer server.Stop()
+
+		Convey("and ClientConfigurationCredentials is invoked without a certificate", func() {
+
+			client, err := ClientConfigurationCredentials(&clients.Configuration{Authorities: authorities})
+			So(err, ShouldBeNil)
+
+			Convey("it completes unary calls without a peer", func() {
+				So(check(client, address), ShouldBeNil)
+				So(<-observed, ShouldBeNil)
+			})
+		})
+
+		Convey("and ClientConfigurationCredentials is invoked with untrusted authorities", func() {
+
+			client, err := ClientConfigurationCredentials(&clients.Configuration{
+				Authorities: []string{fixtures.Base64Resource(encoding.PEMEncodeCertificate(untrusted.Certificate))},
+			})
+			So(err, ShouldBeNil)
+
+			Convey("it fails unary calls", func() {
+				So(check(client, address), ShouldNotBeNil)
+			})
+		})
+	})
+
+	Convey("When credentials are built from nil configurations", t, func() {
+
+		_, clientErr := ClientCredentials(nil)
+		_, serverErr := ServerCredentials(nil)
+		_, clientConfigurationErr := ClientConfigurationCredentials(nil)
+		_, serverConfigurationErr := ServerConfigurationCredentials(nil)
+
+		Convey("it returns non-nil errors", func() {
+			So(clientErr, ShouldNotBeNil)
+			So(serverErr, ShouldNotBeNil)
+			So(clientConfigurationErr, ShouldNotBeNil)
+			So(serverConfigurationErr, ShouldNotBeNil)
+		})
+	})
+
+	Convey("When interceptors are built with an invalid authority", t, func() {
+
+		_, unaryErr := UnaryServerInterceptor([]string{"base64:///invalid"})
+		_, streamErr := StreamServerInterceptor([]string{"base64:///invalid"})
+
+		Convey("it returns non-nil errors", func() {
+			So(unaryErr, ShouldNotBeNil)
+			So(streamErr, ShouldNotBeNil)
+		})
+	})
+}
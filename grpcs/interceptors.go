@@ -0,0 +1,88 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcs
+
+import (
+	"context"
+
+	"github.com/greymatter-io/nautls/servers"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// UnaryServerInterceptor returns a grpc.UnaryServerInterceptor that stores the servers.Peer of each call presenting a
+// client certificate in the context of the call for retrieval using servers.PeerFromContext. The authorities must be
+// the authorities of the server configuration and are used to identify the entry that verified a peer.
+func UnaryServerInterceptor(authorities []string) (grpc.UnaryServerInterceptor, error) {
+
+	peers, err := servers.NewPeers(authorities)
+	if err != nil {
+		return nil, errors.Wrap(err, "error building peers")
+	}
+
+	return func(ctx context.Context, request interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(withPeer(ctx, peers), request)
+	}, nil
+}
+
+// StreamServerInterceptor returns a grpc.StreamServerInterceptor that stores the servers.Peer of each stream presenting
+// a client certificate in the context of the stream for retrieval using servers.PeerFromContext. See
+// UnaryServerInterceptor.
+func StreamServerInterceptor(authorities []string) (grpc.StreamServerInterceptor, error) {
+
+	peers, err := servers.NewPeers(authorities)
+	if err != nil {
+		return nil, errors.Wrap(err, "error building peers")
+	}
+
+	return func(service interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(service, &peerStream{ServerStream: stream, ctx: withPeer(stream.Context(), peers)})
+	}, nil
+}
+
+// peerStream provides a grpc.ServerStream whose context holds the peer of the stream.
+type peerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the context of the stream.
+func (s *peerStream) Context() context.Context {
+	return s.ctx
+}
+
+// withPeer returns the context of a call holding the peer of its connection or the context unchanged if the call was
+// not made over TLS or did not present a client certificate.
+func withPeer(ctx context.Context, peers *servers.Peers) context.Context {
+
+	caller, ok := peer.FromContext(ctx)
+	if !ok {
+		return ctx
+	}
+
+	info, ok := caller.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return ctx
+	}
+
+	identified := peers.Peer(&info.State)
+	if identified == nil {
+		return ctx
+	}
+
+	return servers.ContextWithPeer(ctx, identified)
+}
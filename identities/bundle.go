@@ -0,0 +1,53 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package identities
+
+import (
+	"crypto/x509"
+	"time"
+
+	"github.com/greymatter-io/nautls/spiffe"
+	"github.com/pkg/errors"
+)
+
+// SPIFFEBundleOptions defines the options of a SPIFFE bundle.
+type SPIFFEBundleOptions struct {
+
+	// RefreshHint defines how often consumers should check for updates to the bundle or zero to omit the hint.
+	RefreshHint time.Duration
+
+	// Sequence defines the sequence number of the bundle which should be incremented each time the bundle changes.
+	Sequence uint64
+}
+
+// ToSPIFFEBundle returns the authorities of an identity as a SPIFFE bundle document holding an "x509-svid" key for
+// each authority or an error. Note that the certificate of an identity without authorities is used when it is a
+// certificate authority (e.g., a root created by Self).
+func (i *Identity) ToSPIFFEBundle(options SPIFFEBundleOptions) ([]byte, error) {
+
+	authorities := i.Authorities
+	if len(authorities) == 0 && i.Certificate != nil && i.Certificate.IsCA {
+		authorities = []*x509.Certificate{i.Certificate}
+	}
+
+	bundle := &spiffe.Bundle{Authorities: authorities, RefreshHint: options.RefreshHint, Sequence: options.Sequence}
+
+	data, err := bundle.Marshal()
+	if err != nil {
+		return nil, errors.Wrap(err, "error marshalling spiffe bundle")
+	}
+
+	return data, nil
+}
@@ -0,0 +1,65 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package identities
+
+import (
+	"testing"
+	"time"
+
+	"github.com/greymatter-io/nautls/spiffe"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSPIFFEBundle(t *testing.T) {
+
+	Convey("When Identity", t, func() {
+
+		template, _ := SVIDAuthorityTemplate("example.org", time.Hour)
+		authority, _ := Self(template)
+
+		leafTemplate, _ := SVIDTemplate("spiffe://example.org/web", time.Hour)
+		leaf, _ := authority.Issue(leafTemplate)
+
+		Convey(".ToSPIFFEBundle is invoked for an issued identity", func() {
+
+			data, err := leaf.ToSPIFFEBundle(SPIFFEBundleOptions{Sequence: 7})
+			So(err, ShouldBeNil)
+
+			bundle, err := spiffe.ParseBundle(data)
+
+			Convey("it returns a bundle of its authorities", func() {
+				So(err, ShouldBeNil)
+				So(bundle.Sequence, ShouldEqual, 7)
+				So(bundle.Authorities, ShouldHaveLength, 1)
+				So(bundle.Authorities[0].Equal(authority.Certificate), ShouldBeTrue)
+			})
+		})
+
+		Convey(".ToSPIFFEBundle is invoked for a root", func() {
+
+			data, err := authority.ToSPIFFEBundle(SPIFFEBundleOptions{})
+			So(err, ShouldBeNil)
+
+			bundle, err := spiffe.ParseBundle(data)
+
+			Convey("it returns a bundle of its certificate", func() {
+				So(err, ShouldBeNil)
+				So(bundle.Authorities, ShouldHaveLength, 1)
+				So(bundle.Authorities[0].Equal(authority.Certificate), ShouldBeTrue)
+			})
+		})
+	})
+}
@@ -15,8 +15,8 @@
 package identities
 
 import (
+	"crypto"
 	"crypto/rand"
-	"crypto/rsa"
 	"crypto/x509"
 
 	"github.com/pkg/errors"
@@ -26,11 +26,11 @@ import (
 type Identity struct {
 	Authorities []*x509.Certificate
 	Certificate *x509.Certificate
-	Key         *rsa.PrivateKey
+	Key         crypto.Signer
 }
 
 // NewIdentity returns a new identity.
-func NewIdentity(authorities []*x509.Certificate, certificate *x509.Certificate, key *rsa.PrivateKey) *Identity {
+func NewIdentity(authorities []*x509.Certificate, certificate *x509.Certificate, key crypto.Signer) *Identity {
 	return &Identity{
 		Authorities: authorities,
 		Certificate: certificate,
@@ -38,15 +38,16 @@ func NewIdentity(authorities []*x509.Certificate, certificate *x509.Certificate,
 	}
 }
 
-// Self generates a self signed identity (e.g., a root).
+// Self generates a self signed identity (e.g., a root). The private key is generated using the algorithm and size defined
+// by the template.
 func Self(template Template) (*Identity, error) {
 
-	key, err := rsa.GenerateKey(rand.Reader, 4096)
+	key, err := generateKey(template)
 	if err != nil {
 		return nil, errors.Wrapf(err, "error generating private key for [%s]", template.Subject.CommonName)
 	}
 
-	certificate, err := sign(template.certificate(), template.certificate(), &key.PublicKey, key)
+	certificate, err := sign(template.certificate(), template.certificate(), key.Public(), key)
 	if err != nil {
 		return nil, errors.Wrapf(err, "error signing certificate for [%s]", template.Subject.CommonName)
 	}
@@ -54,15 +55,16 @@ func Self(template Template) (*Identity, error) {
 	return NewIdentity([]*x509.Certificate{}, certificate, key), nil
 }
 
-// Issue returns a new identity signed by this identity based upon a template.
+// Issue returns a new identity signed by this identity based upon a template. The private key is generated using the
+// algorithm and size defined by the template.
 func (i *Identity) Issue(template Template) (*Identity, error) {
 
-	key, err := rsa.GenerateKey(rand.Reader, 4096)
+	key, err := generateKey(template)
 	if err != nil {
 		return nil, errors.Wrapf(err, "error generating private key for [%s]", template.Subject.CommonName)
 	}
 
-	certificate, err := sign(template.certificate(), i.Certificate, &key.PublicKey, i.Key)
+	certificate, err := sign(template.certificate(), i.Certificate, key.Public(), i.Key)
 	if err != nil {
 		return nil, errors.Wrapf(err, "error signing certificate for [%s]", template.Subject.CommonName)
 	}
@@ -71,7 +73,7 @@ func (i *Identity) Issue(template Template) (*Identity, error) {
 }
 
 // sign returns a signed certificate for the provided template.
-func sign(template, parent *x509.Certificate, public *rsa.PublicKey, private *rsa.PrivateKey) (*x509.Certificate, error) {
+func sign(template, parent *x509.Certificate, public crypto.PublicKey, private crypto.Signer) (*x509.Certificate, error) {
 
 	bytes, err := x509.CreateCertificate(rand.Reader, template, parent, public, private)
 	if err != nil {
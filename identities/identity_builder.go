@@ -51,7 +51,8 @@ func (b *IdentityBuilder) WithCertificate(certificate string) *IdentityBuilder {
 	return b
 }
 
-// WithKey sets the key for the identity. The value must be a URL that points to the location of a PEM encoded RSA key.
+// WithKey sets the key for the identity. The value must be a URL that points to the location of a PEM encoded RSA,
+// ECDSA or Ed25519 key.
 //
 // Note that in addition to those schemes supported by [getter](https://godoc.org/github.com/hashicorp/go-getter) a
 // "base64" scheme is supported for providing the PEM encoded certifiate in the path of the URL directly. This is most
@@ -60,3 +61,26 @@ func (b *IdentityBuilder) WithKey(key string) *IdentityBuilder {
 	b.config.Key = key
 	return b
 }
+
+// WithPassphrase sets the passphrase used to decrypt the key when it is encrypted. The value must be a URL that points to
+// the location of the passphrase.
+//
+// Note that in addition to those schemes supported by [getter](https://godoc.org/github.com/hashicorp/go-getter) a
+// "base64" scheme is supported for providing the passphrase in the path of the URL directly. This is most applicable
+// when the passphrase must be provided via an environement variable.
+func (b *IdentityBuilder) WithPassphrase(passphrase string) *IdentityBuilder {
+	b.config.Passphrase = passphrase
+	return b
+}
+
+// WithPKCS12 sets the PKCS #12 archive holding the certificate, key and certificate chain for the identity. The value
+// must be a URL that points to the location of a PKCS #12 (i.e., .p12 or .pfx) file and the password must be provided
+// using WithPassphrase.
+//
+// Note that in addition to those schemes supported by [getter](https://godoc.org/github.com/hashicorp/go-getter) a
+// "base64" scheme is supported for providing the PKCS #12 archive in the path of the URL directly. This is most
+// applicable when the archive must be provided via an environement variable.
+func (b *IdentityBuilder) WithPKCS12(pkcs12 string) *IdentityBuilder {
+	b.config.PKCS12 = pkcs12
+	return b
+}
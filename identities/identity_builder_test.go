@@ -145,6 +145,23 @@ func TestIdentityBuilder(t *testing.T) {
 					So(err, ShouldNotBeNil)
 				})
 			})
+
+			Convey("with a pkcs12 archive", func() {
+
+				builder.WithCertificate("")
+				builder.WithKey("")
+				builder.WithPKCS12(fmt.Sprintf("file://%s", tests.MustAbsolutePath("testdata/single.p12", t)))
+				builder.WithPassphrase(fmt.Sprintf("file://%s", tests.MustAbsolutePath("testdata/passphrase.txt", t)))
+				identity, err := builder.Build()
+
+				Convey("it should return a non-nil identity", func() {
+					So(identity, ShouldNotBeNil)
+				})
+
+				Convey("it should return a nil error", func() {
+					So(err, ShouldBeNil)
+				})
+			})
 		})
 	})
 }
@@ -15,25 +15,35 @@
 package identities
 
 import (
-	"crypto/rsa"
+	"crypto"
 	"crypto/x509"
 	"encoding/pem"
 	"fmt"
 
+	"github.com/greymatter-io/nautls/internal/keys"
 	"github.com/greymatter-io/nautls/internal/urls"
 	"github.com/pkg/errors"
 )
 
+// certificateBlock defines the PEM block type for X.509 certificates.
+const certificateBlock = "CERTIFICATE"
+
 // IdentityConfig provides a serializable representation of an Identity structure.
 type IdentityConfig struct {
 	Authorities string `json:"authorities" mapstructure:"authorities" yaml:"authorities"`
 	Certificate string `json:"certificate" mapstructure:"certificate" yaml:"certificate"`
 	Key         string `json:"key" mapstructure:"key" yaml:"key"`
+	Passphrase  string `json:"passphrase" mapstructure:"passphrase" yaml:"passphrase"`
+	PKCS12      string `json:"pkcs12" mapstructure:"pkcs12" yaml:"pkcs12"`
 }
 
 // Build creates an Identity from the IdentityConfig instance.
 func (c *IdentityConfig) Build() (*Identity, error) {
 
+	if c.PKCS12 != "" {
+		return c.buildPKCS12()
+	}
+
 	authorities, err := loadCertificates(c.Authorities)
 	if err != nil {
 		return nil, errors.Wrapf(err, "error loading authorities from [%s]", c.Authorities)
@@ -44,7 +54,12 @@ func (c *IdentityConfig) Build() (*Identity, error) {
 		return nil, errors.Wrapf(err, "error loading certificate from [%s]", c.Certificate)
 	}
 
-	key, err := loadKey(c.Key)
+	passphrase, err := keys.ReadPassphrase(c.Passphrase)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error loading passphrase from [%s]", c.Passphrase)
+	}
+
+	key, err := loadKey(c.Key, passphrase)
 	if err != nil {
 		return nil, errors.Wrapf(err, "error loading key from [%s]", c.Key)
 	}
@@ -54,6 +69,42 @@ func (c *IdentityConfig) Build() (*Identity, error) {
 	return identity, nil
 }
 
+// buildPKCS12 creates an Identity from the PKCS #12 archive of the IdentityConfig instance. Note that the authorities are
+// appended to the certificate chain in the archive when provided.
+func (c *IdentityConfig) buildPKCS12() (*Identity, error) {
+
+	if (c.Certificate != "") || (c.Key != "") {
+		return nil, fmt.Errorf("certificate and key must not be defined with pkcs12 [%s]", c.PKCS12)
+	}
+
+	passphrase, err := keys.ReadPassphrase(c.Passphrase)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error loading passphrase from [%s]", c.Passphrase)
+	}
+
+	bytes, err := loadResource(c.PKCS12)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error loading pkcs12 from [%s]", c.PKCS12)
+	}
+
+	identity, err := FromPKCS12(bytes, string(passphrase))
+	if err != nil {
+		return nil, errors.Wrapf(err, "error decoding pkcs12 from [%s]", c.PKCS12)
+	}
+
+	if c.Authorities != "" {
+
+		authorities, err := loadCertificates(c.Authorities)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error loading authorities from [%s]", c.Authorities)
+		}
+
+		identity.Authorities = append(identity.Authorities, authorities...)
+	}
+
+	return identity, nil
+}
+
 // loadCertificate loads a single PEM encoded X.509 certificate from a URL. Note that an error is thrown if the number
 // of certificates decoded is not one.
 func loadCertificate(resource string) (*x509.Certificate, error) {
@@ -89,11 +140,11 @@ func loadCertificates(resource string) ([]*x509.Certificate, error) {
 	return certificates, nil
 }
 
-// loadKey loads a single PEM encoded RSA key from a URL. Note that an error is thrown if the number
-// of keys decoded is not one.
-func loadKey(resource string) (*rsa.PrivateKey, error) {
+// loadKey loads a single PEM encoded private key from a URL, decrypting it with the passphrase if required. Note that an
+// error is thrown if the number of keys decoded is not one.
+func loadKey(resource string, passphrase []byte) (crypto.Signer, error) {
 
-	keys, err := loadKeys(resource)
+	keys, err := loadKeys(resource, passphrase)
 	if err != nil {
 		return nil, errors.Wrapf(err, "error loading key from [%s]", resource)
 	}
@@ -108,15 +159,15 @@ func loadKey(resource string) (*rsa.PrivateKey, error) {
 	}
 }
 
-// loadCertificates loads PEM encoded RSA Keys from a URL.
-func loadKeys(resource string) ([]*rsa.PrivateKey, error) {
+// loadKeys loads PEM encoded private keys from a URL, decrypting them with the passphrase if required.
+func loadKeys(resource string, passphrase []byte) ([]crypto.Signer, error) {
 
 	bytes, err := loadResource(resource)
 	if err != nil {
 		return nil, errors.Wrapf(err, "error loading keys from [%s]", resource)
 	}
 
-	keys, err := decodeKeys(bytes)
+	keys, err := decodeKeys(bytes, passphrase)
 	if err != nil {
 		return nil, errors.Wrapf(err, "error decoding keys from [%s]", resource)
 	}
@@ -125,8 +176,9 @@ func loadKeys(resource string) ([]*rsa.PrivateKey, error) {
 
 }
 
-// decodeCertificates decodes PEM encoded X.509 certificates. Note that unparsable values outside a PEM block are
-// ignored while unparsable values inside a PEM block will result in an error.
+// decodeCertificates decodes PEM encoded X.509 certificates. Note that unparsable values outside a PEM block and PEM
+// blocks that are not certificates (e.g., private keys in a bundle) are ignored while unparsable values inside a
+// certificate block will result in an error.
 func decodeCertificates(bytes []byte) ([]*x509.Certificate, error) {
 
 	var result []*x509.Certificate
@@ -134,12 +186,15 @@ func decodeCertificates(bytes []byte) ([]*x509.Certificate, error) {
 	decoded, tail := pem.Decode(bytes)
 	for decoded != nil {
 
-		parsed, err := x509.ParseCertificate(decoded.Bytes)
-		if err != nil {
-			return nil, errors.Wrap(err, "error parsing certificates")
-		}
+		if decoded.Type == certificateBlock {
+
+			parsed, err := x509.ParseCertificate(decoded.Bytes)
+			if err != nil {
+				return nil, errors.Wrap(err, "error parsing certificates")
+			}
 
-		result = append(result, parsed)
+			result = append(result, parsed)
+		}
 
 		decoded, tail = pem.Decode(tail)
 	}
@@ -147,21 +202,31 @@ func decodeCertificates(bytes []byte) ([]*x509.Certificate, error) {
 	return result, nil
 }
 
-// decodeKeys decodes PEM encoded RSA keys. Note that unparsable values outside a PEM block are ignored while unparsable
-// values inside a PEM block will result in an error.
-func decodeKeys(bytes []byte) ([]*rsa.PrivateKey, error) {
+// decodeKeys decodes PEM encoded RSA, ECDSA and Ed25519 keys from PKCS #1, SEC 1 and PKCS #8 blocks, decrypting legacy
+// and PKCS #8 encrypted blocks with the passphrase. Note that unparsable values outside a PEM block and PEM blocks that
+// are not private keys (e.g., certificates in a bundle or EC parameters) are ignored while unparsable values inside a
+// private key block will result in an error.
+func decodeKeys(bytes []byte, passphrase []byte) ([]crypto.Signer, error) {
 
-	var result []*rsa.PrivateKey
+	var result []crypto.Signer
 
 	decoded, tail := pem.Decode(bytes)
 	for decoded != nil {
 
-		parsed, err := x509.ParsePKCS1PrivateKey(decoded.Bytes)
+		block, err := keys.DecryptBlock(decoded, passphrase)
 		if err != nil {
-			return nil, errors.Wrap(err, "error parsing keys")
+			return nil, errors.Wrap(err, "error decrypting keys")
 		}
 
-		result = append(result, parsed)
+		if isKeyBlock(block) {
+
+			parsed, err := parseKey(block)
+			if err != nil {
+				return nil, errors.Wrap(err, "error parsing keys")
+			}
+
+			result = append(result, parsed)
+		}
 
 		decoded, tail = pem.Decode(tail)
 	}
@@ -15,6 +15,9 @@
 package identities
 
 import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
 	"encoding/json"
 	"testing"
 
@@ -134,6 +137,253 @@ func TestIdentityConfig(t *testing.T) {
 				})
 			})
 
+			Convey("with an ecdsa key", func() {
+
+				config.Key = "./testdata/ecdsa.key"
+				identity, err := config.Build()
+
+				Convey("it should return a non-nil identity", func() {
+					So(identity, ShouldNotBeNil)
+				})
+
+				Convey("it should return an ecdsa key", func() {
+					So(identity.Key, ShouldHaveSameTypeAs, &ecdsa.PrivateKey{})
+				})
+
+				Convey("it should return a nil error", func() {
+					So(err, ShouldBeNil)
+				})
+			})
+
+			Convey("with an ed25519 key", func() {
+
+				config.Key = "./testdata/ed25519.key"
+				identity, err := config.Build()
+
+				Convey("it should return a non-nil identity", func() {
+					So(identity, ShouldNotBeNil)
+				})
+
+				Convey("it should return an ed25519 key", func() {
+					So(identity.Key, ShouldHaveSameTypeAs, ed25519.PrivateKey{})
+				})
+
+				Convey("it should return a nil error", func() {
+					So(err, ShouldBeNil)
+				})
+			})
+
+			Convey("with a pkcs8 key", func() {
+
+				config.Key = "./testdata/pkcs8.key"
+				identity, err := config.Build()
+
+				Convey("it should return an rsa key", func() {
+					So(identity.Key, ShouldHaveSameTypeAs, &rsa.PrivateKey{})
+				})
+
+				Convey("it should return a nil error", func() {
+					So(err, ShouldBeNil)
+				})
+			})
+
+			Convey("with a key preceded by ec parameters", func() {
+
+				config.Key = "./testdata/parameters.key"
+				identity, err := config.Build()
+
+				Convey("it should return an ecdsa key", func() {
+					So(identity.Key, ShouldHaveSameTypeAs, &ecdsa.PrivateKey{})
+				})
+
+				Convey("it should return a nil error", func() {
+					So(err, ShouldBeNil)
+				})
+			})
+
+			Convey("with a bundle containing a certificate and key", func() {
+
+				config.Certificate = "./testdata/bundle.pem"
+				config.Key = "./testdata/bundle.pem"
+				identity, err := config.Build()
+
+				Convey("it should return the certificate", func() {
+					So(identity.Certificate.Subject.CommonName, ShouldNotBeEmpty)
+				})
+
+				Convey("it should return a key matching the certificate", func() {
+					So(identity.Key.Public(), ShouldResemble, identity.Certificate.PublicKey)
+				})
+
+				Convey("it should return a nil error", func() {
+					So(err, ShouldBeNil)
+				})
+			})
+
+			Convey("with a bundle as authorities", func() {
+
+				config.Authorities = "./testdata/bundle.pem"
+				identity, err := config.Build()
+
+				Convey("it should return only the certificates", func() {
+					So(identity.Authorities, ShouldHaveLength, 1)
+				})
+
+				Convey("it should return a nil error", func() {
+					So(err, ShouldBeNil)
+				})
+			})
+
+			Convey("with a legacy encrypted key", func() {
+
+				config.Key = "./testdata/encrypted.key"
+
+				Convey("and a valid passphrase", func() {
+
+					config.Passphrase = "./testdata/passphrase.txt"
+					identity, err := config.Build()
+
+					Convey("it should return an rsa key", func() {
+						So(identity.Key, ShouldHaveSameTypeAs, &rsa.PrivateKey{})
+					})
+
+					Convey("it should return a nil error", func() {
+						So(err, ShouldBeNil)
+					})
+				})
+
+				Convey("and an invalid passphrase", func() {
+
+					config.Passphrase = "base64:///aW52YWxpZA=="
+					identity, err := config.Build()
+
+					Convey("it should return a nil identity", func() {
+						So(identity, ShouldBeNil)
+					})
+
+					Convey("it should return a non-nil error", func() {
+						So(err, ShouldNotBeNil)
+					})
+				})
+
+				Convey("and no passphrase", func() {
+
+					identity, err := config.Build()
+
+					Convey("it should return a nil identity", func() {
+						So(identity, ShouldBeNil)
+					})
+
+					Convey("it should return a non-nil error", func() {
+						So(err, ShouldNotBeNil)
+					})
+				})
+			})
+
+			Convey("with an encrypted pkcs8 key", func() {
+
+				config.Key = "./testdata/encrypted.pkcs8.key"
+
+				Convey("and a valid passphrase", func() {
+
+					config.Passphrase = "./testdata/passphrase.txt"
+					identity, err := config.Build()
+
+					Convey("it should return an ecdsa key", func() {
+						So(identity.Key, ShouldHaveSameTypeAs, &ecdsa.PrivateKey{})
+					})
+
+					Convey("it should return a nil error", func() {
+						So(err, ShouldBeNil)
+					})
+				})
+
+				Convey("and an invalid passphrase", func() {
+
+					config.Passphrase = "base64:///aW52YWxpZA=="
+					identity, err := config.Build()
+
+					Convey("it should return a nil identity", func() {
+						So(identity, ShouldBeNil)
+					})
+
+					Convey("it should return a non-nil error", func() {
+						So(err, ShouldNotBeNil)
+					})
+				})
+			})
+
+			Convey("with a pkcs12 archive", func() {
+
+				config.Certificate = ""
+				config.Key = ""
+				config.PKCS12 = "./testdata/single.p12"
+				config.Passphrase = "./testdata/passphrase.txt"
+
+				Convey("and authorities", func() {
+
+					identity, err := config.Build()
+
+					Convey("it should return the certificate", func() {
+						So(identity.Certificate, ShouldNotBeNil)
+					})
+
+					Convey("it should return a key matching the certificate", func() {
+						So(identity.Key.Public(), ShouldResemble, identity.Certificate.PublicKey)
+					})
+
+					Convey("it should return the archived and additional authorities", func() {
+						So(identity.Authorities, ShouldHaveLength, 4)
+					})
+
+					Convey("it should return a nil error", func() {
+						So(err, ShouldBeNil)
+					})
+				})
+
+				Convey("and no authorities", func() {
+
+					config.Authorities = ""
+					identity, err := config.Build()
+
+					Convey("it should return the archived authorities", func() {
+						So(identity.Authorities, ShouldHaveLength, 2)
+					})
+
+					Convey("it should return a nil error", func() {
+						So(err, ShouldBeNil)
+					})
+				})
+
+				Convey("and an invalid passphrase", func() {
+
+					config.Passphrase = "base64:///aW52YWxpZA=="
+					identity, err := config.Build()
+
+					Convey("it should return a nil identity", func() {
+						So(identity, ShouldBeNil)
+					})
+
+					Convey("it should return a non-nil error", func() {
+						So(err, ShouldNotBeNil)
+					})
+				})
+
+				Convey("and a certificate", func() {
+
+					config.Certificate = "./testdata/single.crt"
+					identity, err := config.Build()
+
+					Convey("it should return a nil identity", func() {
+						So(identity, ShouldBeNil)
+					})
+
+					Convey("it should return a non-nil error", func() {
+						So(err, ShouldNotBeNil)
+					})
+				})
+			})
+
 			Convey("with multiple keys", func() {
 
 				config.Key = "./testdata/multiple.key"
@@ -157,6 +407,8 @@ func TestIdentityConfig(t *testing.T) {
 				"authorities": "authorities",
 				"certificate": "certificate",
 				"key":         "key",
+				"passphrase":  "passphrase",
+				"pkcs12":      "pkcs12",
 			}
 
 			Convey("from JSON", func() {
@@ -175,6 +427,14 @@ func TestIdentityConfig(t *testing.T) {
 					So(actual.Key, ShouldEqual, expected["key"])
 				})
 
+				Convey("it should populate the passphrase", func() {
+					So(actual.Passphrase, ShouldEqual, expected["passphrase"])
+				})
+
+				Convey("it should populate the pkcs12", func() {
+					So(actual.PKCS12, ShouldEqual, expected["pkcs12"])
+				})
+
 				Convey("it should return a nil error", func() {
 					So(err, ShouldBeNil)
 				})
@@ -196,6 +456,14 @@ func TestIdentityConfig(t *testing.T) {
 					So(actual.Key, ShouldEqual, expected["key"])
 				})
 
+				Convey("it should populate the passphrase", func() {
+					So(actual.Passphrase, ShouldEqual, expected["passphrase"])
+				})
+
+				Convey("it should populate the pkcs12", func() {
+					So(actual.PKCS12, ShouldEqual, expected["pkcs12"])
+				})
+
 				Convey("it should return a nil error", func() {
 					So(err, ShouldBeNil)
 				})
@@ -15,6 +15,9 @@
 package identities
 
 import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
@@ -56,7 +59,7 @@ func TestIdentity(t *testing.T) {
 
 			Convey("with an unauthorative template", func() {
 
-				template := Template{}
+				template := Template{SerialNumber: big.NewInt(-1)}
 				identity, err := Self(template)
 
 				Convey("it returns a nil identity", func() {
@@ -68,6 +71,71 @@ func TestIdentity(t *testing.T) {
 				})
 			})
 
+			Convey("with an ecdsa template", func() {
+
+				identity, err := Self(Template{
+					KeyAlgorithm: ECDSA,
+					KeySize:      384,
+					NotAfter:     time.Now().AddDate(10, 0, 0),
+					NotBefore:    time.Now(),
+					SerialNumber: big.NewInt(time.Now().Unix()),
+					Subject:      pkix.Name{CommonName: "NauTLS (ECDSA)"},
+				})
+
+				Convey("it returns a nil error", func() {
+					So(err, ShouldBeNil)
+				})
+
+				Convey("it returns an identity with an ecdsa key of the requested size", func() {
+					So(identity.Key, ShouldHaveSameTypeAs, &ecdsa.PrivateKey{})
+					So(identity.Key.(*ecdsa.PrivateKey).Curve, ShouldEqual, elliptic.P384())
+				})
+
+				Convey("it returns a certificate with an ecdsa public key", func() {
+					So(identity.Certificate.PublicKeyAlgorithm, ShouldEqual, x509.ECDSA)
+				})
+			})
+
+			Convey("with an ed25519 template", func() {
+
+				identity, err := Self(Template{
+					KeyAlgorithm: Ed25519,
+					NotAfter:     time.Now().AddDate(10, 0, 0),
+					NotBefore:    time.Now(),
+					SerialNumber: big.NewInt(time.Now().Unix()),
+					Subject:      pkix.Name{CommonName: "NauTLS (Ed25519)"},
+				})
+
+				Convey("it returns a nil error", func() {
+					So(err, ShouldBeNil)
+				})
+
+				Convey("it returns an identity with an ed25519 key", func() {
+					So(identity.Key, ShouldHaveSameTypeAs, ed25519.PrivateKey{})
+				})
+
+				Convey("it returns a certificate with an ed25519 public key", func() {
+					So(identity.Certificate.PublicKeyAlgorithm, ShouldEqual, x509.Ed25519)
+				})
+			})
+
+			Convey("with an unsupported key size", func() {
+
+				identity, err := Self(Template{
+					KeyAlgorithm: ECDSA,
+					KeySize:      128,
+					SerialNumber: big.NewInt(time.Now().Unix()),
+				})
+
+				Convey("it returns a nil identity", func() {
+					So(identity, ShouldBeNil)
+				})
+
+				Convey("it returns a non-nil error", func() {
+					So(err, ShouldNotBeNil)
+				})
+			})
+
 			Convey("with an valid template", func() {
 
 				template := Template{
@@ -146,6 +214,7 @@ func TestIdentity(t *testing.T) {
 
 			identity, err := intermediate.Issue(Template{
 				BasicConstraintsValid: true,
+				DNSNames:              []string{"nautls.com"},
 				ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
 				IsCA:                  false,
 				KeyUsage:              x509.KeyUsageDigitalSignature,
@@ -185,5 +254,46 @@ func TestIdentity(t *testing.T) {
 				So(err, ShouldBeNil)
 			})
 		})
+
+		Convey(".Issue is invoked with mixed key algorithms", func() {
+
+			root, _ := Self(Template{
+				BasicConstraintsValid: true,
+				IsCA:                  true,
+				KeyAlgorithm:          Ed25519,
+				KeyUsage:              x509.KeyUsageCRLSign | x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+				NotAfter:              time.Now().AddDate(10, 0, 0),
+				NotBefore:             time.Now(),
+				SerialNumber:          big.NewInt(time.Now().Unix()),
+				Subject:               pkix.Name{CommonName: "NauTLS (Ed25519 Root)"},
+			})
+
+			identity, err := root.Issue(Template{
+				DNSNames:     []string{"nautls.com"},
+				ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+				KeyAlgorithm: ECDSA,
+				KeyUsage:     x509.KeyUsageDigitalSignature,
+				NotAfter:     time.Now().AddDate(10, 0, 0),
+				NotBefore:    time.Now(),
+				SerialNumber: big.NewInt(time.Now().Unix()),
+				Subject:      pkix.Name{CommonName: "nautls.com"},
+			})
+
+			roots := x509.NewCertPool()
+			roots.AddCert(root.Certificate)
+
+			Convey("it returns a nil error", func() {
+				So(err, ShouldBeNil)
+			})
+
+			Convey("it returns a verifiable identity", func() {
+				_, err := identity.Certificate.Verify(x509.VerifyOptions{DNSName: "nautls.com", Roots: roots})
+				So(err, ShouldBeNil)
+			})
+
+			Convey("it returns a certificate signed with ed25519", func() {
+				So(identity.Certificate.SignatureAlgorithm, ShouldEqual, x509.PureEd25519)
+			})
+		})
 	})
 }
@@ -0,0 +1,183 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package identities
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// KeyAlgorithm defines the algorithm used to generate the private key of an identity.
+type KeyAlgorithm int
+
+const (
+	// RSA generates RSA private keys. This is the default algorithm.
+	RSA KeyAlgorithm = iota
+
+	// ECDSA generates ECDSA private keys on one of the NIST curves.
+	ECDSA
+
+	// Ed25519 generates Ed25519 private keys.
+	Ed25519
+)
+
+const (
+	// DefaultRSAKeySize defines the modulus size in bits used for RSA keys when no size is provided.
+	DefaultRSAKeySize = 4096
+
+	// DefaultECDSAKeySize defines the curve size in bits used for ECDSA keys when no size is provided.
+	DefaultECDSAKeySize = 256
+)
+
+const (
+	// pkcs1KeyBlock defines the PEM block type for PKCS #1 encoded RSA private keys.
+	pkcs1KeyBlock = "RSA PRIVATE KEY"
+
+	// pkcs8KeyBlock defines the PEM block type for PKCS #8 encoded private keys.
+	pkcs8KeyBlock = "PRIVATE KEY"
+
+	// sec1KeyBlock defines the PEM block type for SEC 1 encoded ECDSA private keys.
+	sec1KeyBlock = "EC PRIVATE KEY"
+)
+
+// String returns the name of the key algorithm.
+func (a KeyAlgorithm) String() string {
+
+	switch a {
+	case RSA:
+		return "RSA"
+	case ECDSA:
+		return "ECDSA"
+	case Ed25519:
+		return "Ed25519"
+	default:
+		return fmt.Sprintf("KeyAlgorithm(%d)", int(a))
+	}
+}
+
+// generateKey generates a private key using the algorithm and size defined by a template. Note that the size is the
+// modulus size for RSA keys, the curve size (i.e., 224, 256, 384 or 521) for ECDSA keys and is ignored for Ed25519 keys.
+func generateKey(template Template) (crypto.Signer, error) {
+
+	switch template.KeyAlgorithm {
+	case RSA:
+
+		size := template.KeySize
+		if size == 0 {
+			size = DefaultRSAKeySize
+		}
+
+		return rsa.GenerateKey(rand.Reader, size)
+
+	case ECDSA:
+
+		size := template.KeySize
+		if size == 0 {
+			size = DefaultECDSAKeySize
+		}
+
+		curve, err := curve(size)
+		if err != nil {
+			return nil, err
+		}
+
+		return ecdsa.GenerateKey(curve, rand.Reader)
+
+	case Ed25519:
+
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+
+		return key, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported key algorithm [%s]", template.KeyAlgorithm)
+	}
+}
+
+// curve returns the NIST elliptic curve for a size in bits.
+func curve(size int) (elliptic.Curve, error) {
+
+	switch size {
+	case 224:
+		return elliptic.P224(), nil
+	case 256:
+		return elliptic.P256(), nil
+	case 384:
+		return elliptic.P384(), nil
+	case 521:
+		return elliptic.P521(), nil
+	default:
+		return nil, fmt.Errorf("unsupported ecdsa key size [%d]", size)
+	}
+}
+
+// parseKey parses a PEM block containing a PKCS #1 ("RSA PRIVATE KEY"), SEC 1 ("EC PRIVATE KEY") or PKCS #8
+// ("PRIVATE KEY") encoded private key.
+func parseKey(block *pem.Block) (crypto.Signer, error) {
+
+	switch block.Type {
+	case pkcs1KeyBlock:
+
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "error parsing pkcs1 private key")
+		}
+
+		return key, nil
+
+	case sec1KeyBlock:
+
+		key, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "error parsing sec1 private key")
+		}
+
+		return key, nil
+
+	case pkcs8KeyBlock:
+
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "error parsing pkcs8 private key")
+		}
+
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key type [%T]", key)
+		}
+
+		return signer, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported private key block type [%s]", block.Type)
+	}
+}
+
+// isKeyBlock returns true if a PEM block contains a private key supported by parseKey.
+func isKeyBlock(block *pem.Block) bool {
+	return block.Type == pkcs1KeyBlock || block.Type == sec1KeyBlock || block.Type == pkcs8KeyBlock
+}
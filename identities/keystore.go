@@ -0,0 +1,329 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package identities
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	"github.com/pavlo-v-chernykh/keystore-go/v4"
+	"github.com/pkg/errors"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// KeyStoreFormat defines the file format of a Java keystore or truststore.
+type KeyStoreFormat int
+
+const (
+	// JKS defines the proprietary Java KeyStore format supported by all Java versions.
+	JKS KeyStoreFormat = iota
+
+	// PKCS12 defines the PKCS #12 format which is the default keystore format for Java 9 and later.
+	PKCS12
+)
+
+// DefaultKeyStoreAlias defines the alias of the private key entry when no alias is provided.
+const DefaultKeyStoreAlias = "nautls"
+
+// String returns the name of the keystore format.
+func (f KeyStoreFormat) String() string {
+
+	switch f {
+	case JKS:
+		return "JKS"
+	case PKCS12:
+		return "PKCS12"
+	default:
+		return fmt.Sprintf("KeyStoreFormat(%d)", int(f))
+	}
+}
+
+// KeyStoreOptions defines the format, alias and passwords used when reading and writing Java keystores and truststores.
+type KeyStoreOptions struct {
+
+	// Alias defines the alias of the private key entry in a keystore. It defaults to DefaultKeyStoreAlias. Note that
+	// the PKCS #12 format stores the entry without an alias, which Java exposes as "1", and that an alias is rejected
+	// for it.
+	Alias string
+
+	// Format defines the file format of the keystore or truststore.
+	Format KeyStoreFormat
+
+	// KeyPassword defines the password protecting the private key entry in a keystore. It defaults to the store
+	// password and a different password is rejected for the PKCS #12 format.
+	KeyPassword string
+
+	// StorePassword defines the password protecting the integrity of the keystore or truststore.
+	StorePassword string
+}
+
+// alias returns the alias of the private key entry.
+func (o KeyStoreOptions) alias() string {
+
+	if o.Alias == "" {
+		return DefaultKeyStoreAlias
+	}
+
+	return o.Alias
+}
+
+// checkPKCS12 returns an error if the options define an alias or a key password different from the store password as
+// neither is supported by the PKCS #12 format.
+func (o KeyStoreOptions) checkPKCS12() error {
+
+	if o.Alias != "" {
+		return fmt.Errorf("pkcs12 keystores do not support the alias [%s]", o.Alias)
+	}
+
+	if o.keyPassword() != o.StorePassword {
+		return errors.New("pkcs12 keystores require the key password to equal the store password")
+	}
+
+	return nil
+}
+
+// keyPassword returns the password of the private key entry.
+func (o KeyStoreOptions) keyPassword() string {
+
+	if o.KeyPassword == "" {
+		return o.StorePassword
+	}
+
+	return o.KeyPassword
+}
+
+// FromKeyStore sets the value of an identity to the leaf certificate, certificate chain and private key held in a Java
+// keystore or errors.
+func (i *Identity) FromKeyStore(data []byte, options KeyStoreOptions) error {
+
+	switch options.Format {
+	case JKS:
+
+		store := keystore.New()
+
+		err := store.Load(bytes.NewReader(data), []byte(options.StorePassword))
+		if err != nil {
+			return errors.Wrap(err, "error loading jks keystore")
+		}
+
+		entry, err := store.GetPrivateKeyEntry(options.alias(), []byte(options.keyPassword()))
+		if err != nil {
+			return errors.Wrapf(err, "error reading private key entry [%s]", options.alias())
+		}
+
+		key, err := x509.ParsePKCS8PrivateKey(entry.PrivateKey)
+		if err != nil {
+			return errors.Wrapf(err, "error parsing private key entry [%s]", options.alias())
+		}
+
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return fmt.Errorf("unsupported private key type [%T] in entry [%s]", key, options.alias())
+		}
+
+		var chain []*x509.Certificate
+		for _, certificate := range entry.CertificateChain {
+
+			parsed, err := x509.ParseCertificate(certificate.Content)
+			if err != nil {
+				return errors.Wrapf(err, "error parsing certificate chain of entry [%s]", options.alias())
+			}
+
+			chain = append(chain, parsed)
+		}
+
+		if len(chain) == 0 {
+			return fmt.Errorf("no certificates defined in entry [%s]", options.alias())
+		}
+
+		*i = *NewIdentity(chain[1:], chain[0], signer)
+
+		return nil
+
+	case PKCS12:
+
+		err := options.checkPKCS12()
+		if err != nil {
+			return err
+		}
+
+		identity, err := FromPKCS12(data, options.StorePassword)
+		if err != nil {
+			return err
+		}
+
+		*i = *identity
+
+		return nil
+
+	default:
+		return fmt.Errorf("unsupported keystore format [%s]", options.Format)
+	}
+}
+
+// ToKeyStore returns the leaf certificate, certificate chain (i.e., the authorities) and private key of an identity as
+// a Java keystore holding a single private key entry or an error.
+func (i *Identity) ToKeyStore(options KeyStoreOptions) ([]byte, error) {
+
+	switch options.Format {
+	case JKS:
+
+		key, err := x509.MarshalPKCS8PrivateKey(i.Key)
+		if err != nil {
+			return nil, errors.Wrap(err, "error marshalling private key")
+		}
+
+		chain := []keystore.Certificate{{Type: "X509", Content: i.Certificate.Raw}}
+		for _, authority := range i.Authorities {
+			chain = append(chain, keystore.Certificate{Type: "X509", Content: authority.Raw})
+		}
+
+		entry := keystore.PrivateKeyEntry{
+			CreationTime:     time.Now(),
+			PrivateKey:       key,
+			CertificateChain: chain,
+		}
+
+		store := keystore.New()
+
+		err = store.SetPrivateKeyEntry(options.alias(), entry, []byte(options.keyPassword()))
+		if err != nil {
+			return nil, errors.Wrapf(err, "error setting private key entry [%s]", options.alias())
+		}
+
+		return storeKeyStore(store, options.StorePassword)
+
+	case PKCS12:
+
+		err := options.checkPKCS12()
+		if err != nil {
+			return nil, err
+		}
+
+		return i.ToPKCS12(options.StorePassword)
+
+	default:
+		return nil, fmt.Errorf("unsupported keystore format [%s]", options.Format)
+	}
+}
+
+// FromTrustStore sets the authorities of an identity to the trusted certificates held in a Java truststore or errors.
+// Note that the certificate and key of the identity are not modified.
+func (i *Identity) FromTrustStore(data []byte, options KeyStoreOptions) error {
+
+	var authorities []*x509.Certificate
+
+	switch options.Format {
+	case JKS:
+
+		store := keystore.New(keystore.WithOrderedAliases())
+
+		err := store.Load(bytes.NewReader(data), []byte(options.StorePassword))
+		if err != nil {
+			return errors.Wrap(err, "error loading jks truststore")
+		}
+
+		for _, alias := range store.Aliases() {
+
+			if !store.IsTrustedCertificateEntry(alias) {
+				continue
+			}
+
+			entry, err := store.GetTrustedCertificateEntry(alias)
+			if err != nil {
+				return errors.Wrapf(err, "error reading trusted certificate entry [%s]", alias)
+			}
+
+			parsed, err := x509.ParseCertificate(entry.Certificate.Content)
+			if err != nil {
+				return errors.Wrapf(err, "error parsing trusted certificate entry [%s]", alias)
+			}
+
+			authorities = append(authorities, parsed)
+		}
+
+	case PKCS12:
+
+		certificates, err := pkcs12.DecodeTrustStore(data, options.StorePassword)
+		if err != nil {
+			return errors.Wrap(err, "error decoding pkcs12 truststore")
+		}
+
+		authorities = certificates
+
+	default:
+		return fmt.Errorf("unsupported truststore format [%s]", options.Format)
+	}
+
+	i.Authorities = authorities
+
+	return nil
+}
+
+// ToTrustStore returns the authorities of an identity as a Java truststore holding a trusted certificate entry for each
+// authority or an error. The entries are aliased by the subject of each authority.
+func (i *Identity) ToTrustStore(options KeyStoreOptions) ([]byte, error) {
+
+	switch options.Format {
+	case JKS:
+
+		store := keystore.New()
+
+		for index, authority := range i.Authorities {
+
+			entry := keystore.TrustedCertificateEntry{
+				CreationTime: time.Now(),
+				Certificate:  keystore.Certificate{Type: "X509", Content: authority.Raw},
+			}
+
+			alias := fmt.Sprintf("%d-%s", index, authority.Subject.String())
+
+			err := store.SetTrustedCertificateEntry(alias, entry)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error setting trusted certificate entry [%s]", alias)
+			}
+		}
+
+		return storeKeyStore(store, options.StorePassword)
+
+	case PKCS12:
+
+		data, err := pkcs12.Modern2023.EncodeTrustStore(i.Authorities, options.StorePassword)
+		if err != nil {
+			return nil, errors.Wrap(err, "error encoding pkcs12 truststore")
+		}
+
+		return data, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported truststore format [%s]", options.Format)
+	}
+}
+
+// storeKeyStore returns a JKS keystore serialized with a password or an error.
+func storeKeyStore(store keystore.KeyStore, password string) ([]byte, error) {
+
+	var buffer bytes.Buffer
+
+	err := store.Store(&buffer, []byte(password))
+	if err != nil {
+		return nil, errors.Wrap(err, "error storing jks keystore")
+	}
+
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,197 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package identities
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestKeyStore(t *testing.T) {
+
+	Convey("When Identity", t, func() {
+
+		root, _ := Self(Template{
+			BasicConstraintsValid: true,
+			IsCA:                  true,
+			KeyAlgorithm:          ECDSA,
+			KeyUsage:              x509.KeyUsageCRLSign | x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+			NotAfter:              time.Now().AddDate(10, 0, 0),
+			NotBefore:             time.Now(),
+			SerialNumber:          big.NewInt(time.Now().Unix()),
+			Subject:               pkix.Name{CommonName: "NauTLS (Root)"},
+		})
+
+		intermediate, _ := root.Issue(Template{
+			BasicConstraintsValid: true,
+			IsCA:                  true,
+			KeyAlgorithm:          ECDSA,
+			KeyUsage:              x509.KeyUsageCRLSign | x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+			NotAfter:              time.Now().AddDate(10, 0, 0),
+			NotBefore:             time.Now(),
+			SerialNumber:          big.NewInt(time.Now().Unix()),
+			Subject:               pkix.Name{CommonName: "NauTLS (Intermediate)"},
+		})
+
+		identity, _ := intermediate.Issue(Template{
+			DNSNames:     []string{"nautls.com"},
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+			KeyAlgorithm: ECDSA,
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			NotAfter:     time.Now().AddDate(10, 0, 0),
+			NotBefore:    time.Now(),
+			SerialNumber: big.NewInt(time.Now().Unix()),
+			Subject:      pkix.Name{CommonName: "nautls.com"},
+		})
+
+		for _, format := range []KeyStoreFormat{JKS, PKCS12} {
+
+			Convey(".ToKeyStore is invoked for "+format.String(), func() {
+
+				options := KeyStoreOptions{Format: format, StorePassword: "changeit"}
+				if format == JKS {
+					options.Alias = "server"
+				}
+
+				data, err := identity.ToKeyStore(options)
+
+				Convey("it returns a nil error", func() {
+					So(err, ShouldBeNil)
+				})
+
+				Convey("and .FromKeyStore is invoked", func() {
+
+					Convey("with the correct options", func() {
+
+						actual := &Identity{}
+						err := actual.FromKeyStore(data, options)
+
+						Convey("it returns a nil error", func() {
+							So(err, ShouldBeNil)
+						})
+
+						Convey("it sets the certificate", func() {
+							So(actual.Certificate.Equal(identity.Certificate), ShouldBeTrue)
+						})
+
+						Convey("it sets the authorities", func() {
+							So(actual.Authorities, ShouldHaveLength, 2)
+							So(actual.Authorities[0].Equal(intermediate.Certificate), ShouldBeTrue)
+							So(actual.Authorities[1].Equal(root.Certificate), ShouldBeTrue)
+						})
+
+						Convey("it sets the key", func() {
+							So(actual.Key.Public(), ShouldResemble, identity.Key.Public())
+						})
+					})
+
+					Convey("with an incorrect store password", func() {
+
+						options.StorePassword = "invalid"
+						options.KeyPassword = "changeit"
+						err := (&Identity{}).FromKeyStore(data, options)
+
+						Convey("it returns a non-nil error", func() {
+							So(err, ShouldNotBeNil)
+						})
+					})
+				})
+			})
+
+			Convey(".ToTrustStore is invoked for "+format.String(), func() {
+
+				options := KeyStoreOptions{Format: format, StorePassword: "changeit"}
+				data, err := identity.ToTrustStore(options)
+
+				Convey("it returns a nil error", func() {
+					So(err, ShouldBeNil)
+				})
+
+				Convey("and .FromTrustStore is invoked", func() {
+
+					actual := &Identity{}
+					err := actual.FromTrustStore(data, options)
+
+					Convey("it returns a nil error", func() {
+						So(err, ShouldBeNil)
+					})
+
+					Convey("it sets the authorities", func() {
+						So(actual.Authorities, ShouldHaveLength, 2)
+						So(actual.Authorities[0].Equal(intermediate.Certificate), ShouldBeTrue)
+						So(actual.Authorities[1].Equal(root.Certificate), ShouldBeTrue)
+					})
+				})
+			})
+		}
+
+		Convey(".ToKeyStore is invoked for JKS with a distinct key password", func() {
+
+			options := KeyStoreOptions{Format: JKS, KeyPassword: "keypass", StorePassword: "changeit"}
+			data, _ := identity.ToKeyStore(options)
+
+			Convey("it cannot be read with the store password", func() {
+				err := (&Identity{}).FromKeyStore(data, KeyStoreOptions{Format: JKS, StorePassword: "changeit"})
+				So(err, ShouldNotBeNil)
+			})
+
+			Convey("it can be read with the key password", func() {
+				err := (&Identity{}).FromKeyStore(data, options)
+				So(err, ShouldBeNil)
+			})
+		})
+
+		Convey(".ToKeyStore is invoked for PKCS12 with a distinct key password", func() {
+
+			data, err := identity.ToKeyStore(KeyStoreOptions{Format: PKCS12, KeyPassword: "keypass", StorePassword: "changeit"})
+
+			Convey("it returns a non-nil error", func() {
+				So(err, ShouldNotBeNil)
+			})
+
+			Convey("it returns a nil keystore", func() {
+				So(data, ShouldBeNil)
+			})
+		})
+
+		Convey(".ToKeyStore is invoked for PKCS12 with an alias", func() {
+
+			data, err := identity.ToKeyStore(KeyStoreOptions{Alias: "server", Format: PKCS12, StorePassword: "changeit"})
+
+			Convey("it returns a non-nil error", func() {
+				So(err, ShouldNotBeNil)
+			})
+
+			Convey("it returns a nil keystore", func() {
+				So(data, ShouldBeNil)
+			})
+		})
+
+		Convey(".FromKeyStore is invoked for PKCS12 with an alias", func() {
+
+			data, _ := identity.ToKeyStore(KeyStoreOptions{Format: PKCS12, StorePassword: "changeit"})
+			err := (&Identity{}).FromKeyStore(data, KeyStoreOptions{Alias: "server", Format: PKCS12, StorePassword: "changeit"})
+
+			Convey("it returns a non-nil error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
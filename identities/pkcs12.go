@@ -0,0 +1,57 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package identities
+
+import (
+	"crypto"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// FromPKCS12 returns a new identity from the leaf certificate, private key and certificate chain held in a password
+// protected PKCS #12 archive or an error.
+func FromPKCS12(data []byte, password string) (*Identity, error) {
+
+	key, certificate, authorities, err := pkcs12.DecodeChain(data, password)
+	if err != nil {
+		return nil, errors.Wrap(err, "error decoding pkcs12 archive")
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type [%T] in pkcs12 archive", key)
+	}
+
+	return NewIdentity(authorities, certificate, signer), nil
+}
+
+// ToPKCS12 returns the leaf certificate, private key and certificate chain (i.e., the authorities) of an identity as a
+// password protected PKCS #12 archive or an error. The archive is encrypted using AES-256-CBC with PBKDF2 and is
+// readable by OpenSSL 1.1.1 and Java 12 or later.
+func (i *Identity) ToPKCS12(password string) ([]byte, error) {
+
+	if i.Certificate == nil {
+		return nil, errors.New("error encoding pkcs12 archive without a certificate")
+	}
+
+	data, err := pkcs12.Modern2023.Encode(i.Key, i.Certificate, i.Authorities, password)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error encoding pkcs12 archive for [%s]", i.Certificate.Subject.CommonName)
+	}
+
+	return data, nil
+}
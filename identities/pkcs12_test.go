@@ -0,0 +1,117 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package identities
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPKCS12(t *testing.T) {
+
+	Convey("When Identity", t, func() {
+
+		root, _ := Self(Template{
+			BasicConstraintsValid: true,
+			IsCA:                  true,
+			KeyAlgorithm:          ECDSA,
+			KeyUsage:              x509.KeyUsageCRLSign | x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+			NotAfter:              time.Now().AddDate(10, 0, 0),
+			NotBefore:             time.Now(),
+			SerialNumber:          big.NewInt(time.Now().Unix()),
+			Subject:               pkix.Name{CommonName: "NauTLS (Root)"},
+		})
+
+		identity, _ := root.Issue(Template{
+			DNSNames:     []string{"nautls.com"},
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+			KeyAlgorithm: ECDSA,
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			NotAfter:     time.Now().AddDate(10, 0, 0),
+			NotBefore:    time.Now(),
+			SerialNumber: big.NewInt(time.Now().Unix()),
+			Subject:      pkix.Name{CommonName: "nautls.com"},
+		})
+
+		Convey(".ToPKCS12 is invoked", func() {
+
+			data, err := identity.ToPKCS12("nautls")
+
+			Convey("it returns a nil error", func() {
+				So(err, ShouldBeNil)
+			})
+
+			Convey("it returns an archive", func() {
+				So(data, ShouldNotBeEmpty)
+			})
+
+			Convey("and FromPKCS12 is invoked", func() {
+
+				Convey("with the correct password", func() {
+
+					actual, err := FromPKCS12(data, "nautls")
+
+					Convey("it returns a nil error", func() {
+						So(err, ShouldBeNil)
+					})
+
+					Convey("it sets the certificate", func() {
+						So(actual.Certificate.Equal(identity.Certificate), ShouldBeTrue)
+					})
+
+					Convey("it sets the authorities", func() {
+						So(actual.Authorities, ShouldHaveLength, 1)
+						So(actual.Authorities[0].Equal(root.Certificate), ShouldBeTrue)
+					})
+
+					Convey("it sets the key", func() {
+						So(actual.Key.Public(), ShouldResemble, identity.Key.Public())
+					})
+				})
+
+				Convey("with an incorrect password", func() {
+
+					actual, err := FromPKCS12(data, "invalid")
+
+					Convey("it returns a non-nil error", func() {
+						So(err, ShouldNotBeNil)
+					})
+
+					Convey("it returns a nil identity", func() {
+						So(actual, ShouldBeNil)
+					})
+				})
+			})
+		})
+
+		Convey(".ToPKCS12 is invoked without a certificate", func() {
+
+			data, err := (&Identity{Key: identity.Key}).ToPKCS12("nautls")
+
+			Convey("it returns a non-nil error", func() {
+				So(err, ShouldNotBeNil)
+			})
+
+			Convey("it returns a nil archive", func() {
+				So(data, ShouldBeNil)
+			})
+		})
+	})
+}
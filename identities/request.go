@@ -0,0 +1,128 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package identities
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"net"
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+// RequestPolicy defines which values of a certificate signing request are copied into a certificate issued by
+// IssueFromCSR. Note that requested values are appended to those defined by the issuer's template and that nothing is
+// copied unless the policy allows it.
+type RequestPolicy struct {
+
+	// Authorize is invoked with the verified request before issuance and may reject it by returning an error.
+	Authorize func(request *x509.CertificateRequest) error
+
+	// DNSNames allows the requested DNS subject alternative names.
+	DNSNames bool
+
+	// EmailAddresses allows the requested email subject alternative names.
+	EmailAddresses bool
+
+	// Extensions defines the object identifiers of requested extensions that are copied verbatim.
+	Extensions []asn1.ObjectIdentifier
+
+	// IPAddresses allows the requested IP subject alternative names.
+	IPAddresses bool
+
+	// Subject allows the requested subject to replace the subject of the template.
+	Subject bool
+
+	// URIs allows the requested URI subject alternative names.
+	URIs bool
+}
+
+// CertificateRequest returns a certificate signing request for the subject, subject alternative names and extra
+// extensions of a template signed by a caller owned private key.
+func CertificateRequest(template Template, key crypto.Signer) (*x509.CertificateRequest, error) {
+
+	bytes, err := x509.CreateCertificateRequest(rand.Reader, template.request(), key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error signing certificate request for [%s]", template.Subject.CommonName)
+	}
+
+	request, err := x509.ParseCertificateRequest(bytes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error parsing certificate request for [%s]", template.Subject.CommonName)
+	}
+
+	return request, nil
+}
+
+// IssueFromCSR returns a new identity signed by this identity for the public key of a certificate signing request. The
+// certificate is based upon a template with the values of the request allowed by the policy copied into it. Note that
+// the returned identity has a nil key as the private key remains with the requester and that the template is not
+// modified.
+func (i *Identity) IssueFromCSR(request *x509.CertificateRequest, template Template, policy RequestPolicy) (*Identity, error) {
+
+	err := request.CheckSignature()
+	if err != nil {
+		return nil, errors.Wrapf(err, "error verifying certificate request for [%s]", request.Subject.CommonName)
+	}
+
+	if policy.Authorize != nil {
+
+		err = policy.Authorize(request)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error authorizing certificate request for [%s]", request.Subject.CommonName)
+		}
+	}
+
+	if policy.Subject {
+		template.Subject = request.Subject
+	}
+
+	if policy.DNSNames {
+		template.DNSNames = append(append([]string{}, template.DNSNames...), request.DNSNames...)
+	}
+
+	if policy.EmailAddresses {
+		template.EmailAddresses = append(append([]string{}, template.EmailAddresses...), request.EmailAddresses...)
+	}
+
+	if policy.IPAddresses {
+		template.IPAddresses = append(append([]net.IP{}, template.IPAddresses...), request.IPAddresses...)
+	}
+
+	if policy.URIs {
+		template.URIs = append(append([]*url.URL{}, template.URIs...), request.URIs...)
+	}
+
+	template.ExtraExtensions = append([]pkix.Extension{}, template.ExtraExtensions...)
+
+	for _, extension := range request.Extensions {
+		for _, allowed := range policy.Extensions {
+			if extension.Id.Equal(allowed) {
+				template.ExtraExtensions = append(template.ExtraExtensions, extension)
+			}
+		}
+	}
+
+	certificate, err := sign(template.certificate(), i.Certificate, request.PublicKey, i.Key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error signing certificate for [%s]", template.Subject.CommonName)
+	}
+
+	return NewIdentity(append([]*x509.Certificate{i.Certificate}, i.Authorities...), certificate, nil), nil
+}
@@ -0,0 +1,229 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package identities
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCertificateRequest(t *testing.T) {
+
+	Convey("When CertificateRequest", t, func() {
+
+		key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		spiffe, _ := url.Parse("spiffe://nautls.com/server")
+		oid := asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 99999, 1}
+
+		Convey("is invoked with a template and key", func() {
+
+			request, err := CertificateRequest(Template{
+				DNSNames:        []string{"nautls.com"},
+				EmailAddresses:  []string{"nautls@nautls.com"},
+				ExtraExtensions: []pkix.Extension{{Id: oid, Value: []byte{0x05, 0x00}}},
+				IPAddresses:     []net.IP{net.ParseIP("127.0.0.1")},
+				Subject:         pkix.Name{CommonName: "nautls.com"},
+				URIs:            []*url.URL{spiffe},
+			}, key)
+
+			Convey("it returns a nil error", func() {
+				So(err, ShouldBeNil)
+			})
+
+			Convey("it returns a signed request", func() {
+				So(request.CheckSignature(), ShouldBeNil)
+				So(request.PublicKey, ShouldResemble, key.Public())
+			})
+
+			Convey("it sets the requested values", func() {
+				So(request.Subject.CommonName, ShouldEqual, "nautls.com")
+				So(request.DNSNames, ShouldResemble, []string{"nautls.com"})
+				So(request.EmailAddresses, ShouldResemble, []string{"nautls@nautls.com"})
+				So(request.IPAddresses[0].Equal(net.ParseIP("127.0.0.1")), ShouldBeTrue)
+				So(request.URIs[0].String(), ShouldEqual, spiffe.String())
+			})
+
+			Convey("and .IssueFromCSR is invoked", func() {
+
+				authority, _ := Self(Template{
+					BasicConstraintsValid: true,
+					IsCA:                  true,
+					KeyAlgorithm:          ECDSA,
+					KeyUsage:              x509.KeyUsageCRLSign | x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+					NotAfter:              time.Now().AddDate(10, 0, 0),
+					NotBefore:             time.Now(),
+					SerialNumber:          big.NewInt(time.Now().Unix()),
+					Subject:               pkix.Name{CommonName: "NauTLS (Authority)"},
+				})
+
+				template := Template{
+					ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+					KeyUsage:     x509.KeyUsageDigitalSignature,
+					NotAfter:     time.Now().AddDate(1, 0, 0),
+					NotBefore:    time.Now(),
+					SerialNumber: big.NewInt(time.Now().Unix()),
+					Subject:      pkix.Name{CommonName: "template"},
+				}
+
+				Convey("with a permissive policy", func() {
+
+					identity, err := authority.IssueFromCSR(request, template, RequestPolicy{
+						DNSNames:       true,
+						EmailAddresses: true,
+						Extensions:     []asn1.ObjectIdentifier{oid},
+						IPAddresses:    true,
+						Subject:        true,
+						URIs:           true,
+					})
+
+					Convey("it returns a nil error", func() {
+						So(err, ShouldBeNil)
+					})
+
+					Convey("it certifies the requested public key", func() {
+						So(identity.Certificate.PublicKey, ShouldResemble, key.Public())
+						So(identity.Key, ShouldBeNil)
+					})
+
+					Convey("it copies the requested values", func() {
+						So(identity.Certificate.Subject.CommonName, ShouldEqual, "nautls.com")
+						So(identity.Certificate.DNSNames, ShouldResemble, []string{"nautls.com"})
+						So(identity.Certificate.EmailAddresses, ShouldResemble, []string{"nautls@nautls.com"})
+						So(identity.Certificate.IPAddresses[0].Equal(net.ParseIP("127.0.0.1")), ShouldBeTrue)
+						So(identity.Certificate.URIs[0].String(), ShouldEqual, spiffe.String())
+					})
+
+					Convey("it copies the allowed extensions", func() {
+						found := false
+						for _, extension := range identity.Certificate.Extensions {
+							found = found || extension.Id.Equal(oid)
+						}
+						So(found, ShouldBeTrue)
+					})
+
+					Convey("it returns a certificate verifiable by the authority", func() {
+						So(identity.Certificate.CheckSignatureFrom(authority.Certificate), ShouldBeNil)
+						So(identity.Authorities, ShouldHaveLength, 1)
+						So(identity.Authorities[0].Equal(authority.Certificate), ShouldBeTrue)
+					})
+				})
+
+				Convey("with a restrictive policy", func() {
+
+					identity, err := authority.IssueFromCSR(request, template, RequestPolicy{DNSNames: true})
+
+					Convey("it returns a nil error", func() {
+						So(err, ShouldBeNil)
+					})
+
+					Convey("it copies only the allowed values", func() {
+						So(identity.Certificate.Subject.CommonName, ShouldEqual, "template")
+						So(identity.Certificate.DNSNames, ShouldResemble, []string{"nautls.com"})
+						So(identity.Certificate.EmailAddresses, ShouldBeEmpty)
+						So(identity.Certificate.IPAddresses, ShouldBeEmpty)
+						So(identity.Certificate.URIs, ShouldBeEmpty)
+					})
+
+					Convey("it does not copy other extensions", func() {
+						for _, extension := range identity.Certificate.Extensions {
+							So(extension.Id.Equal(oid), ShouldBeFalse)
+						}
+					})
+				})
+
+				Convey("with a template whose values have spare capacity", func() {
+
+					names := append(make([]string, 0, 4), "template.nautls.com")
+					emails := append(make([]string, 0, 4), "template@nautls.com")
+					addresses := append(make([]net.IP, 0, 4), net.ParseIP("127.0.0.2"))
+					uris := append(make([]*url.URL, 0, 4), spiffe)
+					extensions := append(make([]pkix.Extension, 0, 4), pkix.Extension{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 99999, 2}, Value: []byte{0x05, 0x00}})
+
+					template.DNSNames = names
+					template.EmailAddresses = emails
+					template.ExtraExtensions = extensions
+					template.IPAddresses = addresses
+					template.URIs = uris
+
+					_, err := authority.IssueFromCSR(request, template, RequestPolicy{
+						DNSNames:       true,
+						EmailAddresses: true,
+						Extensions:     []asn1.ObjectIdentifier{oid},
+						IPAddresses:    true,
+						URIs:           true,
+					})
+
+					Convey("it returns a nil error", func() {
+						So(err, ShouldBeNil)
+					})
+
+					Convey("it does not modify the values of the template", func() {
+						So(names[:cap(names)], ShouldResemble, []string{"template.nautls.com", "", "", ""})
+						So(emails[:cap(emails)], ShouldResemble, []string{"template@nautls.com", "", "", ""})
+						So(addresses[:cap(addresses)][1:], ShouldResemble, []net.IP{nil, nil, nil})
+						So(uris[:cap(uris)][1:], ShouldResemble, []*url.URL{nil, nil, nil})
+						So(extensions[:cap(extensions)][1:], ShouldResemble, []pkix.Extension{{}, {}, {}})
+					})
+				})
+
+				Convey("with a rejecting policy", func() {
+
+					identity, err := authority.IssueFromCSR(request, template, RequestPolicy{
+						Authorize: func(request *x509.CertificateRequest) error {
+							return errors.New("rejected")
+						},
+					})
+
+					Convey("it returns a non-nil error", func() {
+						So(err, ShouldNotBeNil)
+					})
+
+					Convey("it returns a nil identity", func() {
+						So(identity, ShouldBeNil)
+					})
+				})
+
+				Convey("with a tampered request", func() {
+
+					tampered := *request
+					tampered.Signature = append([]byte{}, request.Signature...)
+					tampered.Signature[len(tampered.Signature)-1] ^= 0xff
+
+					identity, err := authority.IssueFromCSR(&tampered, template, RequestPolicy{})
+
+					Convey("it returns a non-nil error", func() {
+						So(err, ShouldNotBeNil)
+					})
+
+					Convey("it returns a nil identity", func() {
+						So(identity, ShouldBeNil)
+					})
+				})
+			})
+		})
+	})
+}
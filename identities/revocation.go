@@ -0,0 +1,184 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package identities
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RevocationReason defines the reason a certificate was revoked as defined by RFC 5280 section 5.3.1.
+type RevocationReason int
+
+const (
+	// Unspecified defines a revocation without a specific reason.
+	Unspecified RevocationReason = 0
+
+	// KeyCompromise defines a revocation due to the compromise of the subject's private key.
+	KeyCompromise RevocationReason = 1
+
+	// CACompromise defines a revocation due to the compromise of the issuer's private key.
+	CACompromise RevocationReason = 2
+
+	// AffiliationChanged defines a revocation due to a change to the subject's name or affiliation.
+	AffiliationChanged RevocationReason = 3
+
+	// Superseded defines a revocation due to the certificate being replaced.
+	Superseded RevocationReason = 4
+
+	// CessationOfOperation defines a revocation due to the certificate no longer being needed.
+	CessationOfOperation RevocationReason = 5
+
+	// CertificateHold defines a temporary revocation.
+	CertificateHold RevocationReason = 6
+
+	// RemoveFromCRL defines the release of a certificate hold in a delta CRL.
+	RemoveFromCRL RevocationReason = 8
+
+	// PrivilegeWithdrawn defines a revocation due to the withdrawal of a privilege asserted by the certificate.
+	PrivilegeWithdrawn RevocationReason = 9
+
+	// AACompromise defines a revocation due to the compromise of an attribute authority.
+	AACompromise RevocationReason = 10
+)
+
+// Revocation represents the revocation of a certificate issued by an identity.
+type Revocation struct {
+	Reason       RevocationReason
+	RevokedAt    time.Time
+	SerialNumber *big.Int
+}
+
+// Revocations tracks the certificates revoked by an identity. Note that it is safe for concurrent use (e.g., revoking
+// certificates while an OCSP responder reads their status).
+type Revocations struct {
+	mutex       sync.RWMutex
+	revocations []Revocation
+}
+
+// Revoke records the revocation of a certificate by serial number. Note that revoking a serial number again replaces
+// the reason and time of the existing revocation.
+func (r *Revocations) Revoke(serialNumber *big.Int, reason RevocationReason, revokedAt time.Time) {
+
+	revocation := Revocation{Reason: reason, RevokedAt: revokedAt, SerialNumber: serialNumber}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for index, existing := range r.revocations {
+		if existing.SerialNumber.Cmp(serialNumber) == 0 {
+			r.revocations[index] = revocation
+			return
+		}
+	}
+
+	r.revocations = append(r.revocations, revocation)
+}
+
+// Revocation returns the revocation of a certificate by serial number and whether it has been revoked.
+func (r *Revocations) Revocation(serialNumber *big.Int) (Revocation, bool) {
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, existing := range r.revocations {
+		if existing.SerialNumber.Cmp(serialNumber) == 0 {
+			return existing, true
+		}
+	}
+
+	return Revocation{}, false
+}
+
+// Revoked returns true if a certificate has been revoked by serial number.
+func (r *Revocations) Revoked(serialNumber *big.Int) bool {
+
+	_, revoked := r.Revocation(serialNumber)
+
+	return revoked
+}
+
+// List returns a copy of the revocations in the order the certificates were first revoked (e.g., for the revocations of
+// a RevocationListTemplate).
+func (r *Revocations) List() []Revocation {
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return append([]Revocation{}, r.revocations...)
+}
+
+// RevocationListTemplate defines the values of a certificate revocation list.
+type RevocationListTemplate struct {
+
+	// NextUpdate defines the time by which the next revocation list will be issued.
+	NextUpdate time.Time
+
+	// Number defines the monotonically increasing sequence number of the revocation list. It defaults to the this update
+	// in nanoseconds since the Unix epoch so that the numbers of successive revocation lists increase.
+	Number *big.Int
+
+	// Revocations defines the revoked certificates.
+	Revocations []Revocation
+
+	// ThisUpdate defines the time at which the revocation list was issued. It defaults to the current time.
+	ThisUpdate time.Time
+}
+
+// RevocationList returns a certificate revocation list signed by this identity based upon a template. Note that the
+// certificate of the identity must allow the CRL signing key usage.
+func (i *Identity) RevocationList(template RevocationListTemplate) (*x509.RevocationList, error) {
+
+	thisUpdate := template.ThisUpdate
+	if thisUpdate.IsZero() {
+		thisUpdate = time.Now()
+	}
+
+	number := template.Number
+	if number == nil {
+		number = big.NewInt(thisUpdate.UnixNano())
+	}
+
+	entries := make([]x509.RevocationListEntry, len(template.Revocations))
+	for index, revocation := range template.Revocations {
+		entries[index] = x509.RevocationListEntry{
+			ReasonCode:     int(revocation.Reason),
+			RevocationTime: revocation.RevokedAt,
+			SerialNumber:   revocation.SerialNumber,
+		}
+	}
+
+	bytes, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		NextUpdate:                template.NextUpdate,
+		Number:                    number,
+		RevokedCertificateEntries: entries,
+		ThisUpdate:                thisUpdate,
+	}, i.Certificate, i.Key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error signing revocation list for [%s]", i.Certificate.Subject.CommonName)
+	}
+
+	list, err := x509.ParseRevocationList(bytes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error parsing revocation list for [%s]", i.Certificate.Subject.CommonName)
+	}
+
+	return list, nil
+}
@@ -0,0 +1,147 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package identities
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRevocations(t *testing.T) {
+
+	Convey("When Revocations", t, func() {
+
+		revocations := &Revocations{}
+		revokedAt := time.Now().Truncate(time.Second).UTC()
+
+		Convey(".Revoke is invoked", func() {
+
+			revocations.Revoke(big.NewInt(1), KeyCompromise, revokedAt)
+
+			Convey("it records the revocation", func() {
+				So(revocations.List(), ShouldHaveLength, 1)
+				So(revocations.Revoked(big.NewInt(1)), ShouldBeTrue)
+				So(revocations.Revoked(big.NewInt(2)), ShouldBeFalse)
+			})
+
+			Convey("and .Revoke is invoked again for the same serial number", func() {
+
+				revocations.Revoke(big.NewInt(1), Superseded, revokedAt)
+
+				Convey("it replaces the revocation", func() {
+					So(revocations.List(), ShouldHaveLength, 1)
+					So(revocations.List()[0].Reason, ShouldEqual, Superseded)
+				})
+			})
+		})
+	})
+}
+
+func TestRevocationList(t *testing.T) {
+
+	Convey("When Identity", t, func() {
+
+		authority, _ := Self(Template{
+			BasicConstraintsValid: true,
+			IsCA:                  true,
+			KeyAlgorithm:          ECDSA,
+			KeyUsage:              x509.KeyUsageCRLSign | x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+			NotAfter:              time.Now().AddDate(10, 0, 0),
+			NotBefore:             time.Now(),
+			SerialNumber:          big.NewInt(time.Now().Unix()),
+			Subject:               pkix.Name{CommonName: "NauTLS (Authority)"},
+		})
+
+		revokedAt := time.Now().Add(-time.Hour).Truncate(time.Second).UTC()
+		nextUpdate := time.Now().Add(24 * time.Hour).Truncate(time.Second).UTC()
+
+		revocations := &Revocations{}
+		revocations.Revoke(big.NewInt(1), KeyCompromise, revokedAt)
+		revocations.Revoke(big.NewInt(2), CessationOfOperation, revokedAt)
+
+		Convey(".RevocationList is invoked", func() {
+
+			list, err := authority.RevocationList(RevocationListTemplate{
+				NextUpdate:  nextUpdate,
+				Number:      big.NewInt(7),
+				Revocations: revocations.List(),
+			})
+
+			Convey("it returns a nil error", func() {
+				So(err, ShouldBeNil)
+			})
+
+			Convey("it returns a list signed by the identity", func() {
+				So(list.CheckSignatureFrom(authority.Certificate), ShouldBeNil)
+			})
+
+			Convey("it sets the number and next update", func() {
+				So(list.Number.Cmp(big.NewInt(7)), ShouldEqual, 0)
+				So(list.NextUpdate.Equal(nextUpdate), ShouldBeTrue)
+			})
+
+			Convey("it sets the revoked certificates", func() {
+				So(list.RevokedCertificateEntries, ShouldHaveLength, 2)
+				So(list.RevokedCertificateEntries[0].SerialNumber.Cmp(big.NewInt(1)), ShouldEqual, 0)
+				So(list.RevokedCertificateEntries[0].ReasonCode, ShouldEqual, int(KeyCompromise))
+				So(list.RevokedCertificateEntries[0].RevocationTime.Equal(revokedAt), ShouldBeTrue)
+				So(list.RevokedCertificateEntries[1].ReasonCode, ShouldEqual, int(CessationOfOperation))
+			})
+		})
+
+		Convey(".RevocationList is invoked without a number", func() {
+
+			thisUpdate := time.Now().Add(-time.Minute)
+
+			first, err := authority.RevocationList(RevocationListTemplate{NextUpdate: nextUpdate, ThisUpdate: thisUpdate})
+			So(err, ShouldBeNil)
+
+			second, err := authority.RevocationList(RevocationListTemplate{NextUpdate: nextUpdate})
+			So(err, ShouldBeNil)
+
+			Convey("it derives the number from the this update", func() {
+				So(first.Number.Cmp(big.NewInt(thisUpdate.UnixNano())), ShouldEqual, 0)
+			})
+
+			Convey("it derives increasing numbers", func() {
+				So(second.Number.Cmp(first.Number), ShouldEqual, 1)
+			})
+		})
+
+		Convey(".RevocationList is invoked by an identity without the CRL signing key usage", func() {
+
+			leaf, _ := authority.Issue(Template{
+				DNSNames:     []string{"nautls.com"},
+				KeyAlgorithm: ECDSA,
+				KeyUsage:     x509.KeyUsageDigitalSignature,
+				NotAfter:     time.Now().AddDate(1, 0, 0),
+				NotBefore:    time.Now(),
+				SerialNumber: big.NewInt(time.Now().Unix()),
+				Subject:      pkix.Name{CommonName: "nautls.com"},
+			})
+
+			_, err := leaf.RevocationList(RevocationListTemplate{NextUpdate: nextUpdate, Number: big.NewInt(1)})
+
+			Convey("it returns a non-nil error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
@@ -0,0 +1,94 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package identities
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"net/url"
+	"time"
+
+	"github.com/greymatter-io/nautls/spiffe"
+	"github.com/pkg/errors"
+)
+
+// SVIDTemplate returns a template for an X.509-SVID leaf certificate holding a SPIFFE ID (e.g.,
+// "spiffe://example.org/ns/production/sa/web") as its only URI subject alternative name and valid for a lifetime. The
+// certificate may be used for both server and client authentication and its serial number is random. This is most
+// applicable to tests requiring SVID shaped certificates without a SPIFFE implementation.
+func SVIDTemplate(id string, lifetime time.Duration) (Template, error) {
+
+	parsed, err := spiffe.ParseID(id)
+	if err != nil {
+		return Template{}, errors.Wrap(err, "error parsing svid id")
+	}
+
+	if parsed.Path == "" {
+		return Template{}, errors.New(fmt.Sprintf("error creating svid template for id without a path [%s]", id))
+	}
+
+	serialNumber, err := randomSerialNumber()
+	if err != nil {
+		return Template{}, errors.Wrap(err, "error generating svid serial number")
+	}
+
+	return Template{
+		BasicConstraintsValid: true,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		KeyAlgorithm:          ECDSA,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageKeyAgreement,
+		NotAfter:              time.Now().Add(lifetime),
+		NotBefore:             time.Now().Add(-time.Minute),
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{CommonName: parsed.Path[1:]},
+		URIs:                  []*url.URL{parsed.URL()},
+	}, nil
+}
+
+// SVIDAuthorityTemplate returns a template for a self signed or intermediate X.509-SVID signing certificate of a trust
+// domain (e.g., "example.org") valid for a lifetime. The certificate holds the ID of the trust domain as its only URI
+// subject alternative name and its serial number is random.
+func SVIDAuthorityTemplate(trustDomain string, lifetime time.Duration) (Template, error) {
+
+	parsed, err := spiffe.ParseID(fmt.Sprintf("%s://%s", spiffe.Scheme, trustDomain))
+	if err != nil {
+		return Template{}, errors.Wrap(err, "error parsing svid trust domain")
+	}
+
+	serialNumber, err := randomSerialNumber()
+	if err != nil {
+		return Template{}, errors.Wrap(err, "error generating svid authority serial number")
+	}
+
+	return Template{
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		KeyAlgorithm:          ECDSA,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		NotAfter:              time.Now().Add(lifetime),
+		NotBefore:             time.Now().Add(-time.Minute),
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{CommonName: trustDomain},
+		URIs:                  []*url.URL{parsed.URL()},
+	}, nil
+}
+
+// randomSerialNumber returns a random positive 128 bit serial number.
+func randomSerialNumber() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
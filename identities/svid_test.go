@@ -0,0 +1,90 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package identities
+
+import (
+	"crypto/x509"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSVIDTemplate(t *testing.T) {
+
+	Convey("When #SVIDAuthorityTemplate is invoked", t, func() {
+
+		template, err := SVIDAuthorityTemplate("example.org", time.Hour)
+
+		Convey("it returns a certificate authority template for the trust domain", func() {
+			So(err, ShouldBeNil)
+			So(template.IsCA, ShouldBeTrue)
+			So(template.URIs, ShouldHaveLength, 1)
+			So(template.URIs[0].String(), ShouldEqual, "spiffe://example.org")
+		})
+
+		Convey("and #SVIDTemplate is invoked", func() {
+
+			authority, err := Self(template)
+			So(err, ShouldBeNil)
+
+			leaf, err := SVIDTemplate("spiffe://example.org/ns/production/sa/web", time.Hour)
+			So(err, ShouldBeNil)
+
+			identity, err := authority.Issue(leaf)
+
+			Convey("it issues an svid verifiable by the authority", func() {
+
+				So(err, ShouldBeNil)
+
+				roots := x509.NewCertPool()
+				roots.AddCert(authority.Certificate)
+
+				_, err := identity.Certificate.Verify(x509.VerifyOptions{
+					KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+					Roots:     roots,
+				})
+				So(err, ShouldBeNil)
+			})
+
+			Convey("it holds the spiffe id as its only uri", func() {
+				So(identity.Certificate.URIs, ShouldHaveLength, 1)
+				So(identity.Certificate.URIs[0].String(), ShouldEqual, "spiffe://example.org/ns/production/sa/web")
+				So(identity.Certificate.IsCA, ShouldBeFalse)
+			})
+		})
+	})
+
+	Convey("When #SVIDTemplate is invoked", t, func() {
+
+		Convey("with an id without a path", func() {
+
+			_, err := SVIDTemplate("spiffe://example.org", time.Hour)
+
+			Convey("it returns a non-nil error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+
+		Convey("with an invalid id", func() {
+
+			_, err := SVIDTemplate("https://example.org/web", time.Hour)
+
+			Convey("it returns a non-nil error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
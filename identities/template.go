@@ -22,8 +22,11 @@ type Template struct {
 	ExcludedURIDomains          []string
 	ExtKeyUsage                 []x509.ExtKeyUsage
 	ExtraExtensions             []pkix.Extension
+	IPAddresses                 []net.IP
 	IsCA                        bool
 	IssuingCertificateURL       []string
+	KeyAlgorithm                KeyAlgorithm
+	KeySize                     int
 	KeyUsage                    x509.KeyUsage
 	MaxPathLen                  int
 	MaxPathLenZero              bool
@@ -57,6 +60,7 @@ func (t *Template) certificate() *x509.Certificate {
 		ExcludedURIDomains:          t.ExcludedURIDomains,
 		ExtKeyUsage:                 t.ExtKeyUsage,
 		ExtraExtensions:             t.ExtraExtensions,
+		IPAddresses:                 t.IPAddresses,
 		IsCA:                        t.IsCA,
 		IssuingCertificateURL:       t.IssuingCertificateURL,
 		KeyUsage:                    t.KeyUsage,
@@ -79,3 +83,15 @@ func (t *Template) certificate() *x509.Certificate {
 		UnknownExtKeyUsage:          t.UnknownExtKeyUsage,
 	}
 }
+
+func (t *Template) request() *x509.CertificateRequest {
+	return &x509.CertificateRequest{
+		DNSNames:           t.DNSNames,
+		EmailAddresses:     t.EmailAddresses,
+		ExtraExtensions:    t.ExtraExtensions,
+		IPAddresses:        t.IPAddresses,
+		SignatureAlgorithm: t.SignatureAlgorithm,
+		Subject:            t.Subject,
+		URIs:               t.URIs,
+	}
+}
@@ -1,5 +1,7 @@
 {
 	"authorities": "{{ .authorities }}",
 	"certificate": "{{ .certificate }}",
-	"key": "{{ .key }}"
+	"key": "{{ .key }}",
+	"passphrase": "{{ .passphrase }}",
+	"pkcs12": "{{ .pkcs12 }}"
 }
@@ -1,3 +1,5 @@
 authorities: "{{ .authorities }}"
 certificate: "{{ .certificate }}"
 key: "{{ .key }}"
+passphrase: "{{ .passphrase }}"
+pkcs12: "{{ .pkcs12 }}"
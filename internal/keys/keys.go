@@ -0,0 +1,116 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+
+	"github.com/greymatter-io/nautls/internal/urls"
+	"github.com/pkg/errors"
+	"github.com/youmark/pkcs8"
+)
+
+const (
+	// EncryptedPKCS8Block defines the PEM block type for encrypted PKCS #8 private keys.
+	EncryptedPKCS8Block = "ENCRYPTED PRIVATE KEY"
+
+	// PKCS8Block defines the PEM block type for unencrypted PKCS #8 private keys.
+	PKCS8Block = "PRIVATE KEY"
+)
+
+// Decrypt returns PEM encoded data with every encrypted private key block decrypted using the passphrase. Legacy
+// blocks (i.e., those with a "Proc-Type: 4,ENCRYPTED" header) retain their block type while encrypted PKCS #8 blocks
+// are returned as "PRIVATE KEY" blocks. Note that other blocks are returned unmodified while values outside a PEM block
+// are dropped.
+func Decrypt(data []byte, passphrase []byte) ([]byte, error) {
+
+	var buffer bytes.Buffer
+
+	decoded, tail := pem.Decode(data)
+	for decoded != nil {
+
+		block, err := DecryptBlock(decoded, passphrase)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error decrypting [%s] block", decoded.Type)
+		}
+
+		err = pem.Encode(&buffer, block)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error encoding [%s] block", block.Type)
+		}
+
+		decoded, tail = pem.Decode(tail)
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// DecryptBlock returns a decrypted copy of a PEM block holding an encrypted private key or the block itself if it is
+// not encrypted.
+func DecryptBlock(block *pem.Block, passphrase []byte) (*pem.Block, error) {
+
+	if !IsEncrypted(block) {
+		return block, nil
+	}
+
+	if len(passphrase) == 0 {
+		return nil, errors.New("encrypted private key requires a passphrase")
+	}
+
+	if block.Type == EncryptedPKCS8Block {
+
+		key, err := pkcs8.ParsePKCS8PrivateKey(block.Bytes, passphrase)
+		if err != nil {
+			return nil, errors.Wrap(err, "error decrypting pkcs8 private key")
+		}
+
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, errors.Wrap(err, "error marshalling pkcs8 private key")
+		}
+
+		return &pem.Block{Type: PKCS8Block, Bytes: der}, nil
+	}
+
+	der, err := x509.DecryptPEMBlock(block, passphrase)
+	if err != nil {
+		return nil, errors.Wrap(err, "error decrypting legacy private key")
+	}
+
+	return &pem.Block{Type: block.Type, Bytes: der}, nil
+}
+
+// IsEncrypted returns true if a PEM block holds an encrypted private key.
+func IsEncrypted(block *pem.Block) bool {
+	return block.Type == EncryptedPKCS8Block || x509.IsEncryptedPEMBlock(block)
+}
+
+// ReadPassphrase reads a passphrase from a resource URL. Note that an empty resource returns a nil passphrase and that
+// trailing line breaks (e.g., from files written by echo) are removed.
+func ReadPassphrase(resource string) ([]byte, error) {
+
+	if resource == "" {
+		return nil, nil
+	}
+
+	passphrase, err := urls.ReadFile(resource)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading passphrase from [%s]", resource)
+	}
+
+	return bytes.TrimRight(passphrase, "\r\n"), nil
+}
@@ -0,0 +1,131 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/youmark/pkcs8"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestKeys(t *testing.T) {
+
+	Convey("When keys", t, func() {
+
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatalf("error generating private key: %s", err)
+		}
+
+		der, err := pkcs8.MarshalPrivateKey(key, []byte("nautls"), nil)
+		if err != nil {
+			t.Fatalf("error encrypting private key: %s", err)
+		}
+
+		encrypted := pem.EncodeToMemory(&pem.Block{Type: EncryptedPKCS8Block, Bytes: der})
+
+		Convey(".Decrypt is invoked", func() {
+
+			Convey("with the correct passphrase", func() {
+
+				decrypted, err := Decrypt(encrypted, []byte("nautls"))
+				block, _ := pem.Decode(decrypted)
+
+				Convey("it returns a nil error", func() {
+					So(err, ShouldBeNil)
+				})
+
+				Convey("it returns an unencrypted pkcs8 block", func() {
+					So(block.Type, ShouldEqual, PKCS8Block)
+					parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+					So(err, ShouldBeNil)
+					So(key.Equal(parsed), ShouldBeTrue)
+				})
+			})
+
+			Convey("with an incorrect passphrase", func() {
+
+				decrypted, err := Decrypt(encrypted, []byte("invalid"))
+
+				Convey("it returns a non-nil error", func() {
+					So(err, ShouldNotBeNil)
+				})
+
+				Convey("it returns nil bytes", func() {
+					So(decrypted, ShouldBeNil)
+				})
+			})
+
+			Convey("with no passphrase", func() {
+
+				_, err := Decrypt(encrypted, nil)
+
+				Convey("it returns a non-nil error", func() {
+					So(err, ShouldNotBeNil)
+				})
+			})
+
+			Convey("with an unencrypted block", func() {
+
+				unencrypted := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("certificate")})
+				decrypted, err := Decrypt(unencrypted, nil)
+
+				Convey("it returns a nil error", func() {
+					So(err, ShouldBeNil)
+				})
+
+				Convey("it returns the block unmodified", func() {
+					So(decrypted, ShouldResemble, unencrypted)
+				})
+			})
+		})
+
+		Convey(".ReadPassphrase is invoked", func() {
+
+			Convey("with an empty resource", func() {
+
+				passphrase, err := ReadPassphrase("")
+
+				Convey("it returns a nil error", func() {
+					So(err, ShouldBeNil)
+				})
+
+				Convey("it returns a nil passphrase", func() {
+					So(passphrase, ShouldBeNil)
+				})
+			})
+
+			Convey("with a trailing line break", func() {
+
+				passphrase, err := ReadPassphrase("base64:///bmF1dGxzCg==")
+
+				Convey("it returns a nil error", func() {
+					So(err, ShouldBeNil)
+				})
+
+				Convey("it returns the trimmed passphrase", func() {
+					So(string(passphrase), ShouldEqual, "nautls")
+				})
+			})
+		})
+	})
+}
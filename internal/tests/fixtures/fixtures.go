@@ -0,0 +1,125 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fixtures provides certificate authorities and certificates for tests. Note that it is separate from the tests
+// package as it depends on the identities and encoding packages whose own tests depend on the tests package.
+package fixtures
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/greymatter-io/nautls/encoding"
+	"github.com/greymatter-io/nautls/identities"
+)
+
+// Base64Resource returns a base64 scheme URL for data. Note that slashes are escaped as the getter of a resource treats
+// a double slash as the separator of a subdirectory.
+func Base64Resource(data []byte) string {
+	return fmt.Sprintf("base64:///%s", strings.ReplaceAll(base64.StdEncoding.EncodeToString(data), "/", "%2F"))
+}
+
+// Resources returns the PEM encoded certificate and key of an identity as base64 scheme URLs.
+func Resources(identity *identities.Identity) (string, string) {
+	return Base64Resource(encoding.PEMEncodeCertificate(identity.Certificate)), Base64Resource(encoding.PEMEncodeKey(identity.Key))
+}
+
+// Authorities returns the PEM encoded certificates of authorities as base64 scheme URLs.
+func Authorities(authorities ...*identities.Identity) []string {
+
+	resources := []string{}
+	for _, authority := range authorities {
+		resources = append(resources, Base64Resource(encoding.PEMEncodeCertificate(authority.Certificate)))
+	}
+
+	return resources
+}
+
+// MustAuthority returns a self signed certificate authority with a key of an algorithm or fails a test.
+func MustAuthority(test *testing.T, name string, algorithm identities.KeyAlgorithm) *identities.Identity {
+
+	authority, err := identities.Self(identities.Template{
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		KeyAlgorithm:          algorithm,
+		KeyUsage:              x509.KeyUsageCRLSign | x509.KeyUsageCertSign,
+		NotAfter:              time.Now().AddDate(1, 0, 0),
+		NotBefore:             time.Now().Add(-time.Hour),
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+	})
+	if err != nil {
+		test.Fatalf("error creating authority [%s]", err)
+	}
+
+	return authority
+}
+
+// MustIssue returns an identity issued by an authority from a template or fails a test. Note that the key usage,
+// serial number and validity of the template default to a digital signature key valid for a year when empty.
+func MustIssue(test *testing.T, authority *identities.Identity, template identities.Template) *identities.Identity {
+
+	if template.KeyUsage == 0 {
+		template.KeyUsage = x509.KeyUsageDigitalSignature
+	}
+
+	if template.NotAfter.IsZero() {
+		template.NotAfter = time.Now().AddDate(1, 0, 0)
+	}
+
+	if template.NotBefore.IsZero() {
+		template.NotBefore = time.Now().Add(-time.Hour)
+	}
+
+	if template.SerialNumber == nil {
+		template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	}
+
+	identity, err := authority.Issue(template)
+	if err != nil {
+		test.Fatalf("error issuing certificate [%s]", err)
+	}
+
+	return identity
+}
+
+// MustIssueServer returns the PEM encoded certificate and key of a server identity issued by an authority for DNS names
+// as base64 scheme URLs or fails a test.
+func MustIssueServer(test *testing.T, authority *identities.Identity, algorithm identities.KeyAlgorithm, name string, names ...string) (string, string) {
+
+	return Resources(MustIssue(test, authority, identities.Template{
+		DNSNames:     names,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		KeyAlgorithm: algorithm,
+		Subject:      pkix.Name{CommonName: name},
+	}))
+}
+
+// MustIssueClient returns the PEM encoded certificate and key of a client identity issued by an authority for DNS names
+// as base64 scheme URLs or fails a test.
+func MustIssueClient(test *testing.T, authority *identities.Identity, algorithm identities.KeyAlgorithm, name string, names ...string) (string, string) {
+
+	return Resources(MustIssue(test, authority, identities.Template{
+		DNSNames:     names,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		KeyAlgorithm: algorithm,
+		Subject:      pkix.Name{CommonName: name},
+	}))
+}
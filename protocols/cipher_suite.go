@@ -0,0 +1,147 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protocols
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+// CipherSuite subtypes a TLS cipher suite identifier (e.g., tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256) to provide
+// serialization support. The names are those of the constants of the crypto/tls package and include the insecure
+// cipher suites returned by tls.InsecureCipherSuites.
+type CipherSuite uint16
+
+// CipherSuites defines an ordered list of cipher suites.
+type CipherSuites []CipherSuite
+
+// MarshalJSON implements the json.Marshaler interface for CipherSuite instances.
+func (c CipherSuite) MarshalJSON() ([]byte, error) {
+
+	value, err := c.ToString()
+	if err != nil {
+		return nil, errors.Wrap(err, "error marshalling cipher suite to json")
+	}
+
+	return []byte(fmt.Sprintf("\"%s\"", value)), nil
+}
+
+// MarshalYAML implements the yaml.Marshaler interface for CipherSuite instances.
+func (c CipherSuite) MarshalYAML() (interface{}, error) {
+	return c.ToString()
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for CipherSuite instances.
+func (c *CipherSuite) UnmarshalJSON(bytes []byte) error {
+
+	var value string
+
+	err := json.Unmarshal(bytes, &value)
+	if err != nil {
+		return errors.Wrap(err, "error unmarshalling cipher suite from json")
+	}
+
+	return c.FromString(value)
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface for CipherSuite instances.
+func (c *CipherSuite) UnmarshalYAML(unmarshal func(interface{}) error) error {
+
+	var value string
+
+	err := unmarshal(&value)
+	if err != nil {
+		return errors.Wrap(err, "error unmarshalling cipher suite from yaml")
+	}
+
+	return c.FromString(value)
+}
+
+// FromString sets the value of a cipher suite to the value represented by a string (e.g.,
+// "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256") or errors.
+func (c *CipherSuite) FromString(value string) error {
+
+	for _, suite := range cipherSuites() {
+		if strings.EqualFold(suite.Name, value) {
+			*c = CipherSuite(suite.ID)
+			return nil
+		}
+	}
+
+	return errors.New(fmt.Sprintf("error unmarshalling unknown cipher suite value [%s]", value))
+}
+
+// ToString returns the string representation of the cipher suite or an error.
+func (c CipherSuite) ToString() (string, error) {
+
+	for _, suite := range cipherSuites() {
+		if suite.ID == uint16(c) {
+			return suite.Name, nil
+		}
+	}
+
+	return "", errors.New(fmt.Sprintf("error converting unknown cipher suite value to string [%d]", c))
+}
+
+// IDs returns the cipher suite identifiers of the list for use as tls.Config.CipherSuites. Note that the identifiers of
+// an empty list are nil so that the defaults of the crypto/tls package are used.
+func (c CipherSuites) IDs() []uint16 {
+
+	if len(c) == 0 {
+		return nil
+	}
+
+	ids := make([]uint16, len(c))
+	for index, suite := range c {
+		ids[index] = uint16(suite)
+	}
+
+	return ids
+}
+
+// StringToCipherSuite returns a mapstructure.DecodeHookFunction that converts a string to a cipher suite.
+func StringToCipherSuite() mapstructure.DecodeHookFunc {
+
+	return func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+
+		if from != reflect.TypeOf("") {
+			return data, nil
+		}
+
+		if to != reflect.TypeOf(CipherSuite(0)) {
+			return data, nil
+		}
+
+		var suite CipherSuite
+
+		err := suite.FromString(data.(string))
+		if err != nil {
+			return nil, errors.Wrapf(err, "error decoding string as cipher suite")
+		}
+
+		return suite, nil
+	}
+}
+
+// cipherSuites returns the secure and insecure cipher suites implemented by the crypto/tls package.
+func cipherSuites() []*tls.CipherSuite {
+	return append(tls.CipherSuites(), tls.InsecureCipherSuites()...)
+}
@@ -0,0 +1,153 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protocols
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"testing"
+
+	"github.com/greymatter-io/nautls/internal/tests"
+	"github.com/mitchellh/mapstructure"
+	"gopkg.in/yaml.v2"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCipherSuite(t *testing.T) {
+
+	Convey("When cipher suite", t, func() {
+
+		for _, suite := range cipherSuites() {
+
+			suite := suite
+			value := CipherSuite(suite.ID)
+
+			Convey(".ToString is invoked for "+suite.Name, func() {
+
+				actual, err := value.ToString()
+
+				Convey("it returns the name of the crypto/tls constant", func() {
+					So(err, ShouldBeNil)
+					So(actual, ShouldEqual, suite.Name)
+				})
+			})
+		}
+
+		Convey("is marshalled to and from json", func() {
+
+			expected := CipherSuites{
+				CipherSuite(tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256),
+				CipherSuite(tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256),
+			}
+
+			bytes, err := json.Marshal(expected)
+			So(err, ShouldBeNil)
+
+			var actual CipherSuites
+			err = json.Unmarshal(bytes, &actual)
+
+			Convey("it uses the names of the cipher suites", func() {
+				So(string(bytes), ShouldContainSubstring, `"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"`)
+			})
+
+			Convey("it returns the original value", func() {
+				So(err, ShouldBeNil)
+				So(actual, ShouldResemble, expected)
+			})
+		})
+
+		Convey("is marshalled to and from yaml", func() {
+
+			expected := CipherSuites{
+				CipherSuite(tls.TLS_CHACHA20_POLY1305_SHA256),
+				CipherSuite(tls.TLS_RSA_WITH_AES_128_CBC_SHA),
+			}
+
+			bytes, err := yaml.Marshal(expected)
+			So(err, ShouldBeNil)
+
+			var actual CipherSuites
+			err = yaml.Unmarshal(bytes, &actual)
+
+			Convey("it returns the original value", func() {
+				So(err, ShouldBeNil)
+				So(actual, ShouldResemble, expected)
+			})
+		})
+
+		Convey("#StringToCipherSuite is invoked", func() {
+
+			var actual CipherSuites
+
+			decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{DecodeHook: StringToCipherSuite(), Result: &actual})
+			if err != nil {
+				t.Fatalf("error initializing decoder [%s]", err.Error())
+			}
+
+			err = decoder.Decode([]string{"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384"})
+
+			Convey("it returns the decoded value", func() {
+				So(err, ShouldBeNil)
+				So(actual, ShouldResemble, CipherSuites{CipherSuite(tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384)})
+			})
+		})
+
+		Convey(".FromString is invoked with an invalid value", func() {
+
+			actual := CipherSuite(tls.TLS_AES_128_GCM_SHA256)
+			err := actual.FromString(tests.MustGenerateHex(t) + "-invalid")
+
+			Convey("it returns a non-nil error", func() {
+				So(err, ShouldNotBeNil)
+			})
+
+			Convey("it does not modify the cipher suite", func() {
+				So(actual, ShouldEqual, CipherSuite(tls.TLS_AES_128_GCM_SHA256))
+			})
+		})
+
+		Convey(".ToString is invoked with an invalid value", func() {
+
+			_, err := CipherSuite(0xffff).ToString()
+
+			Convey("it returns a non-nil error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+
+		Convey(".IDs is invoked", func() {
+
+			Convey("with an empty list", func() {
+
+				Convey("it returns nil", func() {
+					So(CipherSuites{}.IDs(), ShouldBeNil)
+				})
+			})
+
+			Convey("with a list", func() {
+
+				Convey("it returns the identifiers in order", func() {
+					expected := []uint16{
+						tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+						tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+					}
+
+					So(CipherSuites{CipherSuite(expected[0]), CipherSuite(expected[1])}.IDs(), ShouldResemble, expected)
+				})
+			})
+		})
+	})
+}
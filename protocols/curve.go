@@ -0,0 +1,249 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protocols
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+// Curve subtypes a TLS key exchange curve or group identifier (i.e., a tls.CurveID) to provide serialization support.
+type Curve uint16
+
+// Curves defines an ordered list of key exchange curves.
+type Curves []Curve
+
+const (
+	// P256 defines the NIST P-256 curve.
+	P256 = Curve(tls.CurveP256)
+
+	// P384 defines the NIST P-384 curve.
+	P384 = Curve(tls.CurveP384)
+
+	// P521 defines the NIST P-521 curve.
+	P521 = Curve(tls.CurveP521)
+
+	// X25519 defines the X25519 curve.
+	X25519 = Curve(tls.X25519)
+
+	// X25519Kyber768Draft00 defines the draft hybrid X25519 and Kyber768 post-quantum key exchange. Note that it is
+	// removed from the curves of a configuration by versions of Go that do not implement it (e.g., Go 1.24 and later).
+	X25519Kyber768Draft00 Curve = 0x6399
+
+	// X25519MLKEM768 defines the hybrid X25519 and ML-KEM-768 post-quantum key exchange. Note that it is removed from
+	// the curves of a configuration by versions of Go that do not implement it (e.g., Go 1.23 and earlier).
+	X25519MLKEM768 Curve = 0x11ec
+)
+
+var (
+	// supportedCurves caches whether the crypto/tls package implements each curve that has been checked.
+	supportedCurves = map[Curve]bool{}
+
+	// supportedCurvesMutex guards the supported curves cache.
+	supportedCurvesMutex sync.Mutex
+)
+
+// curveNames maps the curves to their string representation.
+var curveNames = map[Curve]string{
+	P256:                  "P256",
+	P384:                  "P384",
+	P521:                  "P521",
+	X25519:                "X25519",
+	X25519Kyber768Draft00: "X25519Kyber768Draft00",
+	X25519MLKEM768:        "X25519MLKEM768",
+}
+
+// MarshalJSON implements the json.Marshaler interface for Curve instances.
+func (c Curve) MarshalJSON() ([]byte, error) {
+
+	value, err := c.ToString()
+	if err != nil {
+		return nil, errors.Wrap(err, "error marshalling curve to json")
+	}
+
+	return []byte(fmt.Sprintf("\"%s\"", value)), nil
+}
+
+// MarshalYAML implements the yaml.Marshaler interface for Curve instances.
+func (c Curve) MarshalYAML() (interface{}, error) {
+	return c.ToString()
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for Curve instances.
+func (c *Curve) UnmarshalJSON(bytes []byte) error {
+
+	var value string
+
+	err := json.Unmarshal(bytes, &value)
+	if err != nil {
+		return errors.Wrap(err, "error unmarshalling curve from json")
+	}
+
+	return c.FromString(value)
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface for Curve instances.
+func (c *Curve) UnmarshalYAML(unmarshal func(interface{}) error) error {
+
+	var value string
+
+	err := unmarshal(&value)
+	if err != nil {
+		return errors.Wrap(err, "error unmarshalling curve from yaml")
+	}
+
+	return c.FromString(value)
+}
+
+// FromString sets the value of a curve to the value represented by a string (e.g., "X25519") or errors. Note that the
+// names of the tls.CurveID constants (e.g., "CurveP256") are accepted as well.
+func (c *Curve) FromString(value string) error {
+
+	for curve, name := range curveNames {
+		if strings.EqualFold(name, value) || strings.EqualFold("Curve"+name, value) {
+			*c = curve
+			return nil
+		}
+	}
+
+	return errors.New(fmt.Sprintf("error unmarshalling unknown curve value [%s]", value))
+}
+
+// ToString returns the string representation of the curve or an error.
+func (c Curve) ToString() (string, error) {
+
+	name, ok := curveNames[c]
+	if !ok {
+		return "", errors.New(fmt.Sprintf("error converting unknown curve value to string [%d]", c))
+	}
+
+	return name, nil
+}
+
+// IDs returns the curve identifiers of the list for use as tls.Config.CurvePreferences. Note that the identifiers of an
+// empty list are nil so that the defaults of the crypto/tls package are used.
+func (c Curves) IDs() []tls.CurveID {
+
+	if len(c) == 0 {
+		return nil
+	}
+
+	ids := make([]tls.CurveID, len(c))
+	for index, curve := range c {
+		ids[index] = tls.CurveID(curve)
+	}
+
+	return ids
+}
+
+// Supported returns the curves of the list implemented by the crypto/tls package in order. Note that the supported curves
+// of an empty list are nil so that the defaults of the crypto/tls package are used.
+func (c Curves) Supported() Curves {
+
+	var supported Curves
+
+	for _, curve := range c {
+		if curve.supported() {
+			supported = append(supported, curve)
+		}
+	}
+
+	return supported
+}
+
+// CheckCurves returns an error if none of the curves of a list are implemented by the crypto/tls package as handshakes
+// would fail without a key exchange. Note that an empty list is not checked as it selects the defaults of the crypto/tls
+// package.
+func CheckCurves(curves Curves) error {
+
+	if len(curves) > 0 && len(curves.Supported()) == 0 {
+
+		names := []string{}
+		for _, curve := range curves {
+			name, err := curve.ToString()
+			if err != nil {
+				name = fmt.Sprintf("%d", curve)
+			}
+			names = append(names, name)
+		}
+
+		return errors.New(fmt.Sprintf("error checking curves as none of [%s] are implemented", strings.Join(names, ", ")))
+	}
+
+	return nil
+}
+
+// supported returns whether the crypto/tls package implements the curve (e.g., as enabled by the GODEBUG settings). As
+// crypto/tls does not expose its curves a client offering only the curve is started over an in-memory connection which
+// fails without sending a ClientHello when the curve is not implemented.
+func (c Curve) supported() bool {
+
+	supportedCurvesMutex.Lock()
+	defer supportedCurvesMutex.Unlock()
+
+	supported, ok := supportedCurves[c]
+	if ok {
+		return supported
+	}
+
+	client, server := net.Pipe()
+
+	offered := make(chan bool, 1)
+	go func() {
+		read, _ := server.Read(make([]byte, 1))
+		server.Close()
+		offered <- read > 0
+	}()
+
+	tls.Client(client, &tls.Config{CurvePreferences: []tls.CurveID{tls.CurveID(c)}, InsecureSkipVerify: true}).Handshake()
+	client.Close()
+
+	supported = <-offered
+	supportedCurves[c] = supported
+
+	return supported
+}
+
+// StringToCurve returns a mapstructure.DecodeHookFunction that converts a string to a curve.
+func StringToCurve() mapstructure.DecodeHookFunc {
+
+	return func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+
+		if from != reflect.TypeOf("") {
+			return data, nil
+		}
+
+		if to != reflect.TypeOf(Curve(0)) {
+			return data, nil
+		}
+
+		var curve Curve
+
+		err := curve.FromString(data.(string))
+		if err != nil {
+			return nil, errors.Wrapf(err, "error decoding string as curve")
+		}
+
+		return curve, nil
+	}
+}
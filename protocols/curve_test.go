@@ -0,0 +1,191 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protocols
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"testing"
+
+	"github.com/greymatter-io/nautls/internal/tests"
+	"github.com/mitchellh/mapstructure"
+	"gopkg.in/yaml.v2"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCurve(t *testing.T) {
+
+	Convey("When curve", t, func() {
+
+		for curve, value := range curveNames {
+
+			curve, value := curve, value
+
+			Convey(".ToString is invoked for "+value, func() {
+
+				actual, err := curve.ToString()
+
+				Convey("it returns the string representation", func() {
+					So(err, ShouldBeNil)
+					So(actual, ShouldEqual, value)
+				})
+			})
+
+			Convey("is marshalled to and from json for "+value, func() {
+
+				bytes, err := json.Marshal(curve)
+				So(err, ShouldBeNil)
+
+				var actual Curve
+				err = json.Unmarshal(bytes, &actual)
+
+				Convey("it returns the original value", func() {
+					So(err, ShouldBeNil)
+					So(actual, ShouldEqual, curve)
+				})
+			})
+
+			Convey("is marshalled to and from yaml for "+value, func() {
+
+				bytes, err := yaml.Marshal(curve)
+				So(err, ShouldBeNil)
+
+				var actual Curve
+				err = yaml.Unmarshal(bytes, &actual)
+
+				Convey("it returns the original value", func() {
+					So(err, ShouldBeNil)
+					So(actual, ShouldEqual, curve)
+				})
+			})
+
+			Convey("#StringToCurve is invoked for "+value, func() {
+
+				var actual Curve
+
+				decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{DecodeHook: StringToCurve(), Result: &actual})
+				if err != nil {
+					t.Fatalf("error initializing decoder [%s]", err.Error())
+				}
+
+				err = decoder.Decode(value)
+
+				Convey("it returns the decoded value", func() {
+					So(err, ShouldBeNil)
+					So(actual, ShouldEqual, curve)
+				})
+			})
+		}
+
+		Convey(".FromString is invoked with the name of a tls.CurveID constant", func() {
+
+			var actual Curve
+			err := actual.FromString("CurveP384")
+
+			Convey("it returns the curve", func() {
+				So(err, ShouldBeNil)
+				So(actual, ShouldEqual, tls.CurveP384)
+			})
+		})
+
+		Convey(".FromString is invoked with an invalid value", func() {
+
+			actual := X25519
+			err := actual.FromString(tests.MustGenerateHex(t) + "-invalid")
+
+			Convey("it returns a non-nil error", func() {
+				So(err, ShouldNotBeNil)
+			})
+
+			Convey("it does not modify the curve", func() {
+				So(actual, ShouldEqual, X25519)
+			})
+		})
+
+		Convey(".ToString is invoked with an invalid value", func() {
+
+			_, err := Curve(0xffff).ToString()
+
+			Convey("it returns a non-nil error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+
+		Convey(".IDs is invoked", func() {
+
+			Convey("with an empty list", func() {
+
+				Convey("it returns nil", func() {
+					So(Curves{}.IDs(), ShouldBeNil)
+				})
+			})
+
+			Convey("with a list", func() {
+
+				Convey("it returns the identifiers in order", func() {
+					So(Curves{X25519Kyber768Draft00, X25519}.IDs(), ShouldResemble, []tls.CurveID{0x6399, tls.X25519})
+				})
+			})
+		})
+
+		Convey(".Supported is invoked", func() {
+
+			Convey("with an empty list", func() {
+
+				Convey("it returns nil", func() {
+					So(Curves{}.Supported(), ShouldBeNil)
+				})
+			})
+
+			Convey("with a list including a curve that is not implemented", func() {
+
+				Convey("it returns the implemented curves in order", func() {
+					So(Curves{P384, Curve(0xfafa), X25519}.Supported(), ShouldResemble, Curves{P384, X25519})
+				})
+			})
+		})
+
+		Convey("#CheckCurves is invoked", func() {
+
+			Convey("with curves that are not implemented", func() {
+
+				err := CheckCurves(Curves{Curve(0xfafa)})
+
+				Convey("it returns a non-nil error", func() {
+					So(err, ShouldNotBeNil)
+				})
+			})
+
+			Convey("with an implemented curve", func() {
+
+				err := CheckCurves(Curves{Curve(0xfafa), P256})
+
+				Convey("it returns a nil error", func() {
+					So(err, ShouldBeNil)
+				})
+			})
+
+			Convey("with an empty list", func() {
+
+				err := CheckCurves(Curves{})
+
+				Convey("it returns a nil error", func() {
+					So(err, ShouldBeNil)
+				})
+			})
+		})
+	})
+}
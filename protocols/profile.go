@@ -0,0 +1,232 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protocols
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+// Profile defines a vetted set of TLS protocol settings modeled on the Mozilla server side TLS guidelines (see
+// https://wiki.mozilla.org/Security/Server_Side_TLS). Note that cipher suites and curves not implemented by the
+// crypto/tls package (e.g., those using finite field Diffie-Hellman) are omitted.
+type Profile int
+
+const (
+	// DefaultProfile uses the defaults of the crypto/tls package.
+	DefaultProfile Profile = iota
+
+	// ModernProfile allows TLS 1.3 only.
+	ModernProfile
+
+	// IntermediateProfile allows TLS 1.2 and TLS 1.3 with forward secret AEAD cipher suites.
+	IntermediateProfile
+
+	// OldProfile allows TLS 1.0 to TLS 1.3 with the cipher suites required by legacy clients.
+	OldProfile
+)
+
+// Settings defines the TLS protocol settings of a configuration.
+type Settings struct {
+
+	// CipherSuites defines the enabled TLS 1.0 to 1.2 cipher suites in order of preference.
+	CipherSuites CipherSuites
+
+	// CurvePreferences defines the enabled key exchange curves in order of preference.
+	CurvePreferences Curves
+
+	// MaxVersion defines the maximum TLS version.
+	MaxVersion Version
+
+	// MinVersion defines the minimum TLS version.
+	MinVersion Version
+}
+
+var (
+	// intermediateCipherSuites defines the cipher suites of the intermediate profile.
+	intermediateCipherSuites = CipherSuites{
+		CipherSuite(tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256),
+		CipherSuite(tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256),
+		CipherSuite(tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384),
+		CipherSuite(tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384),
+		CipherSuite(tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256),
+		CipherSuite(tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256),
+	}
+
+	// oldCipherSuites defines the cipher suites of the old profile.
+	oldCipherSuites = append(append(CipherSuites{}, intermediateCipherSuites...),
+		CipherSuite(tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA256),
+		CipherSuite(tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256),
+		CipherSuite(tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA),
+		CipherSuite(tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA),
+		CipherSuite(tls.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA),
+		CipherSuite(tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA),
+		CipherSuite(tls.TLS_RSA_WITH_AES_128_GCM_SHA256),
+		CipherSuite(tls.TLS_RSA_WITH_AES_256_GCM_SHA384),
+		CipherSuite(tls.TLS_RSA_WITH_AES_128_CBC_SHA256),
+		CipherSuite(tls.TLS_RSA_WITH_AES_128_CBC_SHA),
+		CipherSuite(tls.TLS_RSA_WITH_AES_256_CBC_SHA),
+		CipherSuite(tls.TLS_RSA_WITH_3DES_EDE_CBC_SHA),
+	)
+
+	// profileCurves defines the curves of the modern, intermediate and old profiles.
+	profileCurves = Curves{X25519, P256, P384}
+)
+
+// Settings returns the settings of the profile with the values of explicit settings taking precedence. Note that a
+// value of the explicit settings is only used when it is not empty (i.e., the zero value).
+func (p Profile) Settings(explicit Settings) Settings {
+
+	var settings Settings
+
+	switch p {
+	case ModernProfile:
+		settings = Settings{CurvePreferences: profileCurves, MinVersion: tls.VersionTLS13}
+	case IntermediateProfile:
+		settings = Settings{CipherSuites: intermediateCipherSuites, CurvePreferences: profileCurves, MinVersion: tls.VersionTLS12}
+	case OldProfile:
+		settings = Settings{CipherSuites: oldCipherSuites, CurvePreferences: profileCurves, MinVersion: tls.VersionTLS10}
+	}
+
+	if len(explicit.CipherSuites) > 0 {
+		settings.CipherSuites = explicit.CipherSuites
+	}
+
+	if len(explicit.CurvePreferences) > 0 {
+		settings.CurvePreferences = explicit.CurvePreferences
+	}
+
+	if explicit.MaxVersion != 0 {
+		settings.MaxVersion = explicit.MaxVersion
+	}
+
+	if explicit.MinVersion != 0 {
+		settings.MinVersion = explicit.MinVersion
+	}
+
+	return settings
+}
+
+// MarshalJSON implements the json.Marshaler interface for Profile instances.
+func (p Profile) MarshalJSON() ([]byte, error) {
+
+	value, err := p.ToString()
+	if err != nil {
+		return nil, errors.Wrap(err, "error marshalling profile to json")
+	}
+
+	return []byte(fmt.Sprintf("\"%s\"", value)), nil
+}
+
+// MarshalYAML implements the yaml.Marshaler interface for Profile instances.
+func (p Profile) MarshalYAML() (interface{}, error) {
+	return p.ToString()
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for Profile instances.
+func (p *Profile) UnmarshalJSON(bytes []byte) error {
+
+	var value string
+
+	err := json.Unmarshal(bytes, &value)
+	if err != nil {
+		return errors.Wrap(err, "error unmarshalling profile from json")
+	}
+
+	return p.FromString(value)
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface for Profile instances.
+func (p *Profile) UnmarshalYAML(unmarshal func(interface{}) error) error {
+
+	var value string
+
+	err := unmarshal(&value)
+	if err != nil {
+		return errors.Wrap(err, "error unmarshalling profile from yaml")
+	}
+
+	return p.FromString(value)
+}
+
+// FromString sets the value of a profile to the value represented by a string or errors.
+func (p *Profile) FromString(value string) error {
+
+	var profile Profile
+
+	switch strings.ToLower(value) {
+	case "", "default":
+		profile = DefaultProfile
+	case "modern":
+		profile = ModernProfile
+	case "intermediate":
+		profile = IntermediateProfile
+	case "old":
+		profile = OldProfile
+	default:
+		return errors.New(fmt.Sprintf("error unmarshalling unknown profile value [%s]", value))
+	}
+
+	*p = profile
+
+	return nil
+}
+
+// ToString returns the string representation of the profile or an error.
+func (p Profile) ToString() (string, error) {
+
+	switch p {
+	case DefaultProfile:
+		return "Default", nil
+	case ModernProfile:
+		return "Modern", nil
+	case IntermediateProfile:
+		return "Intermediate", nil
+	case OldProfile:
+		return "Old", nil
+	default:
+		return "", errors.New(fmt.Sprintf("error converting unknown profile value to string [%d]", p))
+	}
+}
+
+// StringToProfile returns a mapstructure.DecodeHookFunction that converts a string to a profile.
+func StringToProfile() mapstructure.DecodeHookFunc {
+
+	return func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+
+		if from != reflect.TypeOf("") {
+			return data, nil
+		}
+
+		if to != reflect.TypeOf(Profile(0)) {
+			return data, nil
+		}
+
+		var profile Profile
+
+		err := profile.FromString(data.(string))
+		if err != nil {
+			return nil, errors.Wrapf(err, "error decoding string as profile")
+		}
+
+		return profile, nil
+	}
+}
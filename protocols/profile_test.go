@@ -0,0 +1,180 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protocols
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"testing"
+
+	"github.com/greymatter-io/nautls/internal/tests"
+	"github.com/mitchellh/mapstructure"
+	"gopkg.in/yaml.v2"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// ValidProfiles returns a map of the valid profile values and their string representation.
+func ValidProfiles() map[Profile]string {
+	return map[Profile]string{
+		DefaultProfile:      "Default",
+		ModernProfile:       "Modern",
+		IntermediateProfile: "Intermediate",
+		OldProfile:          "Old",
+	}
+}
+
+func TestProfile(t *testing.T) {
+
+	Convey("When profile", t, func() {
+
+		for profile, value := range ValidProfiles() {
+
+			Convey(".ToString is invoked for "+value, func() {
+
+				actual, err := profile.ToString()
+
+				Convey("it returns the string representation", func() {
+					So(err, ShouldBeNil)
+					So(actual, ShouldEqual, value)
+				})
+			})
+
+			Convey("is marshalled to and from json for "+value, func() {
+
+				bytes, err := json.Marshal(profile)
+				So(err, ShouldBeNil)
+
+				var actual Profile
+				err = json.Unmarshal(bytes, &actual)
+
+				Convey("it returns the original value", func() {
+					So(err, ShouldBeNil)
+					So(actual, ShouldEqual, profile)
+				})
+			})
+
+			Convey("is marshalled to and from yaml for "+value, func() {
+
+				bytes, err := yaml.Marshal(profile)
+				So(err, ShouldBeNil)
+
+				var actual Profile
+				err = yaml.Unmarshal(bytes, &actual)
+
+				Convey("it returns the original value", func() {
+					So(err, ShouldBeNil)
+					So(actual, ShouldEqual, profile)
+				})
+			})
+
+			Convey("#StringToProfile is invoked for "+value, func() {
+
+				var actual Profile
+
+				decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{DecodeHook: StringToProfile(), Result: &actual})
+				if err != nil {
+					t.Fatalf("error initializing decoder [%s]", err.Error())
+				}
+
+				err = decoder.Decode(value)
+
+				Convey("it returns the decoded value", func() {
+					So(err, ShouldBeNil)
+					So(actual, ShouldEqual, profile)
+				})
+			})
+		}
+
+		Convey(".FromString is invoked with an invalid value", func() {
+
+			actual := ModernProfile
+			err := actual.FromString(tests.MustGenerateHex(t) + "-invalid")
+
+			Convey("it returns a non-nil error", func() {
+				So(err, ShouldNotBeNil)
+			})
+
+			Convey("it does not modify the profile", func() {
+				So(actual, ShouldEqual, ModernProfile)
+			})
+		})
+
+		Convey(".ToString is invoked with an invalid value", func() {
+
+			_, err := Profile(-1).ToString()
+
+			Convey("it returns a non-nil error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+
+		Convey(".Settings is invoked", func() {
+
+			Convey("for the default profile", func() {
+
+				Convey("it returns empty settings", func() {
+					So(DefaultProfile.Settings(Settings{}), ShouldResemble, Settings{})
+				})
+			})
+
+			Convey("for the modern profile", func() {
+
+				settings := ModernProfile.Settings(Settings{})
+
+				Convey("it requires TLS 1.3", func() {
+					So(settings.MinVersion, ShouldEqual, tls.VersionTLS13)
+					So(settings.CipherSuites, ShouldBeEmpty)
+				})
+			})
+
+			Convey("for the intermediate profile", func() {
+
+				settings := IntermediateProfile.Settings(Settings{})
+
+				Convey("it requires TLS 1.2 and forward secret AEAD cipher suites", func() {
+					So(settings.MinVersion, ShouldEqual, tls.VersionTLS12)
+					So(settings.CipherSuites, ShouldResemble, intermediateCipherSuites)
+					So(settings.CurvePreferences, ShouldResemble, Curves{X25519, P256, P384})
+				})
+			})
+
+			Convey("for the old profile", func() {
+
+				settings := OldProfile.Settings(Settings{})
+
+				Convey("it allows TLS 1.0 and the intermediate cipher suites first", func() {
+					So(settings.MinVersion, ShouldEqual, tls.VersionTLS10)
+					So(settings.CipherSuites[:len(intermediateCipherSuites)], ShouldResemble, intermediateCipherSuites)
+					So(settings.CipherSuites, ShouldContain, CipherSuite(tls.TLS_RSA_WITH_3DES_EDE_CBC_SHA))
+				})
+			})
+
+			Convey("with explicit settings", func() {
+
+				explicit := Settings{
+					CipherSuites:     CipherSuites{CipherSuite(tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384)},
+					CurvePreferences: Curves{P521},
+					MaxVersion:       tls.VersionTLS12,
+					MinVersion:       tls.VersionTLS11,
+				}
+
+				Convey("it returns the explicit settings", func() {
+					So(IntermediateProfile.Settings(explicit), ShouldResemble, explicit)
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,157 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package protocols provides serializable representations of TLS protocol versions, cipher suites and key exchange
+// curves for use within client and server configurations.
+package protocols
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+// Version subtypes a TLS protocol version (e.g., tls.VersionTLS12) to provide serialization support. The zero value
+// selects the default of the crypto/tls package.
+type Version uint16
+
+// MarshalJSON implements the json.Marshaler interface for Version instances.
+func (v Version) MarshalJSON() ([]byte, error) {
+
+	value, err := v.ToString()
+	if err != nil {
+		return nil, errors.Wrap(err, "error marshalling version to json")
+	}
+
+	return []byte(fmt.Sprintf("\"%s\"", value)), nil
+}
+
+// MarshalYAML implements the yaml.Marshaler interface for Version instances.
+func (v Version) MarshalYAML() (interface{}, error) {
+	return v.ToString()
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for Version instances.
+func (v *Version) UnmarshalJSON(bytes []byte) error {
+
+	var value string
+
+	err := json.Unmarshal(bytes, &value)
+	if err != nil {
+		return errors.Wrap(err, "error unmarshalling version from json")
+	}
+
+	return v.FromString(value)
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface for Version instances.
+func (v *Version) UnmarshalYAML(unmarshal func(interface{}) error) error {
+
+	var value string
+
+	err := unmarshal(&value)
+	if err != nil {
+		return errors.Wrap(err, "error unmarshalling version from yaml")
+	}
+
+	return v.FromString(value)
+}
+
+// FromString sets the value of a version to the value represented by a string (e.g., "TLS1.2") or errors.
+func (v *Version) FromString(value string) error {
+
+	var version Version
+
+	switch strings.ToLower(value) {
+	case "":
+		version = 0
+	case "tls1.0":
+		version = tls.VersionTLS10
+	case "tls1.1":
+		version = tls.VersionTLS11
+	case "tls1.2":
+		version = tls.VersionTLS12
+	case "tls1.3":
+		version = tls.VersionTLS13
+	default:
+		return errors.New(fmt.Sprintf("error unmarshalling unknown version value [%s]", value))
+	}
+
+	*v = version
+
+	return nil
+}
+
+// ToString returns the string representation of the version or an error.
+func (v Version) ToString() (string, error) {
+
+	switch v {
+	case 0:
+		return "", nil
+	case tls.VersionTLS10:
+		return "TLS1.0", nil
+	case tls.VersionTLS11:
+		return "TLS1.1", nil
+	case tls.VersionTLS12:
+		return "TLS1.2", nil
+	case tls.VersionTLS13:
+		return "TLS1.3", nil
+	default:
+		return "", errors.New(fmt.Sprintf("error converting unknown version value to string [%d]", v))
+	}
+}
+
+// StringToVersion returns a mapstructure.DecodeHookFunction that converts a string to a version.
+func StringToVersion() mapstructure.DecodeHookFunc {
+
+	return func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+
+		if from != reflect.TypeOf("") {
+			return data, nil
+		}
+
+		if to != reflect.TypeOf(Version(0)) {
+			return data, nil
+		}
+
+		var version Version
+
+		err := version.FromString(data.(string))
+		if err != nil {
+			return nil, errors.Wrapf(err, "error decoding string as version")
+		}
+
+		return version, nil
+	}
+}
+
+// CheckVersions returns an error if a minimum version is greater than a maximum version. Note that zero values are
+// not checked as they select the defaults of the crypto/tls package.
+func CheckVersions(minimum Version, maximum Version) error {
+
+	if minimum != 0 && maximum != 0 && minimum > maximum {
+
+		low, _ := minimum.ToString()
+		high, _ := maximum.ToString()
+
+		return errors.New(fmt.Sprintf("error checking versions as the minimum [%s] exceeds the maximum [%s]", low, high))
+	}
+
+	return nil
+}
@@ -0,0 +1,168 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protocols
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"testing"
+
+	"github.com/greymatter-io/nautls/internal/tests"
+	"github.com/mitchellh/mapstructure"
+	"gopkg.in/yaml.v2"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// ValidVersions returns a map of the valid version values and their string representation.
+func ValidVersions() map[Version]string {
+	return map[Version]string{
+		tls.VersionTLS10: "TLS1.0",
+		tls.VersionTLS11: "TLS1.1",
+		tls.VersionTLS12: "TLS1.2",
+		tls.VersionTLS13: "TLS1.3",
+	}
+}
+
+func TestVersion(t *testing.T) {
+
+	Convey("When version", t, func() {
+
+		for version, value := range ValidVersions() {
+
+			Convey(".ToString is invoked for "+value, func() {
+
+				actual, err := version.ToString()
+
+				Convey("it returns a nil error", func() {
+					So(err, ShouldBeNil)
+				})
+
+				Convey("it returns the string representation", func() {
+					So(actual, ShouldEqual, value)
+				})
+			})
+
+			Convey("is marshalled to and from json for "+value, func() {
+
+				bytes, err := json.Marshal(version)
+				So(err, ShouldBeNil)
+
+				var actual Version
+				err = json.Unmarshal(bytes, &actual)
+
+				Convey("it returns the original value", func() {
+					So(err, ShouldBeNil)
+					So(actual, ShouldEqual, version)
+				})
+			})
+
+			Convey("is marshalled to and from yaml for "+value, func() {
+
+				bytes, err := yaml.Marshal(version)
+				So(err, ShouldBeNil)
+
+				var actual Version
+				err = yaml.Unmarshal(bytes, &actual)
+
+				Convey("it returns the original value", func() {
+					So(err, ShouldBeNil)
+					So(actual, ShouldEqual, version)
+				})
+			})
+
+			Convey("#StringToVersion is invoked for "+value, func() {
+
+				var actual Version
+
+				decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{DecodeHook: StringToVersion(), Result: &actual})
+				if err != nil {
+					t.Fatalf("error initializing decoder [%s]", err.Error())
+				}
+
+				err = decoder.Decode(value)
+
+				Convey("it returns the decoded value", func() {
+					So(err, ShouldBeNil)
+					So(actual, ShouldEqual, version)
+				})
+			})
+		}
+
+		Convey(".FromString is invoked with an empty value", func() {
+
+			actual := Version(tls.VersionTLS12)
+			err := actual.FromString("")
+
+			Convey("it returns the zero value", func() {
+				So(err, ShouldBeNil)
+				So(actual, ShouldBeZeroValue)
+			})
+		})
+
+		Convey(".FromString is invoked with an invalid value", func() {
+
+			actual := Version(tls.VersionTLS12)
+			err := actual.FromString(tests.MustGenerateHex(t) + "-invalid")
+
+			Convey("it returns a non-nil error", func() {
+				So(err, ShouldNotBeNil)
+			})
+
+			Convey("it does not modify the version", func() {
+				So(actual, ShouldEqual, tls.VersionTLS12)
+			})
+		})
+
+		Convey(".ToString is invoked with an invalid value", func() {
+
+			_, err := Version(0xffff).ToString()
+
+			Convey("it returns a non-nil error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+
+		Convey("#CheckVersions is invoked", func() {
+
+			Convey("with a minimum greater than the maximum", func() {
+
+				err := CheckVersions(tls.VersionTLS13, tls.VersionTLS12)
+
+				Convey("it returns a non-nil error", func() {
+					So(err, ShouldNotBeNil)
+				})
+			})
+
+			Convey("with a minimum less than the maximum", func() {
+
+				err := CheckVersions(tls.VersionTLS12, tls.VersionTLS13)
+
+				Convey("it returns a nil error", func() {
+					So(err, ShouldBeNil)
+				})
+			})
+
+			Convey("with a default maximum", func() {
+
+				err := CheckVersions(tls.VersionTLS13, 0)
+
+				Convey("it returns a nil error", func() {
+					So(err, ShouldBeNil)
+				})
+			})
+		})
+	})
+}
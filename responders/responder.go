@@ -0,0 +1,298 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package responders provides an RFC 6960 OCSP responder for certificates issued by an identities.Identity.
+package responders
+
+import (
+	"bytes"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/greymatter-io/nautls/identities"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ocsp"
+)
+
+const (
+	// DefaultValidity defines the duration between the this update and next update of responses when no validity is
+	// provided.
+	DefaultValidity = time.Hour
+
+	// maximumRequestSize defines the maximum size in bytes of a POST encoded request.
+	maximumRequestSize = 10240
+
+	// requestContentType defines the media type of an OCSP request.
+	requestContentType = "application/ocsp-request"
+
+	// responseContentType defines the media type of an OCSP response.
+	responseContentType = "application/ocsp-response"
+)
+
+// Status represents the revocation status of a certificate.
+type Status struct {
+
+	// Reason defines the reason the certificate was revoked.
+	Reason identities.RevocationReason
+
+	// Revoked defines whether the certificate was revoked.
+	Revoked bool
+
+	// RevokedAt defines the time at which the certificate was revoked.
+	RevokedAt time.Time
+
+	// Unknown defines whether the certificate is unknown to the source (e.g., it was never issued).
+	Unknown bool
+}
+
+// StatusSource provides the revocation status of certificates by serial number.
+type StatusSource interface {
+	Status(serialNumber *big.Int) (Status, error)
+}
+
+// StatusFunc adapts a function to a StatusSource.
+type StatusFunc func(serialNumber *big.Int) (Status, error)
+
+// Status returns the revocation status of a certificate by serial number.
+func (f StatusFunc) Status(serialNumber *big.Int) (Status, error) {
+	return f(serialNumber)
+}
+
+// RevocationsSource returns a StatusSource backed by tracked revocations. Note that certificates that have not been
+// revoked are reported as good since the revocations do not record issued certificates.
+func RevocationsSource(revocations *identities.Revocations) StatusSource {
+
+	return StatusFunc(func(serialNumber *big.Int) (Status, error) {
+
+		revocation, revoked := revocations.Revocation(serialNumber)
+		if !revoked {
+			return Status{}, nil
+		}
+
+		return Status{Reason: revocation.Reason, Revoked: true, RevokedAt: revocation.RevokedAt}, nil
+	})
+}
+
+// Responder implements an http.Handler that answers OCSP requests for certificates issued by an identity. Requests are
+// accepted using both the POST encoding and the GET encoding in which the base64 encoded request is the path of the URL.
+// Note that when mounted beneath a prefix the handler must be wrapped with http.StripPrefix.
+type Responder struct {
+
+	// Delegate defines an optional identity issued by the issuer with the OCSP signing extended key usage that signs
+	// responses in place of the issuer.
+	Delegate *identities.Identity
+
+	// Issuer defines the identity that issued the certificates for which status is provided.
+	Issuer *identities.Identity
+
+	// Source defines the source of revocation status.
+	Source StatusSource
+
+	// Validity defines the duration between the this update and next update of responses. It defaults to
+	// DefaultValidity.
+	Validity time.Duration
+}
+
+// NewResponder returns a new responder for the certificates issued by an issuer. The delegate is optional and the issuer
+// signs responses when it is nil.
+func NewResponder(issuer *identities.Identity, delegate *identities.Identity, source StatusSource) *Responder {
+	return &Responder{
+		Delegate: delegate,
+		Issuer:   issuer,
+		Source:   source,
+		Validity: DefaultValidity,
+	}
+}
+
+// ServeHTTP answers an OCSP request.
+func (r *Responder) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+
+	var data []byte
+	var err error
+
+	switch request.Method {
+	case http.MethodGet:
+		data, err = decodeGet(request.URL)
+	case http.MethodPost:
+		data, err = decodePost(request)
+	default:
+		writer.Header().Set("Allow", "GET, POST")
+		http.Error(writer, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err != nil {
+		write(writer, ocsp.MalformedRequestErrorResponse, 0)
+		return
+	}
+
+	response, err := r.Respond(data)
+	if err != nil {
+		write(writer, ocsp.InternalErrorErrorResponse, 0)
+		return
+	}
+
+	if request.Method == http.MethodGet {
+		write(writer, response, r.validity())
+		return
+	}
+
+	write(writer, response, 0)
+}
+
+// Respond returns a DER encoded OCSP response for a DER encoded OCSP request. Note that malformed requests and requests
+// for certificates of another issuer result in the corresponding OCSP error response rather than an error.
+func (r *Responder) Respond(data []byte) ([]byte, error) {
+
+	request, err := ocsp.ParseRequest(data)
+	if err != nil {
+		return ocsp.MalformedRequestErrorResponse, nil
+	}
+
+	issued, err := r.issued(request)
+	if err != nil {
+		return nil, errors.Wrap(err, "error matching ocsp request issuer")
+	}
+
+	if !issued {
+		return ocsp.UnauthorizedErrorResponse, nil
+	}
+
+	status, err := r.Source.Status(request.SerialNumber)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading status of [%s]", request.SerialNumber)
+	}
+
+	now := time.Now().UTC().Truncate(time.Minute)
+
+	template := ocsp.Response{
+		IssuerHash:   request.HashAlgorithm,
+		NextUpdate:   now.Add(r.validity()),
+		SerialNumber: request.SerialNumber,
+		Status:       ocsp.Good,
+		ThisUpdate:   now,
+	}
+
+	switch {
+	case status.Unknown:
+		template.Status = ocsp.Unknown
+	case status.Revoked:
+		template.RevocationReason = int(status.Reason)
+		template.RevokedAt = status.RevokedAt
+		template.Status = ocsp.Revoked
+	}
+
+	signer := r.Issuer
+	if r.Delegate != nil {
+		signer = r.Delegate
+		template.Certificate = r.Delegate.Certificate
+	}
+
+	response, err := ocsp.CreateResponse(r.Issuer.Certificate, signer.Certificate, template, signer.Key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error signing ocsp response for [%s]", request.SerialNumber)
+	}
+
+	return response, nil
+}
+
+// issued returns true if an OCSP request identifies the issuer of the responder.
+func (r *Responder) issued(request *ocsp.Request) (bool, error) {
+
+	if !request.HashAlgorithm.Available() {
+		return false, nil
+	}
+
+	var info struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+
+	_, err := asn1.Unmarshal(r.Issuer.Certificate.RawSubjectPublicKeyInfo, &info)
+	if err != nil {
+		return false, errors.Wrap(err, "error parsing issuer public key")
+	}
+
+	hash := request.HashAlgorithm.New()
+	hash.Write(info.PublicKey.RightAlign())
+	keyHash := hash.Sum(nil)
+
+	hash = request.HashAlgorithm.New()
+	hash.Write(r.Issuer.Certificate.RawSubject)
+	nameHash := hash.Sum(nil)
+
+	return bytes.Equal(keyHash, request.IssuerKeyHash) && bytes.Equal(nameHash, request.IssuerNameHash), nil
+}
+
+// validity returns the duration between the this update and next update of responses.
+func (r *Responder) validity() time.Duration {
+
+	if r.Validity <= 0 {
+		return DefaultValidity
+	}
+
+	return r.Validity
+}
+
+// decodeGet returns the DER encoded request from the path of a GET encoded request.
+func decodeGet(location *url.URL) ([]byte, error) {
+
+	path, err := url.PathUnescape(strings.TrimPrefix(location.EscapedPath(), "/"))
+	if err != nil {
+		return nil, errors.Wrap(err, "error unescaping ocsp request")
+	}
+
+	data, err := base64.StdEncoding.DecodeString(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "error decoding ocsp request")
+	}
+
+	return data, nil
+}
+
+// decodePost returns the DER encoded request from the body of a POST encoded request.
+func decodePost(request *http.Request) ([]byte, error) {
+
+	if request.Header.Get("Content-Type") != requestContentType {
+		return nil, fmt.Errorf("unsupported content type [%s]", request.Header.Get("Content-Type"))
+	}
+
+	data, err := io.ReadAll(io.LimitReader(request.Body, maximumRequestSize))
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading ocsp request")
+	}
+
+	return data, nil
+}
+
+// write writes an OCSP response allowing it to be cached for a duration when the duration is positive.
+func write(writer http.ResponseWriter, response []byte, cache time.Duration) {
+
+	writer.Header().Set("Content-Type", responseContentType)
+
+	if cache > 0 {
+		writer.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d, public, no-transform, must-revalidate", int(cache.Seconds())))
+	}
+
+	writer.WriteHeader(http.StatusOK)
+	writer.Write(response)
+}
@@ -0,0 +1,302 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package responders
+
+import (
+	"bytes"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/greymatter-io/nautls/identities"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ocsp"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// mustIdentity returns an identity issued by a parent (or self signed when the parent is nil) or fails a test.
+func mustIdentity(t *testing.T, parent *identities.Identity, template identities.Template) *identities.Identity {
+
+	template.KeyAlgorithm = identities.ECDSA
+	template.NotAfter = time.Now().AddDate(1, 0, 0)
+	template.NotBefore = time.Now().Add(-time.Hour)
+
+	var identity *identities.Identity
+	var err error
+
+	if parent == nil {
+		identity, err = identities.Self(template)
+	} else {
+		identity, err = parent.Issue(template)
+	}
+
+	if err != nil {
+		t.Fatalf("error creating identity [%s]", err)
+	}
+
+	return identity
+}
+
+// get sends a GET encoded OCSP request.
+func get(server *httptest.Server, request []byte) (*http.Response, []byte, error) {
+
+	response, err := http.Get(server.URL + "/" + url.PathEscape(base64.StdEncoding.EncodeToString(request)))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	return response, body, err
+}
+
+// post sends a POST encoded OCSP request.
+func post(server *httptest.Server, request []byte) (*http.Response, []byte, error) {
+
+	response, err := http.Post(server.URL, requestContentType, bytes.NewReader(request))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	return response, body, err
+}
+
+func TestResponder(t *testing.T) {
+
+	Convey("When Responder", t, func() {
+
+		authority := mustIdentity(t, nil, identities.Template{
+			BasicConstraintsValid: true,
+			IsCA:                  true,
+			KeyUsage:              x509.KeyUsageCRLSign | x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+			SerialNumber:          big.NewInt(1),
+			Subject:               pkix.Name{CommonName: "NauTLS (Authority)"},
+		})
+
+		good := mustIdentity(t, authority, identities.Template{
+			DNSNames:     []string{"good.nautls.com"},
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			SerialNumber: big.NewInt(2),
+			Subject:      pkix.Name{CommonName: "good.nautls.com"},
+		})
+
+		revoked := mustIdentity(t, authority, identities.Template{
+			DNSNames:     []string{"revoked.nautls.com"},
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			SerialNumber: big.NewInt(3),
+			Subject:      pkix.Name{CommonName: "revoked.nautls.com"},
+		})
+
+		revokedAt := time.Now().Add(-time.Minute).UTC().Truncate(time.Second)
+		revocations := &identities.Revocations{}
+		revocations.Revoke(revoked.Certificate.SerialNumber, identities.KeyCompromise, revokedAt)
+
+		goodRequest, _ := ocsp.CreateRequest(good.Certificate, authority.Certificate, nil)
+		revokedRequest, _ := ocsp.CreateRequest(revoked.Certificate, authority.Certificate, nil)
+
+		Convey("is signed by the issuer", func() {
+
+			server := httptest.NewServer(NewResponder(authority, nil, RevocationsSource(revocations)))
+			defer server.Close()
+
+			Convey("and a POST request is sent for a good certificate", func() {
+
+				response, body, err := post(server, goodRequest)
+				So(err, ShouldBeNil)
+
+				parsed, err := ocsp.ParseResponseForCert(body, good.Certificate, authority.Certificate)
+
+				Convey("it returns an ocsp response", func() {
+					So(response.StatusCode, ShouldEqual, http.StatusOK)
+					So(response.Header.Get("Content-Type"), ShouldEqual, responseContentType)
+				})
+
+				Convey("it returns a response signed by the issuer", func() {
+					So(err, ShouldBeNil)
+				})
+
+				Convey("it returns a good status", func() {
+					So(parsed.Status, ShouldEqual, ocsp.Good)
+					So(parsed.SerialNumber.Cmp(good.Certificate.SerialNumber), ShouldEqual, 0)
+					So(parsed.NextUpdate.Sub(parsed.ThisUpdate), ShouldEqual, DefaultValidity)
+				})
+			})
+
+			Convey("and a GET request is sent for a revoked certificate", func() {
+
+				response, body, err := get(server, revokedRequest)
+				So(err, ShouldBeNil)
+
+				parsed, err := ocsp.ParseResponseForCert(body, revoked.Certificate, authority.Certificate)
+
+				Convey("it returns a cacheable response", func() {
+					So(response.StatusCode, ShouldEqual, http.StatusOK)
+					So(response.Header.Get("Cache-Control"), ShouldStartWith, "max-age=3600")
+				})
+
+				Convey("it returns a revoked status", func() {
+					So(err, ShouldBeNil)
+					So(parsed.Status, ShouldEqual, ocsp.Revoked)
+					So(parsed.RevocationReason, ShouldEqual, ocsp.KeyCompromise)
+					So(parsed.RevokedAt.Equal(revokedAt), ShouldBeTrue)
+				})
+			})
+
+			Convey("and a request is sent for a certificate of another issuer", func() {
+
+				other := mustIdentity(t, nil, identities.Template{
+					BasicConstraintsValid: true,
+					IsCA:                  true,
+					KeyUsage:              x509.KeyUsageCertSign,
+					SerialNumber:          big.NewInt(1),
+					Subject:               pkix.Name{CommonName: "NauTLS (Other)"},
+				})
+
+				request, _ := ocsp.CreateRequest(good.Certificate, other.Certificate, nil)
+				_, body, err := post(server, request)
+				So(err, ShouldBeNil)
+
+				Convey("it returns an unauthorized response", func() {
+					So(body, ShouldResemble, ocsp.UnauthorizedErrorResponse)
+				})
+			})
+
+			Convey("and a malformed request is sent", func() {
+
+				_, body, err := post(server, []byte("invalid"))
+				So(err, ShouldBeNil)
+
+				Convey("it returns a malformed request response", func() {
+					So(body, ShouldResemble, ocsp.MalformedRequestErrorResponse)
+				})
+			})
+
+			Convey("and a request is sent with an unsupported method", func() {
+
+				request, _ := http.NewRequest(http.MethodPut, server.URL, bytes.NewReader(goodRequest))
+				response, err := http.DefaultClient.Do(request)
+				So(err, ShouldBeNil)
+				response.Body.Close()
+
+				Convey("it returns method not allowed", func() {
+					So(response.StatusCode, ShouldEqual, http.StatusMethodNotAllowed)
+				})
+			})
+		})
+
+		Convey("is signed by a delegate", func() {
+
+			delegate := mustIdentity(t, authority, identities.Template{
+				ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning},
+				KeyUsage:     x509.KeyUsageDigitalSignature,
+				SerialNumber: big.NewInt(4),
+				Subject:      pkix.Name{CommonName: "NauTLS (Responder)"},
+			})
+
+			server := httptest.NewServer(NewResponder(authority, delegate, RevocationsSource(revocations)))
+			defer server.Close()
+
+			_, body, err := post(server, goodRequest)
+			So(err, ShouldBeNil)
+
+			parsed, err := ocsp.ParseResponseForCert(body, good.Certificate, authority.Certificate)
+
+			Convey("it returns a response verifiable through the issuer", func() {
+				So(err, ShouldBeNil)
+				So(parsed.Certificate.Equal(delegate.Certificate), ShouldBeTrue)
+				So(parsed.Status, ShouldEqual, ocsp.Good)
+			})
+		})
+
+		Convey("is backed by a source", func() {
+
+			Convey("that reports the certificate as unknown", func() {
+
+				responder := NewResponder(authority, nil, StatusFunc(func(*big.Int) (Status, error) {
+					return Status{Unknown: true}, nil
+				}))
+
+				response, err := responder.Respond(goodRequest)
+				So(err, ShouldBeNil)
+
+				parsed, err := ocsp.ParseResponseForCert(response, good.Certificate, authority.Certificate)
+
+				Convey("it returns an unknown status", func() {
+					So(err, ShouldBeNil)
+					So(parsed.Status, ShouldEqual, ocsp.Unknown)
+				})
+			})
+
+			Convey("of revocations that are revoked while serving", func() {
+
+				server := httptest.NewServer(NewResponder(authority, nil, RevocationsSource(revocations)))
+				defer server.Close()
+
+				done := make(chan struct{})
+				go func() {
+					defer close(done)
+					for serial := int64(100); serial < 200; serial++ {
+						revocations.Revoke(big.NewInt(serial), identities.Superseded, revokedAt)
+					}
+				}()
+
+				failures := 0
+				for index := 0; index < 20; index++ {
+					_, body, err := post(server, goodRequest)
+					if err != nil {
+						failures++
+						continue
+					}
+					_, err = ocsp.ParseResponseForCert(body, good.Certificate, authority.Certificate)
+					if err != nil {
+						failures++
+					}
+				}
+
+				<-done
+
+				Convey("it returns responses", func() {
+					So(failures, ShouldEqual, 0)
+					So(revocations.Revoked(big.NewInt(199)), ShouldBeTrue)
+				})
+			})
+
+			Convey("that errors", func() {
+
+				server := httptest.NewServer(NewResponder(authority, nil, StatusFunc(func(*big.Int) (Status, error) {
+					return Status{}, errors.New("unavailable")
+				})))
+				defer server.Close()
+
+				_, body, err := post(server, goodRequest)
+				So(err, ShouldBeNil)
+
+				Convey("it returns an internal error response", func() {
+					So(body, ShouldResemble, ocsp.InternalErrorErrorResponse)
+				})
+			})
+		})
+	})
+}
@@ -14,6 +14,11 @@
 
 package servers
 
+import (
+	"github.com/greymatter-io/nautls/protocols"
+	"github.com/greymatter-io/nautls/spiffe"
+)
+
 // ConfigurationBuilder provides an builder for server tls.Config instances.
 type ConfigurationBuilder struct {
 	Configuration
@@ -27,10 +32,25 @@ func NewConfigurationBuilder() *ConfigurationBuilder {
 // Build returns a Configuration for the current state of the builder.
 func (b *ConfigurationBuilder) Build() *Configuration {
 	return &Configuration{
-		Authorities:    b.Authorities,
-		Certificate:    b.Certificate,
-		Key:            b.Key,
-		Authentication: b.Authentication,
+		Authorities:      b.Authorities,
+		Certificate:      b.Certificate,
+		Certificates:     b.Certificates,
+		CipherSuites:     b.CipherSuites,
+		CurvePreferences: b.CurvePreferences,
+		Key:              b.Key,
+		MaxVersion:       b.MaxVersion,
+		MinVersion:       b.MinVersion,
+		NextProtos:       b.NextProtos,
+		Passphrase:       b.Passphrase,
+		PKCS12:           b.PKCS12,
+		Policy:           b.Policy,
+		Profile:          b.Profile,
+		Reload:           b.Reload,
+		Revocations:      b.Revocations,
+		SPIFFE:           b.SPIFFE,
+		Staple:           b.Staple,
+		Stapling:         b.Stapling,
+		Authentication:   b.Authentication,
 	}
 }
 
@@ -56,6 +76,24 @@ func (b *ConfigurationBuilder) WithCertificate(certificate string) *Configuratio
 	return b
 }
 
+// WithCertificates sets additional server certificates selected by the server name of a ClientHello (i.e., SNI).
+func (b *ConfigurationBuilder) WithCertificates(certificates []NamedCertificate) *ConfigurationBuilder {
+	b.Certificates = certificates
+	return b
+}
+
+// WithCipherSuites sets the enabled TLS 1.0 to 1.2 cipher suites in order of preference.
+func (b *ConfigurationBuilder) WithCipherSuites(suites protocols.CipherSuites) *ConfigurationBuilder {
+	b.CipherSuites = suites
+	return b
+}
+
+// WithCurvePreferences sets the enabled key exchange curves in order of preference.
+func (b *ConfigurationBuilder) WithCurvePreferences(curves protocols.Curves) *ConfigurationBuilder {
+	b.CurvePreferences = curves
+	return b
+}
+
 // WithKey sets the server key. The value must be a URL that points to the location of a PEM encoded key.
 //
 // Note that in addition to those schemes supported by [getter](https://godoc.org/github.com/hashicorp/go-getter) a
@@ -66,6 +104,104 @@ func (b *ConfigurationBuilder) WithKey(key string) *ConfigurationBuilder {
 	return b
 }
 
+// WithMaxVersion sets the maximum TLS version.
+func (b *ConfigurationBuilder) WithMaxVersion(version protocols.Version) *ConfigurationBuilder {
+	b.MaxVersion = version
+	return b
+}
+
+// WithMinVersion sets the minimum TLS version.
+func (b *ConfigurationBuilder) WithMinVersion(version protocols.Version) *ConfigurationBuilder {
+	b.MinVersion = version
+	return b
+}
+
+// WithNextProtos sets the supported application level protocols (i.e., ALPN) in order of preference.
+func (b *ConfigurationBuilder) WithNextProtos(protos []string) *ConfigurationBuilder {
+	b.NextProtos = protos
+	return b
+}
+
+// WithPassphrase sets the passphrase used to decrypt the key when it is encrypted. The value must be a URL that points to
+// the location of the passphrase.
+//
+// Note that in addition to those schemes supported by [getter](https://godoc.org/github.com/hashicorp/go-getter) a
+// "base64" scheme is supported for providing the passphrase in the path of the URL directly. This is most applicable
+// when the passphrase must be provided via an environement variable.
+func (b *ConfigurationBuilder) WithPassphrase(passphrase string) *ConfigurationBuilder {
+	b.Passphrase = passphrase
+	return b
+}
+
+// WithPKCS12 sets a PKCS #12 archive holding the server certificate, key and certificate chain. The value must be a URL
+// that points to the location of a PKCS #12 (i.e., .p12 or .pfx) file and the password must be provided using
+// WithPassphrase.
+//
+// Note that in addition to those schemes supported by [getter](https://godoc.org/github.com/hashicorp/go-getter) a
+// "base64" scheme is supported for providing the PKCS #12 archive in the path of the URL directly. This is most
+// applicable when the archive must be provided via an environement variable.
+func (b *ConfigurationBuilder) WithPKCS12(pkcs12 string) *ConfigurationBuilder {
+	b.PKCS12 = pkcs12
+	return b
+}
+
+// WithPolicy sets the CEL expression that must evaluate to true for the verified client certificate (e.g.,
+// "peer.subject.organizationalUnit.exists(o, o == 'payments')").
+func (b *ConfigurationBuilder) WithPolicy(policy string) *ConfigurationBuilder {
+	b.Policy = policy
+	return b
+}
+
+// WithProfile sets the vetted set of version, cipher suite and curve settings (e.g., protocols.IntermediateProfile).
+// Note that the settings provided via WithCipherSuites, WithCurvePreferences, WithMaxVersion and WithMinVersion override
+// those of the profile.
+func (b *ConfigurationBuilder) WithProfile(profile protocols.Profile) *ConfigurationBuilder {
+	b.Profile = profile
+	return b
+}
+
+// WithReload sets the minimum interval (e.g., "30s") between checks for changes to the content of the resources. When set
+// certificates, authorities and revocation lists are reloaded without a restart.
+func (b *ConfigurationBuilder) WithReload(reload string) *ConfigurationBuilder {
+	b.Reload = reload
+	return b
+}
+
+// WithRevocations sets the certificate revocation lists used to reject revoked client certificates. The values must be
+// URLs that point to the locations of PEM or DER encoded revocation lists.
+//
+// Note that in addition to those schemes supported by [getter](https://godoc.org/github.com/hashicorp/go-getter) a
+// "base64" scheme is supported for providing the revocation list in the path of the URL directly. This is most
+// applicable when the revocation list must be provided via an environement variable.
+func (b *ConfigurationBuilder) WithRevocations(revocations []string) *ConfigurationBuilder {
+	b.Revocations = revocations
+	return b
+}
+
+// WithSPIFFE sets the rules matching the SPIFFE ID of the client certificate of which at least one must match.
+func (b *ConfigurationBuilder) WithSPIFFE(matchers []spiffe.Matcher) *ConfigurationBuilder {
+	b.SPIFFE = matchers
+	return b
+}
+
+// WithStaple sets an OCSP response stapled to the server certificate in place of one fetched from the OCSP responders
+// named by the certificate. The value must be a URL that points to the location of a DER encoded OCSP response.
+//
+// Note that in addition to those schemes supported by [getter](https://godoc.org/github.com/hashicorp/go-getter) a
+// "base64" scheme is supported for providing the OCSP response in the path of the URL directly. This is most
+// applicable when the response must be provided via an environement variable.
+func (b *ConfigurationBuilder) WithStaple(staple string) *ConfigurationBuilder {
+	b.Staple = staple
+	return b
+}
+
+// WithStapling sets whether an OCSP response fetched from the OCSP responders named by the server certificate is
+// stapled to the certificate.
+func (b *ConfigurationBuilder) WithStapling(stapling bool) *ConfigurationBuilder {
+	b.Stapling = stapling
+	return b
+}
+
 // WithAuthentication sets the client authentication mode for mTLS connections.
 func (b *ConfigurationBuilder) WithAuthentication(authentication Authentication) *ConfigurationBuilder {
 	b.Authentication = authentication
@@ -0,0 +1,459 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package servers
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/greymatter-io/nautls/clients"
+	"github.com/greymatter-io/nautls/encoding"
+	"github.com/greymatter-io/nautls/identities"
+	"github.com/greymatter-io/nautls/internal/tests"
+	"github.com/greymatter-io/nautls/internal/tests/fixtures"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// served returns the common name of the certificate served to a client by an address.
+func served(client *http.Client, address string) (string, error) {
+
+	response, err := client.Get(fmt.Sprintf("https://%s", address))
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	return response.TLS.PeerCertificates[0].Subject.CommonName, nil
+}
+
+func TestConfigurationSNI(t *testing.T) {
+
+	authority := fixtures.MustAuthority(t, "NauTLS (Authority)", identities.ECDSA)
+	authorities := []string{fixtures.Base64Resource(encoding.PEMEncodeCertificate(authority.Certificate))}
+
+	defaultCertificate, defaultKey := fixtures.MustIssueServer(t, authority, identities.ECDSA, "default", "localhost", "default.nautls.test")
+	exactCertificate, exactKey := fixtures.MustIssueServer(t, authority, identities.ECDSA, "exact", "api.nautls.test")
+	wildcardCertificate, wildcardKey := fixtures.MustIssueServer(t, authority, identities.ECDSA, "wildcard", "*.nautls.test")
+
+	Convey("When Configuration", t, func() {
+
+		configuration := &Configuration{
+			Certificate: defaultCertificate,
+			Key:         defaultKey,
+			Certificates: []NamedCertificate{
+				{Name: "wildcard", Certificate: wildcardCertificate, Key: wildcardKey},
+				{Name: "exact", Certificate: exactCertificate, Key: exactKey, Servers: []string{"api.nautls.test"}},
+			},
+		}
+
+		config, err := configuration.TLS()
+		So(err, ShouldBeNil)
+
+		address, server := tests.MustServe(t, config)
+		defer server.Close()
+
+		client := func(name string) *http.Client {
+			return &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true, ServerName: name}}}
+		}
+
+		Convey(".TLS is invoked with named certificates", func() {
+
+			Convey("it serves the exactly matching certificate", func() {
+				name, err := served(client("api.nautls.test"), address)
+				So(err, ShouldBeNil)
+				So(name, ShouldEqual, "exact")
+			})
+
+			Convey("it serves the wildcard certificate for other names", func() {
+				name, err := served(client("www.nautls.test"), address)
+				So(err, ShouldBeNil)
+				So(name, ShouldEqual, "wildcard")
+			})
+
+			Convey("it serves the default certificate for unmatched names", func() {
+				name, err := served(client("localhost"), address)
+				So(err, ShouldBeNil)
+				So(name, ShouldEqual, "default")
+			})
+
+			Convey("it serves the default certificate for names beneath a wildcard's single label", func() {
+				name, err := served(client("a.b.nautls.test"), address)
+				So(err, ShouldBeNil)
+				So(name, ShouldEqual, "default")
+			})
+
+			Convey("it serves a certificate verifiable by a client", func() {
+				client, _ := (&clients.Configuration{Authorities: authorities, Server: "api.nautls.test"}).HTTP()
+				name, err := served(client, address)
+				So(err, ShouldBeNil)
+				So(name, ShouldEqual, "exact")
+			})
+		})
+
+		Convey(".TLS is invoked with a named certificate marked as the default", func() {
+
+			configuration.Certificates[0].Default = true
+
+			config, err := configuration.TLS()
+			So(err, ShouldBeNil)
+
+			address, server := tests.MustServe(t, config)
+			defer server.Close()
+
+			Convey("it serves the named default certificate for unmatched names", func() {
+				name, err := served(client("unknown.test"), address)
+				So(err, ShouldBeNil)
+				So(name, ShouldEqual, "wildcard")
+			})
+
+			Convey("it serves the certificate of the configuration for matching names", func() {
+				name, err := served(client("localhost"), address)
+				So(err, ShouldBeNil)
+				So(name, ShouldEqual, "default")
+			})
+		})
+
+		Convey(".TLS is invoked with an invalid named certificate", func() {
+
+			configuration.Certificates[0].Key = fixtures.Base64Resource([]byte("invalid"))
+
+			config, err := configuration.TLS()
+
+			Convey("it returns a non-nil error", func() {
+				So(err, ShouldNotBeNil)
+			})
+
+			Convey("it returns a nil configuration", func() {
+				So(config, ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestSecurityConfigDualAlgorithm(t *testing.T) {
+
+	authority := fixtures.MustAuthority(t, "NauTLS (Authority)", identities.ECDSA)
+
+	rsaCertificate, rsaKey := fixtures.MustIssueServer(t, authority, identities.RSA, "rsa", "localhost")
+	ecdsaCertificate, ecdsaKey := fixtures.MustIssueServer(t, authority, identities.ECDSA, "ecdsa", "localhost")
+
+	algorithm := func(address string, config *tls.Config) (x509.PublicKeyAlgorithm, error) {
+
+		config.InsecureSkipVerify = true
+
+		if config.ServerName == "" {
+			config.ServerName = "localhost"
+		}
+
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: config}}
+
+		response, err := client.Get(fmt.Sprintf("https://%s", address))
+		if err != nil {
+			return x509.UnknownPublicKeyAlgorithm, err
+		}
+		defer response.Body.Close()
+
+		return response.TLS.PeerCertificates[0].PublicKeyAlgorithm, nil
+	}
+
+	Convey("When SecurityConfig", t, func() {
+
+		Convey(".Build is invoked with RSA and ECDSA certificates for the same name", func() {
+
+			configuration := &SecurityConfig{
+				Certificate: rsaCertificate,
+				Key:         rsaKey,
+				Certificates: []NamedCertificate{
+					{Name: "ecdsa", Certificate: ecdsaCertificate, Key: ecdsaKey},
+				},
+			}
+
+			config, err := configuration.Build()
+			So(err, ShouldBeNil)
+
+			address, server := tests.MustServe(t, config)
+			defer server.Close()
+
+			Convey("it serves the ECDSA certificate to modern clients", func() {
+				actual, err := algorithm(address, &tls.Config{})
+				So(err, ShouldBeNil)
+				So(actual, ShouldEqual, x509.ECDSA)
+			})
+
+			Convey("it serves the ECDSA certificate to clients restricted to ECDSA", func() {
+				actual, err := algorithm(address, &tls.Config{
+					CipherSuites: []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256},
+					MaxVersion:   tls.VersionTLS12,
+				})
+				So(err, ShouldBeNil)
+				So(actual, ShouldEqual, x509.ECDSA)
+			})
+
+			Convey("it serves the RSA certificate to clients restricted to RSA", func() {
+				actual, err := algorithm(address, &tls.Config{
+					CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256},
+					MaxVersion:   tls.VersionTLS12,
+				})
+				So(err, ShouldBeNil)
+				So(actual, ShouldEqual, x509.RSA)
+			})
+		})
+
+		Convey(".Build is invoked with only named certificates for the same name", func() {
+
+			configuration := &SecurityConfig{
+				Certificates: []NamedCertificate{
+					{Name: "rsa", Certificate: rsaCertificate, Key: rsaKey},
+					{Name: "ecdsa", Certificate: ecdsaCertificate, Key: ecdsaKey},
+				},
+			}
+
+			config, err := configuration.Build()
+			So(err, ShouldBeNil)
+
+			address, server := tests.MustServe(t, config)
+			defer server.Close()
+
+			Convey("it serves both certificates as defaults for unmatched server names", func() {
+
+				actual, err := algorithm(address, &tls.Config{
+					CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256},
+					MaxVersion:   tls.VersionTLS12,
+					ServerName:   "unknown.test",
+				})
+				So(err, ShouldBeNil)
+				So(actual, ShouldEqual, x509.RSA)
+			})
+		})
+	})
+}
+
+// request returns the error of a request to an address.
+func request(client *http.Client, address string) error {
+
+	response, err := client.Get(fmt.Sprintf("https://%s", address))
+	if err != nil {
+		return err
+	}
+
+	return response.Body.Close()
+}
+
+// mustIssueClient returns a PEM encoded client certificate and key issued by an authority for an organizational unit or
+// fails a test.
+func mustIssueClient(t *testing.T, authority *identities.Identity, name string, unit string) (string, string) {
+
+	return fixtures.Resources(fixtures.MustIssue(t, authority, identities.Template{
+		EmailAddresses: []string{fmt.Sprintf("%s@nautls.com", name)},
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		KeyAlgorithm:   identities.ECDSA,
+		Subject:        pkix.Name{CommonName: name, OrganizationalUnit: []string{unit}},
+	}))
+}
+
+func TestConfigurationPolicy(t *testing.T) {
+
+	authority := fixtures.MustAuthority(t, "NauTLS (Authority)", identities.ECDSA)
+	authorities := []string{fixtures.Base64Resource(encoding.PEMEncodeCertificate(authority.Certificate))}
+
+	serverCertificate, serverKey := fixtures.MustIssueServer(t, authority, identities.ECDSA, "localhost", "localhost")
+	paymentsCertificate, paymentsKey := mustIssueClient(t, authority, "checkout", "payments")
+	ledgerCertificate, ledgerKey := mustIssueClient(t, authority, "ledger", "accounting")
+
+	serve := func(policy string) (string, io.Closer) {
+
+		config, err := (&Configuration{
+			Authentication: Authentication(tls.RequireAndVerifyClientCert),
+			Authorities:    authorities,
+			Certificate:    serverCertificate,
+			Key:            serverKey,
+			Policy:         policy,
+		}).TLS()
+		So(err, ShouldBeNil)
+
+		return tests.MustServe(t, config)
+	}
+
+	client := func(certificate string, key string) *http.Client {
+
+		client, err := (&clients.Configuration{
+			Authorities: authorities,
+			Certificate: certificate,
+			Key:         key,
+			Server:      "localhost",
+		}).HTTP()
+		So(err, ShouldBeNil)
+
+		return client
+	}
+
+	Convey("When Configuration defines a policy on the organizational unit", t, func() {
+
+		address, server := serve(`peer.subject.organizationalUnit.exists(o, o == "payments")`)
+		defer server.Close()
+
+		Convey("and the client belongs to the organizational unit", func() {
+
+			Convey("it accepts the connection", func() {
+				So(request(client(paymentsCertificate, paymentsKey), address), ShouldBeNil)
+			})
+		})
+
+		Convey("and the client belongs to another organizational unit", func() {
+
+			Convey("it rejects the connection", func() {
+				So(request(client(ledgerCertificate, ledgerKey), address), ShouldNotBeNil)
+			})
+		})
+	})
+
+	Convey("When Configuration defines a policy on the names, issuer and validity", t, func() {
+
+		address, server := serve(`peer.emailAddresses.exists(e, e.endsWith("@nautls.com")) && ` +
+			`peer.issuer.commonName == "NauTLS (Authority)" && peer.notAfter > timestamp("2020-01-01T00:00:00Z") && ` +
+			`peer.subject.commonName != "ledger" && size(peer.serialNumber) > 0`)
+		defer server.Close()
+
+		Convey("it accepts the matching client", func() {
+			So(request(client(paymentsCertificate, paymentsKey), address), ShouldBeNil)
+		})
+
+		Convey("it rejects the other client", func() {
+			So(request(client(ledgerCertificate, ledgerKey), address), ShouldNotBeNil)
+		})
+	})
+
+	Convey("When Configuration defines an invalid policy", t, func() {
+
+		_, err := (&Configuration{Certificate: serverCertificate, Key: serverKey, Policy: "peer.subject.("}).TLS()
+
+		Convey("it returns a non-nil error", func() {
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("When Configuration defines a policy without a boolean result", t, func() {
+
+		_, err := (&Configuration{Certificate: serverCertificate, Key: serverKey, Policy: "size(peer.uris) + 1"}).TLS()
+
+		Convey("it returns a non-nil error", func() {
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("When Configuration defines a policy and does not verify clients", t, func() {
+
+		config, err := (&Configuration{Certificate: serverCertificate, Key: serverKey, Policy: "true"}).TLS()
+		So(err, ShouldBeNil)
+
+		address, server := tests.MustServe(t, config)
+		defer server.Close()
+
+		Convey("it rejects clients without a verified certificate", func() {
+			So(request(client(paymentsCertificate, paymentsKey), address), ShouldNotBeNil)
+		})
+	})
+}
+
+func TestConfigurationPKCS12(t *testing.T) {
+
+	authority := fixtures.MustAuthority(t, "NauTLS (Authority)", identities.ECDSA)
+	identity := fixtures.MustIssue(t, authority, identities.Template{
+		DNSNames:     []string{"localhost"},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		KeyAlgorithm: identities.ECDSA,
+		Subject:      pkix.Name{CommonName: "localhost"},
+	})
+
+	archive, err := identity.ToPKCS12("secret")
+	if err != nil {
+		t.Fatalf("error encoding pkcs12 archive [%s]", err)
+	}
+
+	certificate, key := fixtures.Resources(identity)
+	pkcs12 := fixtures.Base64Resource(archive)
+	passphrase := fixtures.Base64Resource([]byte("secret"))
+
+	Convey("When Configuration", t, func() {
+
+		Convey(".TLS is invoked with a pkcs12 archive", func() {
+
+			config, err := (&Configuration{PKCS12: pkcs12, Passphrase: passphrase}).TLS()
+
+			Convey("it returns a nil error", func() {
+				So(err, ShouldBeNil)
+			})
+
+			Convey("it returns a configuration with the certificate of the archive", func() {
+				So(config.Certificates, ShouldHaveLength, 1)
+			})
+		})
+
+		Convey(".TLS is invoked with both a key pair and a pkcs12 archive", func() {
+
+			config, err := (&Configuration{Certificate: certificate, Key: key, PKCS12: pkcs12, Passphrase: passphrase}).TLS()
+
+			Convey("it returns a non-nil error", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "certificate and key must not be defined with pkcs12")
+			})
+
+			Convey("it returns a nil configuration", func() {
+				So(config, ShouldBeNil)
+			})
+		})
+
+		Convey(".TLS is invoked with a named certificate defining both a key pair and a pkcs12 archive", func() {
+
+			config, err := (&Configuration{
+				Certificates: []NamedCertificate{
+					{Name: "both", Certificate: certificate, Key: key, PKCS12: pkcs12, Passphrase: passphrase},
+				},
+			}).TLS()
+
+			Convey("it returns a non-nil error", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "certificate and key must not be defined with pkcs12")
+			})
+
+			Convey("it returns a nil configuration", func() {
+				So(config, ShouldBeNil)
+			})
+		})
+	})
+
+	Convey("When SecurityConfig", t, func() {
+
+		Convey(".Build is invoked with both a key pair and a pkcs12 archive", func() {
+
+			config, err := (&SecurityConfig{Certificate: certificate, Key: key, PKCS12: pkcs12, Passphrase: passphrase}).Build()
+
+			Convey("it returns a non-nil error", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "certificate and key must not be defined with pkcs12")
+			})
+
+			Convey("it returns a nil configuration", func() {
+				So(config, ShouldBeNil)
+			})
+		})
+	})
+}
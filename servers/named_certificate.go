@@ -0,0 +1,147 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package servers
+
+import (
+	"crypto/tls"
+
+	"github.com/greymatter-io/nautls/builders"
+	"github.com/pkg/errors"
+)
+
+// NamedCertificate provides a serializable representation of a server certificate selected by the server name of a
+// ClientHello.
+type NamedCertificate struct {
+
+	// Name defines a descriptive name for the certificate used in error messages.
+	Name string `json:"name" mapstructure:"name" yaml:"name"`
+
+	// Certificate defines the server certificate. The value must be a URL that points to the location of a PEM encoded
+	// certificate.
+	//
+	// Note that in addition to those schemes supported by [getter](https://godoc.org/github.com/hashicorp/go-getter) a
+	// "base64" scheme is supported for providing the PEM encoded certifiate in the path of the URL directly. This is most
+	// applicable when the certificate data must be provided via an environement variable.
+	Certificate string `json:"certificate" mapstructure:"certificate" yaml:"certificate"`
+
+	// Default defines whether the certificate is served when the server name of a ClientHello matches no certificate or
+	// is not provided. Note that the first certificate is the default when no certificate is marked as a default.
+	Default bool `json:"default" mapstructure:"default" yaml:"default"`
+
+	// Key defines the server key. The value must be a URL that points to the location of a PEM encoded key.
+	//
+	// Note that in addition to those schemes supported by [getter](https://godoc.org/github.com/hashicorp/go-getter) a
+	// "base64" scheme is supported for providing the PEM encoded certifiate in the path of the URL directly. This is most
+	// applicable when the certificate data must be provided via an environement variable.
+	Key string `json:"key" mapstructure:"key" yaml:"key"`
+
+	// Passphrase defines the passphrase used to decrypt the key when it is encrypted and the password of the PKCS #12
+	// archive. The value must be a URL that points to the location of the passphrase.
+	//
+	// Note that in addition to those schemes supported by [getter](https://godoc.org/github.com/hashicorp/go-getter) a
+	// "base64" scheme is supported for providing the passphrase in the path of the URL directly. This is most applicable
+	// when the passphrase must be provided via an environement variable.
+	Passphrase string `json:"passphrase" mapstructure:"passphrase" yaml:"passphrase"`
+
+	// PKCS12 defines a PKCS #12 archive holding the server certificate, key and certificate chain as an alternative to the
+	// certificate and key. The value must be a URL that points to the location of a PKCS #12 (i.e., .p12 or .pfx) file.
+	//
+	// Note that in addition to those schemes supported by [getter](https://godoc.org/github.com/hashicorp/go-getter) a
+	// "base64" scheme is supported for providing the PKCS #12 archive in the path of the URL directly. This is most
+	// applicable when the archive must be provided via an environement variable.
+	PKCS12 string `json:"pkcs12" mapstructure:"pkcs12" yaml:"pkcs12"`
+
+	// Servers defines the server names and wildcard patterns (e.g., "*.example.com") for which the certificate is served.
+	// It defaults to the DNS subject alternative names of the certificate.
+	Servers []string `json:"servers" mapstructure:"servers" yaml:"servers"`
+}
+
+// build returns the certificates of the named certificate prepared for selection.
+func (n NamedCertificate) build(stapling bool, staplers *staplers) ([]builders.SNICertificate, error) {
+
+	certificates, err := builders.BuildIdentityCertificates(n.Certificate, n.Key, n.PKCS12, n.Passphrase)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error building certificates for [%s]", n.Name)
+	}
+
+	return buildSNICertificates(certificates, n.Servers, n.Default, stapling, "", staplers)
+}
+
+// resources returns the resources of the named certificate.
+func (n NamedCertificate) resources() []string {
+	return []string{n.Certificate, n.Key, n.Passphrase, n.PKCS12}
+}
+
+// buildSelector returns a function that selects among the certificates of a configuration and its named certificates by
+// server name along with all of the certificates.
+func buildSelector(certificates []tls.Certificate, named []NamedCertificate, stapling bool, staple string, staplers *staplers) (func(*tls.ClientHelloInfo) (*tls.Certificate, error), []tls.Certificate, error) {
+
+	marked := false
+	for _, certificate := range named {
+		marked = marked || certificate.Default
+	}
+
+	selections, err := buildSNICertificates(certificates, nil, !marked, stapling, staple, staplers)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error building default certificates")
+	}
+
+	for _, certificate := range named {
+
+		built, err := certificate.build(stapling, staplers)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "error building named certificate [%s]", certificate.Name)
+		}
+
+		selections = append(selections, built...)
+	}
+
+	selector, err := builders.BuildCertificateSelector(selections)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error building certificate selector")
+	}
+
+	all := []tls.Certificate{}
+	for _, selection := range selections {
+		all = append(all, selection.Certificate)
+	}
+
+	return selector, all, nil
+}
+
+// buildSNICertificates returns certificates prepared for selection with an optional stapler each.
+func buildSNICertificates(certificates []tls.Certificate, names []string, isDefault bool, stapling bool, staple string, staplers *staplers) ([]builders.SNICertificate, error) {
+
+	selections := []builders.SNICertificate{}
+
+	for _, certificate := range certificates {
+
+		selection := builders.SNICertificate{Certificate: certificate, Default: isDefault, Names: names}
+
+		if stapling || staple != "" {
+
+			stapler, err := staplers.build([]tls.Certificate{certificate}, staple)
+			if err != nil {
+				return nil, errors.Wrap(err, "error building stapler")
+			}
+
+			selection.Stapler = stapler
+		}
+
+		selections = append(selections, selection)
+	}
+
+	return selections, nil
+}
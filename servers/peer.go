@@ -0,0 +1,198 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package servers
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+
+	"github.com/greymatter-io/nautls/builders"
+	"github.com/greymatter-io/nautls/spiffe"
+	"github.com/pkg/errors"
+)
+
+// peerKey defines the context key of the peer of a request.
+type peerKey struct{}
+
+// Peer provides a structured representation of the identity of the client certificate of a request.
+//
+// Note that the certificate of a peer is only verified when the authentication mode verifies client certificates (i.e.,
+// "VerifyClientCertIfGiven" or "RequireAndVerifyClientCert"). For the "RequestClientCert" and "RequireAnyClientCert"
+// modes the fields describe the certificate as presented, the chain is the presented chain and the authority and SPIFFE
+// ID are never defined.
+type Peer struct {
+
+	// Authority defines the entry of the authorities of the configuration that verified the chain of the peer. Note that
+	// the value is empty when the peer is not verified or the chain was verified by the system certificates.
+	Authority string
+
+	// Certificate defines the certificate of the peer.
+	Certificate *x509.Certificate
+
+	// Chain defines the chain of the peer starting with its certificate.
+	Chain []*x509.Certificate
+
+	// DNSNames defines the DNS subject alternative names of the certificate.
+	DNSNames []string
+
+	// EmailAddresses defines the email subject alternative names of the certificate.
+	EmailAddresses []string
+
+	// Fingerprint defines the base64 encoded SHA-256 fingerprint of the certificate prefixed with "sha256/" (i.e., the
+	// format of a certificate pin).
+	Fingerprint string
+
+	// IPAddresses defines the IP subject alternative names of the certificate.
+	IPAddresses []net.IP
+
+	// Issuer defines the distinguished name of the issuer of the certificate (e.g., "CN=NauTLS (Authority)").
+	Issuer string
+
+	// SPIFFE defines the SPIFFE ID of the certificate when it is a verified X.509-SVID and nil otherwise.
+	SPIFFE *spiffe.ID
+
+	// Subject defines the distinguished name of the subject of the certificate (e.g., "CN=web,OU=payments").
+	Subject string
+
+	// URIs defines the URI subject alternative names of the certificate.
+	URIs []*url.URL
+
+	// Verified defines whether the chain of the peer was verified.
+	Verified bool
+}
+
+// Peers identifies the peers of connections using the authorities of a server configuration.
+type Peers struct {
+	index map[string]string
+}
+
+// NewPeers returns a Peers for the authorities of a server configuration which are used to identify the entry that
+// verified a peer.
+//
+// Note that the authorities are read when the instance is created and that a peer verified by an authority added by a
+// later reload is reported without an authority.
+func NewPeers(authorities []string) (*Peers, error) {
+
+	index, err := builders.BuildAuthorityIndex(authorities)
+	if err != nil {
+		return nil, errors.Wrap(err, "error building authority index")
+	}
+
+	return &Peers{index: index}, nil
+}
+
+// NewPeerMiddleware returns an http.Handler middleware that stores the Peer of each request presenting a client
+// certificate in the context of the request for retrieval using PeerFromRequest or PeerFromContext. The authorities
+// must be the authorities of the server configuration. See NewPeers.
+func NewPeerMiddleware(authorities []string) (func(http.Handler) http.Handler, error) {
+
+	peers, err := NewPeers(authorities)
+	if err != nil {
+		return nil, errors.Wrap(err, "error building peers")
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+
+			peer := peers.Peer(request.TLS)
+			if peer != nil {
+				request = request.WithContext(ContextWithPeer(request.Context(), peer))
+			}
+
+			next.ServeHTTP(writer, request)
+		})
+	}, nil
+}
+
+// PeerMiddleware returns an http.Handler middleware that stores the Peer of each request presenting a client
+// certificate in the context of the request. See NewPeerMiddleware.
+func (c *Configuration) PeerMiddleware() (func(http.Handler) http.Handler, error) {
+	return NewPeerMiddleware(c.Authorities)
+}
+
+// ContextWithPeer returns a copy of a context holding a peer.
+func ContextWithPeer(ctx context.Context, peer *Peer) context.Context {
+	return context.WithValue(ctx, peerKey{}, peer)
+}
+
+// PeerFromContext returns the peer held by a context. Note that false is returned when the context does not hold a peer
+// (e.g., the request was not made over TLS or did not present a client certificate).
+func PeerFromContext(ctx context.Context) (*Peer, bool) {
+	peer, ok := ctx.Value(peerKey{}).(*Peer)
+	return peer, ok && peer != nil
+}
+
+// PeerFromRequest returns the peer stored in the context of a request by the peer middleware.
+func PeerFromRequest(request *http.Request) (*Peer, bool) {
+	return PeerFromContext(request.Context())
+}
+
+// Peer returns the peer of a connection state or nil if the state does not hold a client certificate.
+func (p *Peers) Peer(state *tls.ConnectionState) *Peer {
+
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return nil
+	}
+
+	chain := state.PeerCertificates
+	authority := ""
+	verified := len(state.VerifiedChains) > 0
+
+	if verified {
+
+		chain = state.VerifiedChains[0]
+
+		for _, candidate := range state.VerifiedChains {
+			if resource, ok := p.index[string(candidate[len(candidate)-1].Raw)]; ok {
+				chain = candidate
+				authority = resource
+				break
+			}
+		}
+	}
+
+	certificate := chain[0]
+	fingerprint := sha256.Sum256(certificate.Raw)
+
+	peer := &Peer{
+		Authority:      authority,
+		Certificate:    certificate,
+		Chain:          chain,
+		DNSNames:       certificate.DNSNames,
+		EmailAddresses: certificate.EmailAddresses,
+		Fingerprint:    fmt.Sprintf("sha256/%s", base64.StdEncoding.EncodeToString(fingerprint[:])),
+		IPAddresses:    certificate.IPAddresses,
+		Issuer:         certificate.Issuer.String(),
+		Subject:        certificate.Subject.String(),
+		URIs:           certificate.URIs,
+		Verified:       verified,
+	}
+
+	if verified {
+		id, err := spiffe.FromCertificate(certificate)
+		if err == nil {
+			peer.SPIFFE = &id
+		}
+	}
+
+	return peer
+}
@@ -0,0 +1,232 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package servers
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/greymatter-io/nautls/clients"
+	"github.com/greymatter-io/nautls/encoding"
+	"github.com/greymatter-io/nautls/identities"
+	"github.com/greymatter-io/nautls/internal/tests/fixtures"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// describedPeer provides the description of a peer returned by the handler of the peer middleware tests.
+type describedPeer struct {
+	Authority   string
+	Chain       int
+	Fingerprint string
+	Found       bool
+	SPIFFE      string
+	Subject     string
+	Verified    bool
+}
+
+// describePeer is an http.Handler that responds with the description of the peer of a request.
+func describePeer(writer http.ResponseWriter, request *http.Request) {
+
+	description := describedPeer{}
+
+	peer, ok := PeerFromRequest(request)
+	if ok {
+		description = describedPeer{
+			Authority:   peer.Authority,
+			Chain:       len(peer.Chain),
+			Fingerprint: peer.Fingerprint,
+			Found:       true,
+			Subject:     peer.Subject,
+			Verified:    peer.Verified,
+		}
+		if peer.SPIFFE != nil {
+			description.SPIFFE = peer.SPIFFE.String()
+		}
+	}
+
+	json.NewEncoder(writer).Encode(description)
+}
+
+// describe returns the description of the peer of a client by a URL.
+func describe(client *http.Client, url string) (describedPeer, error) {
+
+	description := describedPeer{}
+
+	response, err := client.Get(url)
+	if err != nil {
+		return description, err
+	}
+	defer response.Body.Close()
+
+	err = json.NewDecoder(response.Body).Decode(&description)
+	return description, err
+}
+
+func TestConfigurationPeerMiddleware(t *testing.T) {
+
+	template, err := identities.SVIDAuthorityTemplate("example.org", time.Hour)
+	if err != nil {
+		t.Fatalf("error creating svid authority template [%s]", err)
+	}
+
+	authority, err := identities.Self(template)
+	if err != nil {
+		t.Fatalf("error creating svid authority [%s]", err)
+	}
+
+	other := fixtures.MustAuthority(t, "NauTLS (Authority)", identities.ECDSA)
+
+	authorities := []string{
+		fixtures.Base64Resource(encoding.PEMEncodeCertificate(other.Certificate)),
+		fixtures.Base64Resource(encoding.PEMEncodeCertificate(authority.Certificate)),
+	}
+
+	svid, err := identities.SVIDTemplate("spiffe://example.org/ns/production/sa/web", time.Hour)
+	if err != nil {
+		t.Fatalf("error creating svid template [%s]", err)
+	}
+
+	identity := fixtures.MustIssue(t, authority, svid)
+	leaf := identity.Certificate
+	fingerprint := sha256.Sum256(leaf.Raw)
+
+	serverCertificate, serverKey := fixtures.MustIssueServer(t, authority, identities.ECDSA, "localhost", "localhost")
+	clientCertificate, clientKey := fixtures.Resources(identity)
+
+	serve := func(authentication tls.ClientAuthType) *httptest.Server {
+
+		configuration := &Configuration{
+			Authentication: Authentication(authentication),
+			Authorities:    authorities,
+			Certificate:    serverCertificate,
+			Key:            serverKey,
+		}
+
+		middleware, err := configuration.PeerMiddleware()
+		So(err, ShouldBeNil)
+
+		config, err := configuration.TLS()
+		So(err, ShouldBeNil)
+
+		server := httptest.NewUnstartedServer(middleware(http.HandlerFunc(describePeer)))
+		server.TLS = config
+		server.StartTLS()
+
+		return server
+	}
+
+	client := func(certificate string, key string) *http.Client {
+
+		client, err := (&clients.Configuration{
+			Authorities: authorities,
+			Certificate: certificate,
+			Key:         key,
+			Server:      "localhost",
+		}).HTTP()
+		So(err, ShouldBeNil)
+
+		return client
+	}
+
+	Convey("When the peer middleware serves a server requiring verified client certificates", t, func() {
+
+		server := serve(tls.RequireAndVerifyClientCert)
+		defer server.Close()
+
+		description, err := describe(client(clientCertificate, clientKey), server.URL)
+		So(err, ShouldBeNil)
+
+		Convey("it stores the verified peer", func() {
+			So(description.Found, ShouldBeTrue)
+			So(description.Verified, ShouldBeTrue)
+			So(description.Subject, ShouldEqual, leaf.Subject.String())
+			So(description.Chain, ShouldEqual, 2)
+		})
+
+		Convey("it identifies the authority that verified the peer", func() {
+			So(description.Authority, ShouldEqual, authorities[1])
+		})
+
+		Convey("it stores the spiffe id and fingerprint of the peer", func() {
+			So(description.SPIFFE, ShouldEqual, "spiffe://example.org/ns/production/sa/web")
+			So(description.Fingerprint, ShouldEqual, "sha256/"+base64.StdEncoding.EncodeToString(fingerprint[:]))
+		})
+	})
+
+	Convey("When the peer middleware serves a server requesting unverified client certificates", t, func() {
+
+		server := serve(tls.RequireAnyClientCert)
+		defer server.Close()
+
+		description, err := describe(client(clientCertificate, clientKey), server.URL)
+		So(err, ShouldBeNil)
+
+		Convey("it stores the presented peer as unverified without an authority or spiffe id", func() {
+			So(description.Found, ShouldBeTrue)
+			So(description.Verified, ShouldBeFalse)
+			So(description.Subject, ShouldEqual, leaf.Subject.String())
+			So(description.Authority, ShouldBeEmpty)
+			So(description.SPIFFE, ShouldBeEmpty)
+		})
+	})
+
+	Convey("When the peer middleware serves a server verifying client certificates if given", t, func() {
+
+		server := serve(tls.VerifyClientCertIfGiven)
+		defer server.Close()
+
+		Convey("and the client does not present a certificate", func() {
+
+			description, err := describe(client("", ""), server.URL)
+			So(err, ShouldBeNil)
+
+			Convey("it does not store a peer", func() {
+				So(description.Found, ShouldBeFalse)
+			})
+		})
+	})
+
+	Convey("When the peer middleware serves plaintext requests", t, func() {
+
+		middleware, err := NewPeerMiddleware(nil)
+		So(err, ShouldBeNil)
+
+		server := httptest.NewServer(middleware(http.HandlerFunc(describePeer)))
+		defer server.Close()
+
+		description, err := describe(http.DefaultClient, server.URL)
+		So(err, ShouldBeNil)
+
+		Convey("it does not store a peer", func() {
+			So(description.Found, ShouldBeFalse)
+		})
+	})
+
+	Convey("When NewPeerMiddleware is invoked with an invalid authority", t, func() {
+
+		_, err := NewPeerMiddleware([]string{"base64:///invalid"})
+
+		Convey("it returns a non-nil error", func() {
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
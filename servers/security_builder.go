@@ -14,7 +14,12 @@
 
 package servers
 
-import "crypto/tls"
+import (
+	"crypto/tls"
+
+	"github.com/greymatter-io/nautls/protocols"
+	"github.com/greymatter-io/nautls/spiffe"
+)
 
 // SecurityBuilder provides an builder for server tls.Config instances.
 //
@@ -55,6 +60,25 @@ func (b *SecurityBuilder) WithCertificate(certificate string) *SecurityBuilder {
 	return b
 }
 
+// WithCertificates sets additional server certificates selected by the server name of a ClientHello (i.e., SNI). Several
+// certificates may be defined for the same server names (e.g., an ECDSA and an RSA certificate).
+func (b *SecurityBuilder) WithCertificates(certificates []NamedCertificate) *SecurityBuilder {
+	b.config.Certificates = certificates
+	return b
+}
+
+// WithCipherSuites sets the enabled TLS 1.0 to 1.2 cipher suites in order of preference.
+func (b *SecurityBuilder) WithCipherSuites(suites protocols.CipherSuites) *SecurityBuilder {
+	b.config.CipherSuites = suites
+	return b
+}
+
+// WithCurvePreferences sets the enabled key exchange curves in order of preference.
+func (b *SecurityBuilder) WithCurvePreferences(curves protocols.Curves) *SecurityBuilder {
+	b.config.CurvePreferences = curves
+	return b
+}
+
 // WithKey sets the server key. The value must be a URL that points to the location of a PEM encoded key.
 //
 // Note that in addition to those schemes supported by [getter](https://godoc.org/github.com/hashicorp/go-getter) a
@@ -65,6 +89,104 @@ func (b *SecurityBuilder) WithKey(key string) *SecurityBuilder {
 	return b
 }
 
+// WithMaxVersion sets the maximum TLS version.
+func (b *SecurityBuilder) WithMaxVersion(version protocols.Version) *SecurityBuilder {
+	b.config.MaxVersion = version
+	return b
+}
+
+// WithMinVersion sets the minimum TLS version.
+func (b *SecurityBuilder) WithMinVersion(version protocols.Version) *SecurityBuilder {
+	b.config.MinVersion = version
+	return b
+}
+
+// WithNextProtos sets the supported application level protocols (i.e., ALPN) in order of preference.
+func (b *SecurityBuilder) WithNextProtos(protos []string) *SecurityBuilder {
+	b.config.NextProtos = protos
+	return b
+}
+
+// WithPassphrase sets the passphrase used to decrypt the key when it is encrypted. The value must be a URL that points to
+// the location of the passphrase.
+//
+// Note that in addition to those schemes supported by [getter](https://godoc.org/github.com/hashicorp/go-getter) a
+// "base64" scheme is supported for providing the passphrase in the path of the URL directly. This is most applicable
+// when the passphrase must be provided via an environement variable.
+func (b *SecurityBuilder) WithPassphrase(passphrase string) *SecurityBuilder {
+	b.config.Passphrase = passphrase
+	return b
+}
+
+// WithPKCS12 sets a PKCS #12 archive holding the server certificate, key and certificate chain. The value must be a URL
+// that points to the location of a PKCS #12 (i.e., .p12 or .pfx) file and the password must be provided using
+// WithPassphrase.
+//
+// Note that in addition to those schemes supported by [getter](https://godoc.org/github.com/hashicorp/go-getter) a
+// "base64" scheme is supported for providing the PKCS #12 archive in the path of the URL directly. This is most
+// applicable when the archive must be provided via an environement variable.
+func (b *SecurityBuilder) WithPKCS12(pkcs12 string) *SecurityBuilder {
+	b.config.PKCS12 = pkcs12
+	return b
+}
+
+// WithPolicy sets the CEL expression that must evaluate to true for the verified client certificate (e.g.,
+// "peer.subject.organizationalUnit.exists(o, o == 'payments')").
+func (b *SecurityBuilder) WithPolicy(policy string) *SecurityBuilder {
+	b.config.Policy = policy
+	return b
+}
+
+// WithProfile sets the vetted set of version, cipher suite and curve settings (e.g., protocols.IntermediateProfile).
+// Note that the settings provided via WithCipherSuites, WithCurvePreferences, WithMaxVersion and WithMinVersion override
+// those of the profile.
+func (b *SecurityBuilder) WithProfile(profile protocols.Profile) *SecurityBuilder {
+	b.config.Profile = profile
+	return b
+}
+
+// WithReload sets the minimum interval (e.g., "30s") between checks for changes to the content of the resources. When set
+// certificates, authorities and revocation lists are reloaded without a restart.
+func (b *SecurityBuilder) WithReload(reload string) *SecurityBuilder {
+	b.config.Reload = reload
+	return b
+}
+
+// WithRevocations sets the certificate revocation lists used to reject revoked client certificates. The values must be
+// URLs that point to the locations of PEM or DER encoded revocation lists.
+//
+// Note that in addition to those schemes supported by [getter](https://godoc.org/github.com/hashicorp/go-getter) a
+// "base64" scheme is supported for providing the revocation list in the path of the URL directly. This is most
+// applicable when the revocation list must be provided via an environement variable.
+func (b *SecurityBuilder) WithRevocations(revocations []string) *SecurityBuilder {
+	b.config.Revocations = revocations
+	return b
+}
+
+// WithSPIFFE sets the rules matching the SPIFFE ID of the client certificate of which at least one must match.
+func (b *SecurityBuilder) WithSPIFFE(matchers []spiffe.Matcher) *SecurityBuilder {
+	b.config.SPIFFE = matchers
+	return b
+}
+
+// WithStaple sets an OCSP response stapled to the server certificate in place of one fetched from the OCSP responders
+// named by the certificate. The value must be a URL that points to the location of a DER encoded OCSP response.
+//
+// Note that in addition to those schemes supported by [getter](https://godoc.org/github.com/hashicorp/go-getter) a
+// "base64" scheme is supported for providing the OCSP response in the path of the URL directly. This is most
+// applicable when the response must be provided via an environement variable.
+func (b *SecurityBuilder) WithStaple(staple string) *SecurityBuilder {
+	b.config.Staple = staple
+	return b
+}
+
+// WithStapling sets whether an OCSP response fetched from the OCSP responders named by the server certificate is
+// stapled to the certificate.
+func (b *SecurityBuilder) WithStapling(stapling bool) *SecurityBuilder {
+	b.config.Stapling = stapling
+	return b
+}
+
 // WithAuthentication sets the client authentication mode for mTLS connections.
 func (b *SecurityBuilder) WithAuthentication(authentication Authentication) *SecurityBuilder {
 	b.config.Authentication = authentication
@@ -15,9 +15,12 @@
 package servers
 
 import (
+	"crypto/tls"
 	"testing"
 
 	"github.com/greymatter-io/nautls/internal/tests"
+	"github.com/greymatter-io/nautls/protocols"
+	"github.com/greymatter-io/nautls/spiffe"
 
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -63,6 +66,39 @@ func TestSecurityBuilder(t *testing.T) {
 			})
 		})
 
+		Convey(".WithCertificates is invoked", func() {
+
+			certificates := []NamedCertificate{{Name: tests.MustGenerateString(t), Servers: tests.MustGenerateStrings(t)}}
+
+			builder.WithCertificates(certificates)
+
+			Convey("it sets the certificates", func() {
+				So(builder.config.Certificates, ShouldResemble, certificates)
+			})
+		})
+
+		Convey(".WithCipherSuites is invoked", func() {
+
+			suites := protocols.CipherSuites{protocols.CipherSuite(tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256)}
+
+			builder.WithCipherSuites(suites)
+
+			Convey("it sets the cipher suites", func() {
+				So(builder.config.CipherSuites, ShouldResemble, suites)
+			})
+		})
+
+		Convey(".WithCurvePreferences is invoked", func() {
+
+			curves := protocols.Curves{protocols.X25519, protocols.P256}
+
+			builder.WithCurvePreferences(curves)
+
+			Convey("it sets the curve preferences", func() {
+				So(builder.config.CurvePreferences, ShouldResemble, curves)
+			})
+		})
+
 		Convey(".WithKey is invoked", func() {
 
 			key := tests.MustGenerateString(t)
@@ -74,6 +110,138 @@ func TestSecurityBuilder(t *testing.T) {
 			})
 		})
 
+		Convey(".WithMaxVersion is invoked", func() {
+
+			version := protocols.Version(tls.VersionTLS13)
+
+			builder.WithMaxVersion(version)
+
+			Convey("it sets the maximum version", func() {
+				So(builder.config.MaxVersion, ShouldEqual, version)
+			})
+		})
+
+		Convey(".WithMinVersion is invoked", func() {
+
+			version := protocols.Version(tls.VersionTLS12)
+
+			builder.WithMinVersion(version)
+
+			Convey("it sets the minimum version", func() {
+				So(builder.config.MinVersion, ShouldEqual, version)
+			})
+		})
+
+		Convey(".WithNextProtos is invoked", func() {
+
+			protos := tests.MustGenerateStrings(t)
+
+			builder.WithNextProtos(protos)
+
+			Convey("it sets the next protos", func() {
+				So(builder.config.NextProtos, ShouldResemble, protos)
+			})
+		})
+
+		Convey(".WithPassphrase is invoked", func() {
+
+			passphrase := tests.MustGenerateString(t)
+
+			builder.WithPassphrase(passphrase)
+
+			Convey("it sets the passphrase", func() {
+				So(builder.config.Passphrase, ShouldEqual, passphrase)
+			})
+		})
+
+		Convey(".WithPKCS12 is invoked", func() {
+
+			pkcs12 := tests.MustGenerateString(t)
+
+			builder.WithPKCS12(pkcs12)
+
+			Convey("it sets the pkcs12", func() {
+				So(builder.config.PKCS12, ShouldEqual, pkcs12)
+			})
+		})
+
+		Convey(".WithPolicy is invoked", func() {
+
+			policy := tests.MustGenerateString(t)
+
+			builder.WithPolicy(policy)
+
+			Convey("it sets the policy", func() {
+				So(builder.config.Policy, ShouldEqual, policy)
+			})
+		})
+
+		Convey(".WithProfile is invoked", func() {
+
+			profile := protocols.IntermediateProfile
+
+			builder.WithProfile(profile)
+
+			Convey("it sets the profile", func() {
+				So(builder.config.Profile, ShouldEqual, profile)
+			})
+		})
+
+		Convey(".WithReload is invoked", func() {
+
+			reload := tests.MustGenerateString(t)
+
+			builder.WithReload(reload)
+
+			Convey("it sets the reload", func() {
+				So(builder.config.Reload, ShouldEqual, reload)
+			})
+		})
+
+		Convey(".WithRevocations is invoked", func() {
+
+			revocations := tests.MustGenerateStrings(t)
+
+			builder.WithRevocations(revocations)
+
+			Convey("it sets the revocations", func() {
+				So(builder.config.Revocations, ShouldResemble, revocations)
+			})
+		})
+
+		Convey(".WithSPIFFE is invoked", func() {
+
+			matchers := []spiffe.Matcher{{TrustDomain: "example.org"}}
+
+			builder.WithSPIFFE(matchers)
+
+			Convey("it sets the spiffe matchers", func() {
+				So(builder.config.SPIFFE, ShouldResemble, matchers)
+			})
+		})
+
+		Convey(".WithStaple is invoked", func() {
+
+			staple := tests.MustGenerateString(t)
+
+			builder.WithStaple(staple)
+
+			Convey("it sets the staple", func() {
+				So(builder.config.Staple, ShouldEqual, staple)
+			})
+		})
+
+		Convey(".WithStapling is invoked", func() {
+
+			stapling := true
+
+			builder.WithStapling(stapling)
+
+			Convey("it sets the stapling", func() {
+				So(builder.config.Stapling, ShouldEqual, stapling)
+			})
+		})
+
 		Convey(".WithAuthentication is invoked", func() {
 
 			authentication := MustGenerateAuthentication(t)
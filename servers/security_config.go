@@ -16,8 +16,11 @@ package servers
 
 import (
 	"crypto/tls"
+	"time"
 
 	"github.com/greymatter-io/nautls/builders"
+	"github.com/greymatter-io/nautls/protocols"
+	"github.com/greymatter-io/nautls/spiffe"
 	"github.com/pkg/errors"
 )
 
@@ -27,7 +30,8 @@ import (
 type SecurityConfig struct {
 
 	// Authorities defines the trusted certificate authorities for verifying mTLS clients. The values must be URLs that
-	// point to the location of PEM encoded certificates.
+	// point to the location of PEM encoded certificates or SPIFFE bundle documents (e.g., the bundle endpoint of a
+	// federated trust domain).
 	//
 	// Note that in addition to those schemes supported by [getter](https://godoc.org/github.com/hashicorp/go-getter) a
 	// "base64" scheme is supported for providing the PEM encoded certifiate in the path of the URL directly. This is most
@@ -42,6 +46,25 @@ type SecurityConfig struct {
 	// applicable when the certificate data must be provided via an environement variable.
 	Certificate string `json:"certificate" mapstructure:"certificate" yaml:"certificate"`
 
+	// Certificates defines additional server certificates selected by the server name of a ClientHello (i.e., SNI). Several
+	// certificates may be defined for the same server names (e.g., an ECDSA and an RSA certificate) in which case each
+	// handshake is served the first certificate supported by the client preferring ECDSA and Ed25519 over RSA. The
+	// certificate and key (or PKCS #12 archive) of the configuration are served as the default unless a named
+	// certificate is marked as the default.
+	Certificates []NamedCertificate `json:"certificates" mapstructure:"certificates" yaml:"certificates"`
+
+	// CipherSuites defines the enabled TLS 1.0 to 1.2 cipher suites in order of preference. For serialization purposes
+	// the values must be the names of the crypto/tls cipher suite constants (e.g.,
+	// "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"). Note that TLS 1.3 cipher suites are not configurable and that the
+	// cipher suites of the profile are used when empty.
+	CipherSuites protocols.CipherSuites `json:"cipherSuites" mapstructure:"cipherSuites" yaml:"cipherSuites"`
+
+	// CurvePreferences defines the enabled key exchange curves in order of preference. For serialization purposes the
+	// values must be one of "P256", "P384", "P521", "X25519", "X25519Kyber768Draft00" or "X25519MLKEM768". Note that
+	// curves not implemented by the version of Go are removed, that building fails when none remain and that the curves
+	// of the profile are used when empty.
+	CurvePreferences protocols.Curves `json:"curvePreferences" mapstructure:"curvePreferences" yaml:"curvePreferences"`
+
 	// Key defines the server key. The value must be a URL that points to the location of a PEM encoded key.
 	//
 	// Note that in addition to those schemes supported by [getter](https://godoc.org/github.com/hashicorp/go-getter) a
@@ -49,6 +72,88 @@ type SecurityConfig struct {
 	// applicable when the certificate data must be provided via an environement variable.
 	Key string `json:"key" mapstructure:"key" yaml:"key"`
 
+	// MaxVersion defines the maximum TLS version. For serialization purposes the value must be one of "TLS1.0",
+	// "TLS1.1", "TLS1.2" or "TLS1.3". Note that the version of the profile is used when empty.
+	MaxVersion protocols.Version `json:"maxVersion" mapstructure:"maxVersion" yaml:"maxVersion"`
+
+	// MinVersion defines the minimum TLS version. For serialization purposes the value must be one of "TLS1.0",
+	// "TLS1.1", "TLS1.2" or "TLS1.3". Note that the version of the profile is used when empty.
+	MinVersion protocols.Version `json:"minVersion" mapstructure:"minVersion" yaml:"minVersion"`
+
+	// NextProtos defines the supported application level protocols (i.e., ALPN) in order of preference (e.g., "h2" and
+	// "http/1.1").
+	NextProtos []string `json:"nextProtos" mapstructure:"nextProtos" yaml:"nextProtos"`
+
+	// Passphrase defines the passphrase used to decrypt the key when it is encrypted (i.e., a legacy "Proc-Type:
+	// 4,ENCRYPTED" PEM block or an encrypted PKCS #8 block) and the password of the PKCS #12 archive. The value must be a
+	// URL that points to the location of the passphrase. Note that trailing line breaks are removed from the passphrase.
+	//
+	// Note that in addition to those schemes supported by [getter](https://godoc.org/github.com/hashicorp/go-getter) a
+	// "base64" scheme is supported for providing the passphrase in the path of the URL directly. This is most applicable
+	// when the passphrase must be provided via an environement variable.
+	Passphrase string `json:"passphrase" mapstructure:"passphrase" yaml:"passphrase"`
+
+	// PKCS12 defines a PKCS #12 archive holding the server certificate, key and certificate chain as an alternative to the
+	// certificate and key. The value must be a URL that points to the location of a PKCS #12 (i.e., .p12 or .pfx) file
+	// and the password must be provided using the passphrase.
+	//
+	// Note that in addition to those schemes supported by [getter](https://godoc.org/github.com/hashicorp/go-getter) a
+	// "base64" scheme is supported for providing the PKCS #12 archive in the path of the URL directly. This is most
+	// applicable when the archive must be provided via an environement variable.
+	PKCS12 string `json:"pkcs12" mapstructure:"pkcs12" yaml:"pkcs12"`
+
+	// Policy defines a CEL expression that must evaluate to true for the verified client certificate for a connection to
+	// be accepted (e.g., "peer.subject.organizationalUnit.exists(o, o == 'payments')"). The certificate is provided as
+	// the "peer" variable with "subject" and "issuer" names (e.g., "commonName" and "organizationalUnit"), "dnsNames",
+	// "emailAddresses", "ipAddresses" and "uris" lists, a decimal "serialNumber" and "notBefore" and "notAfter"
+	// timestamps. Note that clients are rejected unless their certificate is verified (e.g.,
+	// "RequireAndVerifyClientCert").
+	Policy string `json:"policy" mapstructure:"policy" yaml:"policy"`
+
+	// Profile defines a vetted set of version, cipher suite and curve settings modeled on the Mozilla server side TLS
+	// guidelines. The value must be one of "Default" (the defaults of the crypto/tls package), "Modern" (TLS 1.3 only),
+	// "Intermediate" (TLS 1.2 and later) or "Old" (TLS 1.0 and later). Note that the cipher suites, curve preferences,
+	// maximum version and minimum version of the configuration override those of the profile when defined.
+	Profile protocols.Profile `json:"profile" mapstructure:"profile" yaml:"profile"`
+
+	// Reload defines the minimum interval (e.g., "30s") between checks for changes to the content of the resources of
+	// the configuration. When defined certificates, authorities and revocation lists are reloaded without a restart
+	// (e.g., when a mounted Kubernetes secret is updated) and the last good values are used when a reload fails. Note that
+	// the value "0s" selects the default interval.
+	Reload string `json:"reload" mapstructure:"reload" yaml:"reload"`
+
+	// Revocations defines the certificate revocation lists used to reject revoked client certificates. The values must be
+	// URLs that point to the location of PEM or DER encoded revocation lists. Note that a revocation list is only applied
+	// to the certificates of its issuer and that connections are rejected if it is not signed by that issuer or is stale.
+	//
+	// Note that in addition to those schemes supported by [getter](https://godoc.org/github.com/hashicorp/go-getter) a
+	// "base64" scheme is supported for providing the revocation list in the path of the URL directly. This is most
+	// applicable when the revocation list must be provided via an environement variable.
+	Revocations []string `json:"revocations" mapstructure:"revocations" yaml:"revocations"`
+
+	// SPIFFE defines the rules matching the SPIFFE ID (i.e., the "spiffe" URI subject alternative name) of the client
+	// certificate of which at least one must match. Each rule must define exactly one of an exact "id" (e.g.,
+	// "spiffe://example.org/ns/production/sa/web"), a "prefix" matching the IDs beneath a path (e.g.,
+	// "spiffe://example.org/ns/production") or a "trustDomain" matching any ID of a trust domain (e.g., "example.org").
+	// Note that clients are rejected unless their certificate is verified (e.g., "RequireAndVerifyClientCert").
+	SPIFFE []spiffe.Matcher `json:"spiffe" mapstructure:"spiffe" yaml:"spiffe"`
+
+	// Staple defines an OCSP response stapled to the server certificate in place of one fetched from the OCSP responders
+	// named by the certificate. The value must be a URL that points to the location of a DER encoded OCSP response and
+	// is read again each time the staple is refreshed. Note that the value enables stapling.
+	//
+	// Note that in addition to those schemes supported by [getter](https://godoc.org/github.com/hashicorp/go-getter) a
+	// "base64" scheme is supported for providing the OCSP response in the path of the URL directly. This is most
+	// applicable when the response must be provided via an environement variable.
+	Staple string `json:"staple" mapstructure:"staple" yaml:"staple"`
+
+	// Stapling defines whether an OCSP response fetched from the OCSP responders named by the server certificate is
+	// stapled to the certificate. The response is fetched in the background and refreshed halfway between its this update
+	// and next update. Note that the certificate is served without a staple until the response is first fetched, that
+	// failures are reported to the OnError functions of the Reloader and that the certificate chain must include the
+	// issuer of the certificate.
+	Stapling bool `json:"stapling" mapstructure:"stapling" yaml:"stapling"`
+
 	// Authentication defines the client authentication mode for mTLS connections.
 	//
 	// For serialization puposes (i.e., JSON and YAML) the value must be the string representation of a tls.ClientAuthType
@@ -59,21 +164,131 @@ type SecurityConfig struct {
 // Build creates a tls.Config from the SecurityConfig instance.
 func (c *SecurityConfig) Build() (*tls.Config, error) {
 
+	if c.Reload != "" {
+
+		reloader, err := c.Reloader()
+		if err != nil {
+			return nil, errors.Wrap(err, "error building reloader")
+		}
+
+		return reloader.ServerConfig(), nil
+	}
+
+	return c.build(&staplers{})
+}
+
+// Reloader returns a builders.Reloader that rebuilds the tls.Config of the configuration when the content of its
+// resources changes at most once per the reload interval.
+func (c *SecurityConfig) Reloader() (*builders.Reloader, error) {
+
+	var interval time.Duration
+
+	if c.Reload != "" {
+
+		parsed, err := time.ParseDuration(c.Reload)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error parsing reload interval [%s]", c.Reload)
+		}
+
+		interval = parsed
+	}
+
+	return builders.BuildReloaderWithFailures(c.resources(), interval, reloadable(c.build))
+}
+
+// build returns a tls.Config instance from the current content of the resources of the configuration. Note that the
+// staplers of the tls.Config are collected by the staplers.
+func (c *SecurityConfig) build(staplers *staplers) (*tls.Config, error) {
+
 	pool, err := builders.BuildCertificatePool(c.Authorities)
 	if err != nil {
 		return nil, errors.Wrap(err, "error building certificate authority pool")
 	}
 
-	certificates, err := builders.BuildCertificates(c.Certificate, c.Key)
+	certificates, err := builders.BuildIdentityCertificates(c.Certificate, c.Key, c.PKCS12, c.Passphrase)
 	if err != nil {
 		return nil, errors.Wrap(err, "error building certificates")
 	}
 
+	verifier, err := builders.BuildRevocationVerifier(c.Revocations)
+	if err != nil {
+		return nil, errors.Wrap(err, "error building revocation verifier")
+	}
+
+	svids, err := builders.BuildSPIFFEVerifier(c.SPIFFE)
+	if err != nil {
+		return nil, errors.Wrap(err, "error building spiffe verifier")
+	}
+
+	policy, err := builders.BuildPolicyVerifier(c.Policy)
+	if err != nil {
+		return nil, errors.Wrap(err, "error building policy verifier")
+	}
+
+	settings := c.Profile.Settings(protocols.Settings{
+		CipherSuites:     c.CipherSuites,
+		CurvePreferences: c.CurvePreferences,
+		MaxVersion:       c.MaxVersion,
+		MinVersion:       c.MinVersion,
+	})
+
+	err = protocols.CheckVersions(settings.MinVersion, settings.MaxVersion)
+	if err != nil {
+		return nil, errors.Wrap(err, "error checking versions")
+	}
+
+	err = protocols.CheckCurves(settings.CurvePreferences)
+	if err != nil {
+		return nil, errors.Wrap(err, "error checking curves")
+	}
+
 	config := &tls.Config{
-		Certificates: certificates,
-		ClientAuth:   tls.ClientAuthType(c.Authentication),
-		ClientCAs:    pool,
+		Certificates:     certificates,
+		CipherSuites:     settings.CipherSuites.IDs(),
+		CurvePreferences: settings.CurvePreferences.Supported().IDs(),
+		MaxVersion:       uint16(settings.MaxVersion),
+		MinVersion:       uint16(settings.MinVersion),
+		NextProtos:       c.NextProtos,
+		ClientAuth:       tls.ClientAuthType(c.Authentication),
+		ClientCAs:        pool,
+		VerifyConnection: builders.BuildConnectionVerifier(verifier, svids, policy),
+	}
+
+	if len(c.Certificates) > 0 {
+
+		selector, all, err := buildSelector(certificates, c.Certificates, c.Stapling, c.Staple, staplers)
+		if err != nil {
+			return nil, errors.Wrap(err, "error building certificate selector")
+		}
+
+		config.Certificates = all
+		config.GetCertificate = selector
+
+		return config, nil
+	}
+
+	if c.Stapling || c.Staple != "" {
+
+		stapler, err := staplers.build(certificates, c.Staple)
+		if err != nil {
+			return nil, errors.Wrap(err, "error building stapler")
+		}
+
+		config.GetCertificate = stapler.GetCertificate
 	}
 
 	return config, nil
 }
+
+// resources returns the resources of the configuration.
+func (c *SecurityConfig) resources() []string {
+
+	resources := append([]string{}, c.Authorities...)
+	resources = append(resources, c.Certificate, c.Key, c.Passphrase, c.PKCS12, c.Staple)
+
+	for _, certificate := range c.Certificates {
+		resources = append(resources, certificate.resources()...)
+	}
+
+	return append(resources, c.Revocations...)
+}
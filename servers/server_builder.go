@@ -0,0 +1,80 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package servers
+
+// ServerBuilder provides a builder for ServerConfig instances.
+type ServerBuilder struct {
+	config ServerConfig
+}
+
+// NewServerBuilder intializes a new instance of the ServerBuilder structure.
+func NewServerBuilder() *ServerBuilder {
+	return &ServerBuilder{}
+}
+
+// Build returns a ServerConfig for the current state of the builder.
+func (b *ServerBuilder) Build() *ServerConfig {
+	config := b.config
+	return &config
+}
+
+// WithHost sets the hostname or address on which the server listens.
+func (b *ServerBuilder) WithHost(host string) *ServerBuilder {
+	b.config.Host = host
+	return b
+}
+
+// WithIdleTimeout sets the maximum duration (e.g., "2m") to wait for the next request on a keep-alive connection.
+func (b *ServerBuilder) WithIdleTimeout(timeout string) *ServerBuilder {
+	b.config.IdleTimeout = timeout
+	return b
+}
+
+// WithPort sets the port on which the server listens.
+func (b *ServerBuilder) WithPort(port int) *ServerBuilder {
+	b.config.Port = port
+	return b
+}
+
+// WithReadTimeout sets the maximum duration (e.g., "30s") for reading an entire request.
+func (b *ServerBuilder) WithReadTimeout(timeout string) *ServerBuilder {
+	b.config.ReadTimeout = timeout
+	return b
+}
+
+// WithSecurity sets the TLS configuration of the server.
+func (b *ServerBuilder) WithSecurity(security *Configuration) *ServerBuilder {
+	b.config.Security = security
+	return b
+}
+
+// WithShutdownTimeout sets the maximum duration (e.g., "10s") to wait for active connections when the server is shut
+// down.
+func (b *ServerBuilder) WithShutdownTimeout(timeout string) *ServerBuilder {
+	b.config.ShutdownTimeout = timeout
+	return b
+}
+
+// WithSocket sets the path of a unix domain socket on which the server listens in place of the host and port.
+func (b *ServerBuilder) WithSocket(socket string) *ServerBuilder {
+	b.config.Socket = socket
+	return b
+}
+
+// WithWriteTimeout sets the maximum duration (e.g., "30s") before timing out writes of a response.
+func (b *ServerBuilder) WithWriteTimeout(timeout string) *ServerBuilder {
+	b.config.WriteTimeout = timeout
+	return b
+}
@@ -0,0 +1,130 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package servers
+
+import (
+	"testing"
+
+	"github.com/greymatter-io/nautls/internal/tests"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestServerBuilder(t *testing.T) {
+
+	Convey("When ServerBuilder", t, func() {
+
+		builder := NewServerBuilder()
+
+		Convey(".WithHost is invoked", func() {
+
+			host := tests.MustGenerateString(t)
+
+			builder.WithHost(host)
+
+			Convey("it sets the host", func() {
+				So(builder.config.Host, ShouldEqual, host)
+			})
+		})
+
+		Convey(".WithIdleTimeout is invoked", func() {
+
+			timeout := tests.MustGenerateString(t)
+
+			builder.WithIdleTimeout(timeout)
+
+			Convey("it sets the idle timeout", func() {
+				So(builder.config.IdleTimeout, ShouldEqual, timeout)
+			})
+		})
+
+		Convey(".WithPort is invoked", func() {
+
+			port := tests.MustGenerateInt(t)
+
+			builder.WithPort(port)
+
+			Convey("it sets the port", func() {
+				So(builder.config.Port, ShouldEqual, port)
+			})
+		})
+
+		Convey(".WithReadTimeout is invoked", func() {
+
+			timeout := tests.MustGenerateString(t)
+
+			builder.WithReadTimeout(timeout)
+
+			Convey("it sets the read timeout", func() {
+				So(builder.config.ReadTimeout, ShouldEqual, timeout)
+			})
+		})
+
+		Convey(".WithSecurity is invoked", func() {
+
+			security := &Configuration{Certificate: tests.MustGenerateString(t), Key: tests.MustGenerateString(t)}
+
+			builder.WithSecurity(security)
+
+			Convey("it sets the security", func() {
+				So(builder.config.Security, ShouldEqual, security)
+			})
+		})
+
+		Convey(".WithShutdownTimeout is invoked", func() {
+
+			timeout := tests.MustGenerateString(t)
+
+			builder.WithShutdownTimeout(timeout)
+
+			Convey("it sets the shutdown timeout", func() {
+				So(builder.config.ShutdownTimeout, ShouldEqual, timeout)
+			})
+		})
+
+		Convey(".WithSocket is invoked", func() {
+
+			socket := tests.MustGenerateString(t)
+
+			builder.WithSocket(socket)
+
+			Convey("it sets the socket", func() {
+				So(builder.config.Socket, ShouldEqual, socket)
+			})
+		})
+
+		Convey(".WithWriteTimeout is invoked", func() {
+
+			timeout := tests.MustGenerateString(t)
+
+			builder.WithWriteTimeout(timeout)
+
+			Convey("it sets the write timeout", func() {
+				So(builder.config.WriteTimeout, ShouldEqual, timeout)
+			})
+		})
+
+		Convey(".Build is invoked", func() {
+
+			builder.WithHost("localhost").WithPort(8443)
+
+			config := builder.Build()
+
+			Convey("it returns the configuration", func() {
+				So(config, ShouldResemble, &ServerConfig{Host: "localhost", Port: 8443})
+			})
+		})
+	})
+}
@@ -0,0 +1,231 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package servers
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ServerConfig provides a serializable representation of an http.Server structure and its net.Listener.
+type ServerConfig struct {
+
+	// Host defines the hostname or address on which the server listens. Note that the server listens on all addresses
+	// when empty.
+	Host string `json:"host" mapstructure:"host" yaml:"host"`
+
+	// IdleTimeout defines the maximum duration (e.g., "2m") to wait for the next request on a keep-alive connection. Note
+	// that the read timeout is used when empty.
+	IdleTimeout string `json:"idleTimeout" mapstructure:"idleTimeout" yaml:"idleTimeout"`
+
+	// Port defines the port on which the server listens. Note that a port is chosen by the system when zero.
+	Port int `json:"port" mapstructure:"port" yaml:"port"`
+
+	// ReadTimeout defines the maximum duration (e.g., "30s") for reading an entire request including the body. Note that
+	// requests are not timed out when empty.
+	ReadTimeout string `json:"readTimeout" mapstructure:"readTimeout" yaml:"readTimeout"`
+
+	// Security defines the TLS configuration used by the server. Note that the server accepts plaintext connections when
+	// nil.
+	Security *Configuration `json:"security" mapstructure:"security" yaml:"security"`
+
+	// ShutdownTimeout defines the maximum duration (e.g., "10s") to wait for active connections to complete when the
+	// server is shut down after which they are closed. Note that active connections are awaited indefinitely when empty.
+	ShutdownTimeout string `json:"shutdownTimeout" mapstructure:"shutdownTimeout" yaml:"shutdownTimeout"`
+
+	// Socket defines the path of a unix domain socket on which the server listens in place of the host and port. Note
+	// that an existing socket at the path is removed (e.g., one left by a previous process).
+	Socket string `json:"socket" mapstructure:"socket" yaml:"socket"`
+
+	// WriteTimeout defines the maximum duration (e.g., "30s") before timing out writes of a response. Note that
+	// responses are not timed out when empty.
+	WriteTimeout string `json:"writeTimeout" mapstructure:"writeTimeout" yaml:"writeTimeout"`
+}
+
+// Address returns the address on which the server listens (i.e., the host and port or the path of the socket).
+func (c *ServerConfig) Address() string {
+
+	if c.Socket != "" {
+		return c.Socket
+	}
+
+	return net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+}
+
+// HTTP returns an http.Server for a handler from the configuration. Note that the TLS configuration of the server is
+// only applied by the http.Server ServeTLS and ListenAndServeTLS methods or by serving the server on the net.Listener
+// returned by Listener.
+func (c *ServerConfig) HTTP(handler http.Handler) (*http.Server, error) {
+
+	config, err := c.Security.TLS()
+	if err != nil {
+		return nil, errors.Wrap(err, "error building tls configuration for server")
+	}
+
+	return c.server(handler, config)
+}
+
+// Listener returns a net.Listener from the configuration that accepts TLS connections when the security is defined. Note
+// that the address of the listener is chosen by the system when the port is zero.
+func (c *ServerConfig) Listener() (net.Listener, error) {
+
+	config, err := c.Security.TLS()
+	if err != nil {
+		return nil, errors.Wrap(err, "error building tls configuration for server")
+	}
+
+	return c.listen(config)
+}
+
+// Serve serves a handler using the configuration until the context is done after which the server is shut down
+// gracefully. Note that nil is returned when the server is shut down and that connections still active when the
+// shutdown timeout passes are closed.
+func (c *ServerConfig) Serve(ctx context.Context, handler http.Handler) error {
+
+	listener, err := c.Listener()
+	if err != nil {
+		return errors.Wrap(err, "error building listener")
+	}
+
+	return c.ServeListener(ctx, listener, handler)
+}
+
+// ServeListener serves a handler on a net.Listener (e.g., one returned by Listener whose address is read when the port is
+// zero) using the configuration until the context is done after which the server is shut down gracefully. Note that the
+// listener is closed when ServeListener returns and that the TLS configuration of the listener is used as is.
+func (c *ServerConfig) ServeListener(ctx context.Context, listener net.Listener, handler http.Handler) error {
+
+	server, err := c.server(handler, nil)
+	if err != nil {
+		listener.Close()
+		return errors.Wrap(err, "error building server")
+	}
+
+	shutdownTimeout, err := parseTimeout("shutdown", c.ShutdownTimeout)
+	if err != nil {
+		listener.Close()
+		return err
+	}
+
+	address := listener.Addr().String()
+
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(listener)
+	}()
+
+	select {
+	case err := <-served:
+		return errors.Wrapf(err, "error serving on [%s]", address)
+	case <-ctx.Done():
+	}
+
+	shutdown := context.Background()
+	if shutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		shutdown, cancel = context.WithTimeout(shutdown, shutdownTimeout)
+		defer cancel()
+	}
+
+	err = server.Shutdown(shutdown)
+	if err != nil {
+		server.Close()
+		return errors.Wrapf(err, "error shutting down server on [%s]", address)
+	}
+
+	return nil
+}
+
+// listen returns a net.Listener for the address of the configuration that accepts TLS connections when the tls.Config
+// is not nil.
+func (c *ServerConfig) listen(config *tls.Config) (net.Listener, error) {
+
+	network := "tcp"
+	if c.Socket != "" {
+
+		network = "unix"
+
+		info, err := os.Stat(c.Socket)
+		if err == nil && info.Mode()&os.ModeSocket != 0 {
+			err = os.Remove(c.Socket)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error removing existing socket [%s]", c.Socket)
+			}
+		}
+	}
+
+	listener, err := net.Listen(network, c.Address())
+	if err != nil {
+		return nil, errors.Wrapf(err, "error listening on [%s]", c.Address())
+	}
+
+	if config == nil {
+		return listener, nil
+	}
+
+	return tls.NewListener(listener, config), nil
+}
+
+// server returns an http.Server for a handler and tls.Config from the configuration.
+func (c *ServerConfig) server(handler http.Handler, config *tls.Config) (*http.Server, error) {
+
+	idleTimeout, err := parseTimeout("idle", c.IdleTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	readTimeout, err := parseTimeout("read", c.ReadTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	writeTimeout, err := parseTimeout("write", c.WriteTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	server := &http.Server{
+		Addr:         c.Address(),
+		Handler:      handler,
+		IdleTimeout:  idleTimeout,
+		ReadTimeout:  readTimeout,
+		TLSConfig:    config,
+		WriteTimeout: writeTimeout,
+	}
+
+	return server, nil
+}
+
+// parseTimeout parses a timeout of the configuration. Note that an empty value returns zero.
+func parseTimeout(name string, value string) (time.Duration, error) {
+
+	if value == "" {
+		return 0, nil
+	}
+
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, errors.Wrapf(err, "error parsing %s timeout [%s]", name, value)
+	}
+
+	return timeout, nil
+}
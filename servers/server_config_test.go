@@ -0,0 +1,323 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package servers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/greymatter-io/nautls/clients"
+	"github.com/greymatter-io/nautls/encoding"
+	"github.com/greymatter-io/nautls/identities"
+	"github.com/greymatter-io/nautls/internal/tests/fixtures"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// get returns the body of a plaintext request to a path of a listener.
+func get(client *http.Client, address string) (string, error) {
+
+	response, err := client.Get(fmt.Sprintf("http://%s/", address))
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	return string(body), err
+}
+
+// hello is an http.Handler that responds with a greeting.
+var hello = http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+	fmt.Fprint(writer, "hello")
+})
+
+func TestServerConfig(t *testing.T) {
+
+	Convey("When ServerConfig", t, func() {
+
+		Convey(".Address is invoked with a host and port", func() {
+
+			config := &ServerConfig{Host: "localhost", Port: 8443}
+
+			Convey("it returns the host and port", func() {
+				So(config.Address(), ShouldEqual, "localhost:8443")
+			})
+		})
+
+		Convey(".Address is invoked with a socket", func() {
+
+			config := &ServerConfig{Host: "localhost", Port: 8443, Socket: "/var/run/nautls.sock"}
+
+			Convey("it returns the socket", func() {
+				So(config.Address(), ShouldEqual, "/var/run/nautls.sock")
+			})
+		})
+
+		Convey(".HTTP is invoked with timeouts", func() {
+
+			server, err := (&ServerConfig{
+				Host:         "localhost",
+				IdleTimeout:  "2m",
+				Port:         8080,
+				ReadTimeout:  "30s",
+				WriteTimeout: "45s",
+			}).HTTP(hello)
+
+			Convey("it returns a nil error", func() {
+				So(err, ShouldBeNil)
+			})
+
+			Convey("it returns a plaintext server with the address and timeouts", func() {
+				So(server.Addr, ShouldEqual, "localhost:8080")
+				So(server.IdleTimeout, ShouldEqual, 2*time.Minute)
+				So(server.ReadTimeout, ShouldEqual, 30*time.Second)
+				So(server.WriteTimeout, ShouldEqual, 45*time.Second)
+				So(server.TLSConfig, ShouldBeNil)
+			})
+		})
+
+		Convey(".HTTP is invoked with an invalid timeout", func() {
+
+			server, err := (&ServerConfig{ReadTimeout: "soon"}).HTTP(hello)
+
+			Convey("it returns a non-nil error", func() {
+				So(err, ShouldNotBeNil)
+			})
+
+			Convey("it returns a nil server", func() {
+				So(server, ShouldBeNil)
+			})
+		})
+
+		Convey(".HTTP is invoked with invalid security", func() {
+
+			_, err := (&ServerConfig{Security: &Configuration{Certificate: "base64:///invalid"}}).HTTP(hello)
+
+			Convey("it returns a non-nil error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+
+		Convey(".Listener is invoked with a socket", func() {
+
+			socket := filepath.Join(t.TempDir(), "nautls.sock")
+
+			listener, err := (&ServerConfig{Socket: socket}).Listener()
+			So(err, ShouldBeNil)
+			listener.Close()
+
+			Convey("and again at the same path", func() {
+
+				listener, err := (&ServerConfig{Socket: socket}).Listener()
+				So(err, ShouldBeNil)
+
+				server := &http.Server{Handler: hello}
+				go server.Serve(listener)
+				defer server.Close()
+
+				client := &http.Client{Transport: &http.Transport{
+					DialContext: func(ctx context.Context, network string, address string) (net.Conn, error) {
+						return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+					},
+				}}
+
+				body, err := get(client, "nautls")
+
+				Convey("it serves on the socket", func() {
+					So(err, ShouldBeNil)
+					So(body, ShouldEqual, "hello")
+				})
+			})
+		})
+
+		Convey(".Listener is invoked without a port", func() {
+
+			listener, err := (&ServerConfig{Host: "localhost"}).Listener()
+			So(err, ShouldBeNil)
+			defer listener.Close()
+
+			Convey("it listens on a port chosen by the system", func() {
+				So(listener.Addr().(*net.TCPAddr).Port, ShouldNotEqual, 0)
+			})
+		})
+
+		Convey(".ServeListener is invoked", func() {
+
+			config := &ServerConfig{Host: "localhost"}
+
+			listener, err := config.Listener()
+			So(err, ShouldBeNil)
+			address := listener.Addr().String()
+
+			started := make(chan struct{})
+			release := make(chan struct{})
+
+			handler := http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				close(started)
+				<-release
+				fmt.Fprint(writer, "hello")
+			})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			served := make(chan error, 1)
+			go func() {
+				served <- config.ServeListener(ctx, listener, handler)
+			}()
+
+			responded := make(chan string, 1)
+			go func() {
+				body, err := get(http.DefaultClient, address)
+				if err == nil {
+					responded <- body
+				}
+			}()
+
+			<-started
+			cancel()
+
+			Convey("it completes active requests before returning", func() {
+
+				select {
+				case <-served:
+					t.Fatal("server returned before the active request completed")
+				case <-time.After(50 * time.Millisecond):
+				}
+
+				close(release)
+
+				So(<-responded, ShouldEqual, "hello")
+				So(<-served, ShouldBeNil)
+			})
+		})
+
+		Convey(".ServeListener is invoked with a shutdown timeout", func() {
+
+			config := &ServerConfig{Host: "localhost", ShutdownTimeout: "50ms"}
+
+			listener, err := config.Listener()
+			So(err, ShouldBeNil)
+			address := listener.Addr().String()
+
+			started := make(chan struct{})
+			release := make(chan struct{})
+			defer close(release)
+
+			handler := http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				close(started)
+				<-release
+			})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			served := make(chan error, 1)
+			go func() {
+				served <- config.ServeListener(ctx, listener, handler)
+			}()
+
+			go get(http.DefaultClient, address)
+
+			<-started
+			cancel()
+
+			Convey("it returns an error when active requests outlast the timeout", func() {
+				So(<-served, ShouldNotBeNil)
+			})
+		})
+
+		Convey(".Serve is invoked with a done context", func() {
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			err := (&ServerConfig{Host: "localhost"}).Serve(ctx, hello)
+
+			Convey("it shuts the server down and returns a nil error", func() {
+				So(err, ShouldBeNil)
+			})
+		})
+
+		Convey(".Serve is invoked with an address in use", func() {
+
+			listener, err := net.Listen("tcp", "localhost:0")
+			So(err, ShouldBeNil)
+			defer listener.Close()
+
+			err = (&ServerConfig{Host: "localhost", Port: listener.Addr().(*net.TCPAddr).Port}).Serve(context.Background(), hello)
+
+			Convey("it returns a non-nil error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
+
+func TestServerConfigSecurity(t *testing.T) {
+
+	authority := fixtures.MustAuthority(t, "NauTLS (Authority)", identities.ECDSA)
+	authorities := []string{fixtures.Base64Resource(encoding.PEMEncodeCertificate(authority.Certificate))}
+
+	serverCertificate, serverKey := fixtures.MustIssueServer(t, authority, identities.ECDSA, "localhost", "localhost")
+
+	Convey("When ServerConfig serves with security", t, func() {
+
+		config := NewServerBuilder().
+			WithHost("localhost").
+			WithSecurity(&Configuration{Certificate: serverCertificate, Key: serverKey}).
+			Build()
+
+		listener, err := config.Listener()
+		So(err, ShouldBeNil)
+		address := fmt.Sprintf("localhost:%d", listener.Addr().(*net.TCPAddr).Port)
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		served := make(chan error, 1)
+		go func() {
+			served <- config.ServeListener(ctx, listener, http.NotFoundHandler())
+		}()
+
+		client, err := (&clients.Configuration{Authorities: authorities}).HTTP()
+		So(err, ShouldBeNil)
+
+		Convey("it accepts tls connections", func() {
+			So(request(client, address), ShouldBeNil)
+		})
+
+		Convey("it rejects plaintext requests", func() {
+
+			response, err := http.Get(fmt.Sprintf("http://%s", address))
+			if err == nil {
+				response.Body.Close()
+			}
+
+			So(err != nil || response.StatusCode == http.StatusBadRequest, ShouldBeTrue)
+		})
+
+		Reset(func() {
+			cancel()
+			So(<-served, ShouldBeNil)
+		})
+	})
+}
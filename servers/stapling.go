@@ -0,0 +1,83 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package servers
+
+import (
+	"crypto/tls"
+	"sync"
+
+	"github.com/greymatter-io/nautls/builders"
+)
+
+// staplers reports the failures of the staplers built for a tls.Config and collects them so that they can be closed
+// when the tls.Config is replaced.
+type staplers struct {
+	built   []*builders.Stapler
+	failure func(error)
+}
+
+// build returns a stapler for certificates that reports its failures to the failure function when it is not nil and
+// starts fetching the staples in the background.
+func (s *staplers) build(certificates []tls.Certificate, staple string) (*builders.Stapler, error) {
+
+	stapler, err := builders.BuildStapler(certificates, staple)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.failure != nil {
+		stapler.OnError(s.failure)
+	}
+
+	s.built = append(s.built, stapler)
+
+	stapler.Refresh()
+
+	return stapler, nil
+}
+
+// close closes the collected staplers.
+func (s *staplers) close() {
+	for _, stapler := range s.built {
+		stapler.Close()
+	}
+}
+
+// reloadable returns a build function for a builders.Reloader from the build function of a configuration that closes
+// the staplers of the tls.Config replaced by each rebuild and those of a failed rebuild.
+func reloadable(build func(*staplers) (*tls.Config, error)) func(func(error)) (*tls.Config, error) {
+
+	var mutex sync.Mutex
+	current := &staplers{}
+
+	return func(failure func(error)) (*tls.Config, error) {
+
+		built := &staplers{failure: failure}
+
+		config, err := build(built)
+		if err != nil {
+			built.close()
+			return nil, err
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		current.close()
+		current = built
+
+		return config, nil
+	}
+}
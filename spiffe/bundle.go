@@ -0,0 +1,177 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spiffe
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// x509SVIDUse defines the JWK use of X.509-SVID authorities within a SPIFFE bundle.
+const x509SVIDUse = "x509-svid"
+
+// Bundle defines the X.509-SVID authorities of a SPIFFE trust bundle (i.e., the JWK Set based document defined by the
+// SPIFFE Trust Domain and Bundle specification). Note that JWT-SVID authorities are ignored.
+type Bundle struct {
+
+	// Authorities defines the X.509-SVID authorities of the trust domain.
+	Authorities []*x509.Certificate
+
+	// RefreshHint defines how often consumers should check for updates to the bundle or zero if not defined.
+	RefreshHint time.Duration
+
+	// Sequence defines the sequence number of the bundle which is incremented each time the bundle changes.
+	Sequence uint64
+}
+
+// document defines the serialized representation of a bundle.
+type document struct {
+	Keys        []key  `json:"keys"`
+	RefreshHint int64  `json:"spiffe_refresh_hint,omitempty"`
+	Sequence    uint64 `json:"spiffe_sequence,omitempty"`
+}
+
+// key defines the serialized representation of a JWK within a bundle.
+type key struct {
+	Curve    string   `json:"crv,omitempty"`
+	Exponent string   `json:"e,omitempty"`
+	Modulus  string   `json:"n,omitempty"`
+	Type     string   `json:"kty"`
+	Use      string   `json:"use"`
+	X        string   `json:"x,omitempty"`
+	X5C      []string `json:"x5c,omitempty"`
+	Y        string   `json:"y,omitempty"`
+}
+
+// IsBundle returns true if bytes appear to hold a SPIFFE bundle (i.e., a JSON object) rather than PEM encoded data.
+func IsBundle(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("{"))
+}
+
+// ParseBundle returns the bundle represented by a SPIFFE bundle document or an error. Each "x509-svid" key must hold
+// exactly one certificate in its "x5c" member.
+func ParseBundle(data []byte) (*Bundle, error) {
+
+	var parsed document
+
+	err := json.Unmarshal(data, &parsed)
+	if err != nil {
+		return nil, errors.Wrap(err, "error unmarshalling spiffe bundle")
+	}
+
+	bundle := &Bundle{
+		Authorities: []*x509.Certificate{},
+		RefreshHint: time.Duration(parsed.RefreshHint) * time.Second,
+		Sequence:    parsed.Sequence,
+	}
+
+	for index, key := range parsed.Keys {
+
+		if key.Use != x509SVIDUse {
+			continue
+		}
+
+		if len(key.X5C) != 1 {
+			return nil, errors.New(fmt.Sprintf("error parsing spiffe bundle key [%d] with [%d] certificates", index, len(key.X5C)))
+		}
+
+		raw, err := base64.StdEncoding.DecodeString(key.X5C[0])
+		if err != nil {
+			return nil, errors.Wrapf(err, "error decoding spiffe bundle key [%d]", index)
+		}
+
+		certificate, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error parsing spiffe bundle key [%d]", index)
+		}
+
+		bundle.Authorities = append(bundle.Authorities, certificate)
+	}
+
+	return bundle, nil
+}
+
+// Marshal returns the SPIFFE bundle document of the bundle or an error if the public key of an authority is not an RSA,
+// ECDSA or Ed25519 key.
+func (b *Bundle) Marshal() ([]byte, error) {
+
+	serialized := document{
+		Keys:        []key{},
+		RefreshHint: int64(b.RefreshHint / time.Second),
+		Sequence:    b.Sequence,
+	}
+
+	for _, authority := range b.Authorities {
+
+		key, err := authorityKey(authority)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error encoding spiffe bundle key for [%s]", authority.Subject.String())
+		}
+
+		serialized.Keys = append(serialized.Keys, key)
+	}
+
+	data, err := json.MarshalIndent(serialized, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "error marshalling spiffe bundle")
+	}
+
+	return data, nil
+}
+
+// authorityKey returns the JWK of an authority.
+func authorityKey(authority *x509.Certificate) (key, error) {
+
+	encoded := key{Use: x509SVIDUse, X5C: []string{base64.StdEncoding.EncodeToString(authority.Raw)}}
+
+	switch public := authority.PublicKey.(type) {
+	case *rsa.PublicKey:
+
+		encoded.Type = "RSA"
+		encoded.Modulus = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		encoded.Exponent = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+
+	case *ecdsa.PublicKey:
+
+		// the coordinates are encoded at the fixed size of the curve (e.g., P-224 which crypto/ecdh does not support)
+		size := (public.Curve.Params().BitSize + 7) / 8
+
+		encoded.Type = "EC"
+		encoded.Curve = public.Curve.Params().Name
+		encoded.X = base64.RawURLEncoding.EncodeToString(public.X.FillBytes(make([]byte, size)))
+		encoded.Y = base64.RawURLEncoding.EncodeToString(public.Y.FillBytes(make([]byte, size)))
+
+	case ed25519.PublicKey:
+
+		encoded.Type = "OKP"
+		encoded.Curve = "Ed25519"
+		encoded.X = base64.RawURLEncoding.EncodeToString(public)
+
+	default:
+		return key{}, errors.New(fmt.Sprintf("unsupported public key type [%T]", authority.PublicKey))
+	}
+
+	return encoded, nil
+}
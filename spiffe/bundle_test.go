@@ -0,0 +1,166 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spiffe_test
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/greymatter-io/nautls/identities"
+	"github.com/greymatter-io/nautls/internal/tests/fixtures"
+	"github.com/greymatter-io/nautls/spiffe"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestBundle(t *testing.T) {
+
+	authorities := []*x509.Certificate{
+		fixtures.MustAuthority(t, "ecdsa", identities.ECDSA).Certificate,
+		fixtures.MustAuthority(t, "rsa", identities.RSA).Certificate,
+		fixtures.MustAuthority(t, "ed25519", identities.Ed25519).Certificate,
+	}
+
+	Convey("When Bundle", t, func() {
+
+		bundle := &spiffe.Bundle{Authorities: authorities, RefreshHint: 5 * time.Minute, Sequence: 42}
+
+		Convey(".Marshal is invoked", func() {
+
+			data, err := bundle.Marshal()
+			So(err, ShouldBeNil)
+
+			var serialized map[string]interface{}
+			So(json.Unmarshal(data, &serialized), ShouldBeNil)
+
+			Convey("it writes the spiffe members", func() {
+				So(serialized["spiffe_sequence"], ShouldEqual, 42)
+				So(serialized["spiffe_refresh_hint"], ShouldEqual, 300)
+				So(serialized["keys"], ShouldHaveLength, 3)
+			})
+
+			Convey("it writes x509-svid keys with their parameters", func() {
+
+				keys := serialized["keys"].([]interface{})
+
+				first := keys[0].(map[string]interface{})
+				So(first["use"], ShouldEqual, "x509-svid")
+				So(first["kty"], ShouldEqual, "EC")
+				So(first["crv"], ShouldEqual, "P-256")
+				So(first["x"], ShouldHaveLength, 43)
+				So(first["y"], ShouldHaveLength, 43)
+
+				So(keys[1].(map[string]interface{})["kty"], ShouldEqual, "RSA")
+				So(keys[1].(map[string]interface{})["e"], ShouldEqual, "AQAB")
+				So(keys[2].(map[string]interface{})["kty"], ShouldEqual, "OKP")
+			})
+
+			Convey("and #ParseBundle is invoked", func() {
+
+				parsed, err := spiffe.ParseBundle(data)
+
+				Convey("it returns the original bundle", func() {
+					So(err, ShouldBeNil)
+					So(parsed.Sequence, ShouldEqual, 42)
+					So(parsed.RefreshHint, ShouldEqual, 5*time.Minute)
+					So(parsed.Authorities, ShouldHaveLength, 3)
+
+					for index, authority := range parsed.Authorities {
+						So(authority.Equal(authorities[index]), ShouldBeTrue)
+					}
+				})
+			})
+
+			Convey("and #IsBundle is invoked", func() {
+				So(spiffe.IsBundle(data), ShouldBeTrue)
+				So(spiffe.IsBundle([]byte("-----BEGIN CERTIFICATE-----")), ShouldBeFalse)
+			})
+		})
+	})
+
+	Convey("When Bundle has a P-224 authority", t, func() {
+
+		authority, err := identities.Self(identities.Template{
+			BasicConstraintsValid: true,
+			IsCA:                  true,
+			KeyAlgorithm:          identities.ECDSA,
+			KeySize:               224,
+			KeyUsage:              x509.KeyUsageCertSign,
+			NotAfter:              time.Now().AddDate(1, 0, 0),
+			NotBefore:             time.Now(),
+			SerialNumber:          big.NewInt(1),
+			Subject:               pkix.Name{CommonName: "p224"},
+		})
+		So(err, ShouldBeNil)
+
+		data, err := (&spiffe.Bundle{Authorities: []*x509.Certificate{authority.Certificate}}).Marshal()
+
+		Convey(".Marshal writes the coordinates at the size of the curve", func() {
+
+			So(err, ShouldBeNil)
+
+			var serialized map[string]interface{}
+			So(json.Unmarshal(data, &serialized), ShouldBeNil)
+
+			key := serialized["keys"].([]interface{})[0].(map[string]interface{})
+			So(key["crv"], ShouldEqual, "P-224")
+			So(key["x"], ShouldHaveLength, 38)
+			So(key["y"], ShouldHaveLength, 38)
+		})
+	})
+
+	Convey("When #ParseBundle is invoked", t, func() {
+
+		Convey("with jwt-svid keys", func() {
+
+			bundle, err := spiffe.ParseBundle([]byte(`{"keys": [{"use": "jwt-svid", "kty": "EC", "kid": "a"}], "spiffe_sequence": 1}`))
+
+			Convey("it ignores the keys", func() {
+				So(err, ShouldBeNil)
+				So(bundle.Authorities, ShouldBeEmpty)
+			})
+		})
+
+		Convey("with an x509-svid key without certificates", func() {
+
+			_, err := spiffe.ParseBundle([]byte(`{"keys": [{"use": "x509-svid", "kty": "EC"}]}`))
+
+			Convey("it returns a non-nil error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+
+		Convey("with an x509-svid key holding an invalid certificate", func() {
+
+			_, err := spiffe.ParseBundle([]byte(`{"keys": [{"use": "x509-svid", "kty": "EC", "x5c": ["AAAA"]}]}`))
+
+			Convey("it returns a non-nil error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+
+		Convey("with invalid json", func() {
+
+			_, err := spiffe.ParseBundle([]byte(`{"keys":`))
+
+			Convey("it returns a non-nil error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
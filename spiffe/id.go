@@ -0,0 +1,172 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package spiffe provides parsing and matching of SPIFFE IDs (see https://github.com/spiffe/spiffe) carried as URI
+// subject alternative names by X.509-SVID certificates.
+package spiffe
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Scheme defines the URI scheme of SPIFFE IDs.
+const Scheme = "spiffe"
+
+// ID defines a parsed SPIFFE ID (e.g., "spiffe://example.org/ns/production/sa/web").
+type ID struct {
+
+	// TrustDomain defines the trust domain of the ID (e.g., "example.org").
+	TrustDomain string
+
+	// Path defines the path of the ID including the leading slash (e.g., "/ns/production/sa/web") or an empty string for
+	// the ID of the trust domain itself.
+	Path string
+}
+
+// ParseID returns the SPIFFE ID represented by a string or an error if the string is not a valid SPIFFE ID.
+func ParseID(value string) (ID, error) {
+
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return ID{}, errors.Wrapf(err, "error parsing spiffe id [%s]", value)
+	}
+
+	return FromURI(parsed)
+}
+
+// FromURI returns the SPIFFE ID represented by a URI or an error if the URI is not a valid SPIFFE ID.
+func FromURI(uri *url.URL) (ID, error) {
+
+	if uri.Scheme != Scheme {
+		return ID{}, errors.New(fmt.Sprintf("error parsing spiffe id with scheme [%s]", uri.Scheme))
+	}
+
+	if uri.User != nil || uri.Port() != "" || uri.RawQuery != "" || uri.Fragment != "" || uri.Opaque != "" {
+		return ID{}, errors.New(fmt.Sprintf("error parsing spiffe id with user, port, query or fragment [%s]", uri))
+	}
+
+	err := validateTrustDomain(uri.Host)
+	if err != nil {
+		return ID{}, errors.Wrapf(err, "error parsing spiffe id [%s]", uri)
+	}
+
+	err = validatePath(uri.EscapedPath())
+	if err != nil {
+		return ID{}, errors.Wrapf(err, "error parsing spiffe id [%s]", uri)
+	}
+
+	return ID{TrustDomain: uri.Host, Path: uri.EscapedPath()}, nil
+}
+
+// FromCertificate returns the SPIFFE ID of a certificate or an error if the certificate does not have exactly one URI
+// subject alternative name holding a valid SPIFFE ID.
+func FromCertificate(certificate *x509.Certificate) (ID, error) {
+
+	if len(certificate.URIs) != 1 {
+		return ID{}, errors.New(fmt.Sprintf("error parsing spiffe id from certificate with [%d] uris", len(certificate.URIs)))
+	}
+
+	return FromURI(certificate.URIs[0])
+}
+
+// FromConnectionState returns the SPIFFE ID of the verified peer certificate of a connection or an error if the peer
+// certificate was not verified or does not hold a SPIFFE ID.
+func FromConnectionState(state tls.ConnectionState) (ID, error) {
+
+	if len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return ID{}, errors.New("error parsing spiffe id from connection without a verified peer certificate")
+	}
+
+	return FromCertificate(state.VerifiedChains[0][0])
+}
+
+// String returns the string representation of the ID (e.g., "spiffe://example.org/ns/production/sa/web").
+func (i ID) String() string {
+	return fmt.Sprintf("%s://%s%s", Scheme, i.TrustDomain, i.Path)
+}
+
+// URL returns the URL representation of the ID (e.g., for use as a URI subject alternative name).
+func (i ID) URL() *url.URL {
+	return &url.URL{Scheme: Scheme, Host: i.TrustDomain, Path: i.Path}
+}
+
+// MemberOf returns true if the ID belongs to a trust domain.
+func (i ID) MemberOf(trustDomain string) bool {
+	return i.TrustDomain == trustDomain
+}
+
+// validateTrustDomain returns an error if a trust domain contains characters other than lowercase letters, digits,
+// dots, dashes and underscores.
+func validateTrustDomain(trustDomain string) error {
+
+	if trustDomain == "" {
+		return errors.New("trust domain is empty")
+	}
+
+	for _, character := range trustDomain {
+		if !validCharacter(character, false) {
+			return errors.New(fmt.Sprintf("trust domain contains invalid character [%c]", character))
+		}
+	}
+
+	return nil
+}
+
+// validatePath returns an error if a path has empty, dot or dot dot segments, a trailing slash or characters other
+// than letters, digits, dots, dashes and underscores.
+func validatePath(path string) error {
+
+	if path == "" {
+		return nil
+	}
+
+	if !strings.HasPrefix(path, "/") {
+		return errors.New("path does not begin with a slash")
+	}
+
+	for _, segment := range strings.Split(path[1:], "/") {
+
+		if segment == "" || segment == "." || segment == ".." {
+			return errors.New(fmt.Sprintf("path contains invalid segment [%s]", segment))
+		}
+
+		for _, character := range segment {
+			if !validCharacter(character, true) {
+				return errors.New(fmt.Sprintf("path contains invalid character [%c]", character))
+			}
+		}
+	}
+
+	return nil
+}
+
+// validCharacter returns true if a character is a lowercase letter, a digit, a dot, a dash, an underscore or, when
+// allowed, an uppercase letter.
+func validCharacter(character rune, uppercase bool) bool {
+
+	switch {
+	case character >= 'a' && character <= 'z', character >= '0' && character <= '9':
+		return true
+	case character >= 'A' && character <= 'Z':
+		return uppercase
+	}
+
+	return strings.ContainsRune(".-_", character)
+}
@@ -0,0 +1,127 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spiffe
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/url"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestID(t *testing.T) {
+
+	Convey("When #ParseID is invoked", t, func() {
+
+		Convey("with a valid id", func() {
+
+			id, err := ParseID("spiffe://example.org/ns/production/sa/web")
+
+			Convey("it returns the trust domain and path", func() {
+				So(err, ShouldBeNil)
+				So(id, ShouldResemble, ID{TrustDomain: "example.org", Path: "/ns/production/sa/web"})
+				So(id.String(), ShouldEqual, "spiffe://example.org/ns/production/sa/web")
+				So(id.MemberOf("example.org"), ShouldBeTrue)
+			})
+		})
+
+		Convey("with the id of a trust domain", func() {
+
+			id, err := ParseID("spiffe://example.org")
+
+			Convey("it returns an empty path", func() {
+				So(err, ShouldBeNil)
+				So(id, ShouldResemble, ID{TrustDomain: "example.org"})
+			})
+		})
+
+		for _, invalid := range []string{
+			"https://example.org/web",
+			"spiffe://",
+			"spiffe://Example.org/web",
+			"spiffe://example.org:443/web",
+			"spiffe://user@example.org/web",
+			"spiffe://example.org/web?query",
+			"spiffe://example.org/web#fragment",
+			"spiffe://example.org/web/",
+			"spiffe://example.org//web",
+			"spiffe://example.org/../web",
+			"spiffe://example.org/we%20b",
+		} {
+
+			invalid := invalid
+
+			Convey("with the invalid id "+invalid, func() {
+
+				_, err := ParseID(invalid)
+
+				Convey("it returns a non-nil error", func() {
+					So(err, ShouldNotBeNil)
+				})
+			})
+		}
+	})
+
+	Convey("When #FromCertificate is invoked", t, func() {
+
+		uri, _ := url.Parse("spiffe://example.org/web")
+
+		Convey("with a certificate holding a spiffe id", func() {
+
+			id, err := FromCertificate(&x509.Certificate{URIs: []*url.URL{uri}})
+
+			Convey("it returns the id", func() {
+				So(err, ShouldBeNil)
+				So(id.String(), ShouldEqual, "spiffe://example.org/web")
+			})
+		})
+
+		Convey("with a certificate holding several uris", func() {
+
+			_, err := FromCertificate(&x509.Certificate{URIs: []*url.URL{uri, uri}})
+
+			Convey("it returns a non-nil error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+
+	Convey("When #FromConnectionState is invoked", t, func() {
+
+		uri, _ := url.Parse("spiffe://example.org/web")
+		certificate := &x509.Certificate{URIs: []*url.URL{uri}}
+
+		Convey("with a verified peer certificate", func() {
+
+			id, err := FromConnectionState(tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{certificate}}})
+
+			Convey("it returns the id", func() {
+				So(err, ShouldBeNil)
+				So(id.String(), ShouldEqual, "spiffe://example.org/web")
+			})
+		})
+
+		Convey("with an unverified peer certificate", func() {
+
+			_, err := FromConnectionState(tls.ConnectionState{PeerCertificates: []*x509.Certificate{certificate}})
+
+			Convey("it returns a non-nil error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
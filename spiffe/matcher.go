@@ -0,0 +1,120 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spiffe
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Matcher provides a serializable representation of a rule matching SPIFFE IDs. Exactly one of the fields must be
+// defined.
+type Matcher struct {
+
+	// ID defines a SPIFFE ID that is matched exactly (e.g., "spiffe://example.org/ns/production/sa/web").
+	ID string `json:"id" mapstructure:"id" yaml:"id"`
+
+	// Prefix defines a SPIFFE ID that matches itself and the IDs beneath its path at segment boundaries (e.g.,
+	// "spiffe://example.org/ns/production" matches "spiffe://example.org/ns/production/sa/web" but not
+	// "spiffe://example.org/ns/production-eu").
+	Prefix string `json:"prefix" mapstructure:"prefix" yaml:"prefix"`
+
+	// TrustDomain defines a trust domain that matches any ID it contains (e.g., "example.org").
+	TrustDomain string `json:"trustDomain" mapstructure:"trustDomain" yaml:"trustDomain"`
+}
+
+// Validate returns an error if the matcher does not define exactly one valid rule.
+func (m Matcher) Validate() error {
+
+	defined := 0
+	for _, value := range []string{m.ID, m.Prefix, m.TrustDomain} {
+		if value != "" {
+			defined++
+		}
+	}
+
+	if defined != 1 {
+		return errors.New("error validating spiffe matcher as exactly one of id, prefix or trust domain must be defined")
+	}
+
+	if m.ID != "" {
+		_, err := ParseID(m.ID)
+		return errors.Wrap(err, "error validating spiffe matcher id")
+	}
+
+	if m.Prefix != "" {
+		_, err := ParseID(m.Prefix)
+		return errors.Wrap(err, "error validating spiffe matcher prefix")
+	}
+
+	return errors.Wrapf(validateTrustDomain(m.TrustDomain), "error validating spiffe matcher trust domain [%s]", m.TrustDomain)
+}
+
+// Match returns true if an ID matches the rule of the matcher.
+func (m Matcher) Match(id ID) bool {
+
+	switch {
+	case m.ID != "":
+
+		expected, err := ParseID(m.ID)
+		return err == nil && expected == id
+
+	case m.Prefix != "":
+
+		prefix, err := ParseID(m.Prefix)
+		if err != nil || !id.MemberOf(prefix.TrustDomain) {
+			return false
+		}
+
+		return id.Path == prefix.Path || strings.HasPrefix(id.Path, strings.TrimSuffix(prefix.Path, "/")+"/")
+
+	case m.TrustDomain != "":
+
+		return id.MemberOf(m.TrustDomain)
+	}
+
+	return false
+}
+
+// Matchers defines a list of matchers of which any may match.
+type Matchers []Matcher
+
+// Validate returns an error if any of the matchers is invalid.
+func (m Matchers) Validate() error {
+
+	for index, matcher := range m {
+
+		err := matcher.Validate()
+		if err != nil {
+			return errors.Wrapf(err, "error validating spiffe matcher [%d]", index)
+		}
+	}
+
+	return nil
+}
+
+// Match returns nil if an ID matches any of the matchers or an error naming the ID.
+func (m Matchers) Match(id ID) error {
+
+	for _, matcher := range m {
+		if matcher.Match(id) {
+			return nil
+		}
+	}
+
+	return errors.New(fmt.Sprintf("error matching spiffe id [%s]", id))
+}
@@ -0,0 +1,94 @@
+// Copyright 2020 Decipher Technology Studios
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spiffe
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMatcher(t *testing.T) {
+
+	Convey("When Matcher", t, func() {
+
+		id, _ := ParseID("spiffe://example.org/ns/production/sa/web")
+
+		Convey(".Match is invoked", func() {
+
+			for matcher, expected := range map[Matcher]bool{
+				{ID: "spiffe://example.org/ns/production/sa/web"}: true,
+				{ID: "spiffe://example.org/ns/production/sa/api"}: false,
+				{ID: "spiffe://example.com/ns/production/sa/web"}: false,
+				{Prefix: "spiffe://example.org/ns/production"}:    true,
+				{Prefix: "spiffe://example.org/ns/prod"}:          false,
+				{Prefix: "spiffe://example.org"}:                  true,
+				{Prefix: "spiffe://example.com/ns/production"}:    false,
+				{TrustDomain: "example.org"}:                      true,
+				{TrustDomain: "example.com"}:                      false,
+			} {
+
+				matcher, expected := matcher, expected
+
+				Convey("for "+matcher.ID+matcher.Prefix+matcher.TrustDomain, func() {
+					So(matcher.Match(id), ShouldEqual, expected)
+				})
+			}
+		})
+
+		Convey(".Validate is invoked", func() {
+
+			Convey("with exactly one valid rule", func() {
+				So(Matcher{TrustDomain: "example.org"}.Validate(), ShouldBeNil)
+				So(Matcher{ID: "spiffe://example.org/web"}.Validate(), ShouldBeNil)
+				So(Matcher{Prefix: "spiffe://example.org/ns"}.Validate(), ShouldBeNil)
+			})
+
+			Convey("with no rules", func() {
+				So(Matcher{}.Validate(), ShouldNotBeNil)
+			})
+
+			Convey("with several rules", func() {
+				So(Matcher{ID: "spiffe://example.org/web", TrustDomain: "example.org"}.Validate(), ShouldNotBeNil)
+			})
+
+			Convey("with an invalid rule", func() {
+				So(Matcher{ID: "https://example.org/web"}.Validate(), ShouldNotBeNil)
+				So(Matcher{TrustDomain: "Example.org"}.Validate(), ShouldNotBeNil)
+			})
+		})
+	})
+
+	Convey("When Matchers.Match is invoked", t, func() {
+
+		id, _ := ParseID("spiffe://example.org/web")
+		matchers := Matchers{{TrustDomain: "example.com"}, {ID: "spiffe://example.org/web"}}
+
+		Convey("with a matching id", func() {
+			So(matchers.Match(id), ShouldBeNil)
+		})
+
+		Convey("with an unmatched id", func() {
+
+			other, _ := ParseID("spiffe://example.org/api")
+			err := matchers.Match(other)
+
+			Convey("it returns an error naming the id", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "spiffe://example.org/api")
+			})
+		})
+	})
+}